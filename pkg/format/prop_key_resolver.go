@@ -130,6 +130,22 @@ func GetConfigQueryResolver(config t.ServiceConfig) t.ConfigQueryResolver {
 	return resolver
 }
 
+// KeyAliases returns all the keys of the config property tagged with key, including key itself, or nil if no
+// property is tagged with it
+func (pkr *PropKeyResolver) KeyAliases(key string) []string {
+	field, found := pkr.keyFields[strings.ToLower(key)]
+	if !found {
+		return nil
+	}
+	aliases := make([]string, 0, len(field.Keys))
+	for _, alias := range field.Keys {
+		if alias != "" {
+			aliases = append(aliases, strings.ToLower(alias))
+		}
+	}
+	return aliases
+}
+
 // KeyIsPrimary returns whether the key is the primary (and not an alias)
 func (pkr *PropKeyResolver) KeyIsPrimary(key string) bool {
 	return pkr.keyFields[key].Keys[0] == key
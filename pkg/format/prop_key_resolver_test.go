@@ -36,6 +36,12 @@ var _ = Describe("Prop Key Resolver", func() {
 			})
 		})
 	})
+	Describe("Getting the aliases of a key", func() {
+		It("should return the keys of the prop, ignoring the case", func() {
+			Expect(pkr.KeyAliases("STR")).To(Equal([]string{"str"}))
+			Expect(pkr.KeyAliases("missing")).To(BeNil())
+		})
+	})
 	Describe("Setting default props", func() {
 		When("a default tag are set for a field", func() {
 			It("should have that value as default", func() {
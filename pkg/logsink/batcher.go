@@ -0,0 +1,243 @@
+package logsink
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+)
+
+// batchOptions are the options shared by the Handler and Writer batches
+type batchOptions struct {
+	targets []string
+	tags    []string
+	params  types.Params
+	delay   time.Duration
+	// maxItems and maxBytes are the number of items and text bytes that causes the batch to be sent, if non-zero
+	maxItems int
+	maxBytes int
+	onError  func(results []router.SendResult)
+}
+
+type batcher struct {
+	router  *router.ServiceRouter
+	options batchOptions
+	mutex   sync.Mutex
+	items   []types.MessageItem
+	size    int
+	timer   *time.Timer
+	closed  bool
+	sending sync.WaitGroup
+}
+
+func newBatcher(sr *router.ServiceRouter, options batchOptions) *batcher {
+	return &batcher{router: sr, options: options}
+}
+
+func (b *batcher) add(item types.MessageItem) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	b.items = append(b.items, item)
+	b.size += len(item.Text)
+	if b.full() {
+		b.sendAsync(b.take())
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(b.options.delay, b.flush)
+	}
+	return nil
+}
+
+func (b *batcher) full() bool {
+	return (b.options.maxItems > 0 && len(b.items) >= b.options.maxItems) ||
+		(b.options.maxBytes > 0 && b.size >= b.options.maxBytes)
+}
+
+// flush sends the current batch when the batch delay has passed
+func (b *batcher) flush() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.closed {
+		b.sendAsync(b.take())
+	}
+}
+
+// take returns the items of the current batch and starts a new one. It must be called with the mutex held.
+func (b *batcher) take() []types.MessageItem {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	items := b.items
+	b.items = nil
+	b.size = 0
+	return items
+}
+
+// sendAsync sends items without blocking. It must be called with the mutex held, so that close waits for the send.
+func (b *batcher) sendAsync(items []types.MessageItem) {
+	if len(items) < 1 {
+		return
+	}
+	b.sending.Add(1)
+	go func() {
+		defer b.sending.Done()
+		_ = b.send(items)
+	}()
+}
+
+// sendNow sends the current batch and waits for it, and any other batches that are being sent
+func (b *batcher) sendNow() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return ErrClosed
+	}
+	items := b.take()
+	b.mutex.Unlock()
+
+	var err error
+	if len(items) > 0 {
+		err = b.send(items)
+	}
+	b.sending.Wait()
+	return err
+}
+
+// send sends items to the selected targets, split into chunks that fits within the message limits of each service
+func (b *batcher) send(items []types.MessageItem) error {
+	var results []router.SendResult
+	for _, group := range groupByLimit(b.router.Select(b.options.targets, b.options.tags)) {
+		for _, chunk := range chunkItems(items, group.limit, group.rich) {
+			params := make(types.Params, len(b.options.params))
+			for key, value := range b.options.params {
+				params[key] = value
+			}
+			results = append(results, b.router.SendItemsTo(group.targets, chunk, &params)...)
+		}
+	}
+
+	err := errors.Join(router.Errors(results)...)
+	if err != nil && b.options.onError != nil {
+		b.options.onError(results)
+	}
+	return err
+}
+
+func (b *batcher) close() error {
+	b.mutex.Lock()
+	if b.closed {
+		b.mutex.Unlock()
+		return nil
+	}
+	b.closed = true
+	items := b.take()
+	b.mutex.Unlock()
+
+	var err error
+	if len(items) > 0 {
+		err = b.send(items)
+	}
+	b.sending.Wait()
+	return err
+}
+
+type targetGroup struct {
+	limit   types.MessageLimit
+	rich    bool
+	targets []*router.Target
+}
+
+// groupByLimit groups the targets whose services have the same message limits and rich sender support
+func groupByLimit(targets []*router.Target) []*targetGroup {
+	var groups []*targetGroup
+	for _, target := range targets {
+		limit := types.MessageLimit{}
+		if limiter, isLimiter := target.Service.(types.MessageLimiter); isLimiter {
+			limit = limiter.MessageLimit()
+		}
+		// Rich senders with a single chunk join the item texts, just like the plain senders
+		_, rich := target.Service.(types.RichSender)
+		rich = rich && limit.ChunkCount > 1
+
+		var group *targetGroup
+		for _, existing := range groups {
+			if existing.limit == limit && existing.rich == rich {
+				group = existing
+				break
+			}
+		}
+		if group == nil {
+			group = &targetGroup{limit: limit, rich: rich}
+			groups = append(groups, group)
+		}
+		group.targets = append(group.targets, target)
+	}
+	return groups
+}
+
+// chunkItems splits items into chunks that can be sent using a service with the supplied limits. Items with a text
+// longer than the chunk size are split into several items. The sizes are measured in the unit of the limit. Rich
+// senders use one chunk per item (reserving one for meta data), while other services get the items joined by newlines.
+func chunkItems(items []types.MessageItem, limit types.MessageLimit, rich bool) [][]types.MessageItem {
+	if limit.ChunkSize < 1 {
+		return [][]types.MessageItem{items}
+	}
+
+	maxTotal := limit.TotalChunkSize
+	if maxTotal < 1 {
+		maxTotal = limit.ChunkSize
+	}
+	maxItemSize := min(limit.ChunkSize, maxTotal)
+
+	maxCount := 0
+	separator := 1
+	if rich {
+		maxCount = max(limit.ChunkCount-1, 1)
+		separator = 0
+	}
+
+	var chunks [][]types.MessageItem
+	var chunk []types.MessageItem
+	total := 0
+	for _, item := range items {
+		for _, text := range splitText(item.Text, maxItemSize, limit.Unit) {
+			size := limit.Unit.Length(text)
+			if len(chunk) > 0 {
+				size += separator
+			}
+			if len(chunk) > 0 && (total+size > maxTotal || (maxCount > 0 && len(chunk) >= maxCount)) {
+				chunks = append(chunks, chunk)
+				chunk = nil
+				total = 0
+				size = limit.Unit.Length(text)
+			}
+			part := item
+			part.Text = text
+			chunk = append(chunk, part)
+			total += size
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// splitText splits text into parts that are at most maxSize long when measured in unit, without splitting any
+// grapheme clusters or markup
+func splitText(text string, maxSize int, unit types.LengthUnit) []string {
+	if unit.Length(text) <= maxSize {
+		return []string{text}
+	}
+	partitioner := util.Partitioner{Limits: types.MessageLimit{ChunkSize: maxSize, Unit: unit}}
+	return partitioner.Split(text)
+}
@@ -0,0 +1,182 @@
+// Package logsink sends application logs as notifications using a ServiceRouter
+package logsink
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+const (
+	// DefaultBatchDelay is the time to wait for more records before sending a batch
+	DefaultBatchDelay = time.Second
+	// DefaultBatchSize is the max number of records sent in a single batch
+	DefaultBatchSize = 20
+)
+
+// shoutrrrPackages is the prefix of the functions in the shoutrrr packages. Records logged by them are dropped, since
+// they are (most likely) logged while sending a batch, and would otherwise cause another batch to be sent.
+const shoutrrrPackages = "github.com/dockerutil/shoutrrr/pkg/"
+
+// ErrClosed is returned when logging using a Handler or Writer that has been closed
+var ErrClosed = errors.New("the log sink has been closed")
+
+// HandlerOptions are the options used by a Handler
+type HandlerOptions struct {
+	// Level is the minimum level of the records that are sent, slog.LevelWarn if nil
+	Level slog.Leveler
+	// Targets and Tags selects the router targets that the records are sent to. If both are empty, all the targets
+	// are used.
+	Targets []string
+	Tags    []string
+	// Params are passed to the services for every batch
+	Params types.Params
+	// BatchDelay is the time to wait for more records before sending a batch, DefaultBatchDelay if zero
+	BatchDelay time.Duration
+	// BatchSize is the max number of records sent in a single batch, DefaultBatchSize if zero
+	BatchSize int
+	// OnError is called with the results of the batches that failed for any target. It must not log using the
+	// Handler, since that would send the failure, most likely failing again.
+	OnError func(results []router.SendResult)
+}
+
+// Handler is an slog.Handler that sends the records at or above the configured level to a ServiceRouter.
+// Records are collected into batches, which are sent as MessageItems when BatchDelay has passed since the first
+// record of the batch, or when BatchSize records have been collected. Batches are split to fit within the message
+// limits of the services. Call Close to send any remaining records.
+type Handler struct {
+	batcher *batcher
+	level   slog.Leveler
+	attrs   []types.Field
+	group   string
+}
+
+// NewHandler creates a Handler sending records to sr using the supplied options, which may be nil
+func NewHandler(sr *router.ServiceRouter, options *HandlerOptions) *Handler {
+	if options == nil {
+		options = &HandlerOptions{}
+	}
+
+	level := options.Level
+	if level == nil {
+		level = slog.LevelWarn
+	}
+
+	batch := batchOptions{
+		targets:  options.Targets,
+		tags:     options.Tags,
+		params:   options.Params,
+		delay:    options.BatchDelay,
+		maxItems: options.BatchSize,
+		onError:  options.OnError,
+	}
+	if batch.delay <= 0 {
+		batch.delay = DefaultBatchDelay
+	}
+	if batch.maxItems <= 0 {
+		batch.maxItems = DefaultBatchSize
+	}
+
+	return &Handler{
+		batcher: newBatcher(sr, batch),
+		level:   level,
+	}
+}
+
+// Enabled reports whether records of level are sent by the handler
+func (handler *Handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handler.level.Level()
+}
+
+// Handle adds the record to the current batch, unless it was logged by shoutrrr itself
+func (handler *Handler) Handle(_ context.Context, record slog.Record) error {
+	if loggedByShoutrrr(record.PC) {
+		return nil
+	}
+
+	item := types.MessageItem{
+		Text:      record.Message,
+		Timestamp: record.Time,
+		Level:     levelFromSlog(record.Level),
+		Fields:    append([]types.Field{}, handler.attrs...),
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		item.Fields = appendAttr(item.Fields, handler.group, attr)
+		return true
+	})
+
+	return handler.batcher.add(item)
+}
+
+// WithAttrs returns a Handler that adds attrs as fields to every record
+func (handler *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *handler
+	clone.attrs = append([]types.Field{}, handler.attrs...)
+	for _, attr := range attrs {
+		clone.attrs = appendAttr(clone.attrs, handler.group, attr)
+	}
+	return &clone
+}
+
+// WithGroup returns a Handler that prefixes the keys of the following attributes with name and a dot
+func (handler *Handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+	clone := *handler
+	clone.group = handler.group + name + "."
+	return &clone
+}
+
+// Close sends the current batch and waits for any batches that are still being sent. Records handled after Close
+// returns ErrClosed. Handlers created using WithAttrs and WithGroup share the batches of their parent, so closing
+// any of them closes all of them.
+func (handler *Handler) Close() error {
+	return handler.batcher.close()
+}
+
+func appendAttr(fields []types.Field, prefix string, attr slog.Attr) []types.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendAttr(fields, prefix, groupAttr)
+		}
+		return fields
+	}
+
+	return append(fields, types.Field{Key: prefix + attr.Key, Value: attr.Value.String()})
+}
+
+func levelFromSlog(level slog.Level) types.MessageLevel {
+	switch {
+	case level >= slog.LevelError:
+		return types.Error
+	case level >= slog.LevelWarn:
+		return types.Warning
+	case level >= slog.LevelInfo:
+		return types.Info
+	default:
+		return types.Debug
+	}
+}
+
+func loggedByShoutrrr(pc uintptr) bool {
+	if pc == 0 {
+		return false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return strings.HasPrefix(frame.Function, shoutrrrPackages)
+}
@@ -0,0 +1,137 @@
+package logsink
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/logging"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the slog handler", func() {
+	ctx := context.Background()
+	timestamp := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	newRecord := func(level slog.Level, message string, attrs ...slog.Attr) slog.Record {
+		record := slog.NewRecord(timestamp, level, message, 0)
+		record.AddAttrs(attrs...)
+		return record
+	}
+
+	When("mapping records to message items", func() {
+		var handler *Handler
+		BeforeEach(func() {
+			sr, _ := newRecordedRouter(nil)
+			handler = NewHandler(sr, &HandlerOptions{BatchDelay: time.Hour})
+		})
+		AfterEach(func() {
+			Expect(handler.Close()).To(Succeed())
+		})
+
+		It("should map the message, time and level", func() {
+			Expect(handler.Handle(ctx, newRecord(slog.LevelError+2, "disk full"))).To(Succeed())
+			Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, "disk almost full"))).To(Succeed())
+			Expect(handler.batcher.items).To(Equal([]types.MessageItem{
+				{Text: "disk full", Timestamp: timestamp, Level: types.Error, Fields: []types.Field{}},
+				{Text: "disk almost full", Timestamp: timestamp, Level: types.Warning, Fields: []types.Field{}},
+			}))
+		})
+		It("should map the attributes to fields, prefixed by their groups", func() {
+			var logHandler slog.Handler = handler
+			logHandler = logHandler.WithAttrs([]slog.Attr{slog.String("host", "db1")}).WithGroup("disk")
+			record := newRecord(slog.LevelWarn, "disk almost full",
+				slog.Int("free", 3),
+				slog.Group("mount", slog.String("path", "/var")),
+				slog.Group("", slog.Bool("inlined", true)),
+			)
+			Expect(logHandler.Handle(ctx, record)).To(Succeed())
+			Expect(handler.batcher.items[0].Fields).To(Equal([]types.Field{
+				{Key: "host", Value: "db1"},
+				{Key: "disk.free", Value: "3"},
+				{Key: "disk.mount.path", Value: "/var"},
+				{Key: "disk.inlined", Value: "true"},
+			}))
+		})
+	})
+
+	It("should only be enabled for the configured level and above", func() {
+		sr, _ := newRecordedRouter(nil)
+		handler := NewHandler(sr, nil)
+		Expect(handler.Enabled(ctx, slog.LevelInfo)).To(BeFalse())
+		Expect(handler.Enabled(ctx, slog.LevelWarn)).To(BeTrue())
+
+		handler = NewHandler(sr, &HandlerOptions{Level: slog.LevelDebug})
+		Expect(handler.Enabled(ctx, slog.LevelDebug)).To(BeTrue())
+	})
+
+	It("should send bursts of records as a single batch", func() {
+		sr, recorder := newRecordedRouter(nil)
+		handler := NewHandler(sr, &HandlerOptions{BatchDelay: 50 * time.Millisecond})
+		for _, message := range []string{"one", "two", "three"} {
+			Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, message))).To(Succeed())
+		}
+		Eventually(recorder.Messages).Should(Equal([]string{"one\ntwo\nthree"}))
+		Expect(handler.Close()).To(Succeed())
+	})
+
+	It("should send the batch when the batch size is reached", func() {
+		sr, recorder := newRecordedRouter(nil)
+		handler := NewHandler(sr, &HandlerOptions{BatchDelay: time.Hour, BatchSize: 2})
+		for _, message := range []string{"one", "two", "three"} {
+			Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, message))).To(Succeed())
+		}
+		Eventually(recorder.Messages).Should(Equal([]string{"one\ntwo"}))
+		Expect(handler.Close()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"one\ntwo", "three"}))
+	})
+
+	It("should send the remaining records when closed, and reject records after that", func() {
+		sr, recorder := newRecordedRouter(nil)
+		handler := NewHandler(sr, &HandlerOptions{BatchDelay: time.Hour})
+		Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, "last words"))).To(Succeed())
+		Expect(handler.Close()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"last words"}))
+		Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, "too late"))).To(MatchError(ErrClosed))
+	})
+
+	It("should only send to the selected targets", func() {
+		sr, recorder := newRecordedRouter(nil)
+		Expect(sr.AddNamedService("ops", "logger://", "ops")).To(Succeed())
+		handler := NewHandler(sr, &HandlerOptions{Tags: []string{"ops"}})
+		Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, "selected"))).To(Succeed())
+		Expect(handler.Close()).To(Succeed())
+		Expect(recorder.Messages()).To(HaveLen(1))
+	})
+
+	It("should call OnError with the results of failed batches", func() {
+		sr, err := router.New(nil, "logger://")
+		Expect(err).NotTo(HaveOccurred())
+		sr.Use(func(context.Context, *router.Target, string, *types.Params, router.SendFunc) error {
+			return ErrClosed
+		})
+		var failed []router.SendResult
+		handler := NewHandler(sr, &HandlerOptions{OnError: func(results []router.SendResult) {
+			failed = results
+		}})
+		Expect(handler.Handle(ctx, newRecord(slog.LevelWarn, "lost"))).To(Succeed())
+		Expect(handler.Close()).To(MatchError(ErrClosed))
+		Expect(failed).To(HaveLen(1))
+		Expect(failed[0].Err).To(MatchError(ErrClosed))
+	})
+
+	It("should not send the records logged by shoutrrr while sending", func() {
+		sr, recorder := newRecordedRouter(nil)
+		handler := NewHandler(sr, &HandlerOptions{Level: slog.LevelDebug, BatchDelay: 10 * time.Millisecond})
+		sr.SetLogger(logging.Slog(slog.New(handler)))
+
+		Expect(handler.Handle(ctx, newRecord(slog.LevelInfo, "logged once"))).To(Succeed())
+		Eventually(recorder.Messages).Should(HaveLen(1))
+		Consistently(recorder.Messages, 100*time.Millisecond).Should(HaveLen(1))
+		Expect(handler.Close()).To(Succeed())
+	})
+})
@@ -0,0 +1,45 @@
+package logsink
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogSink(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr LogSink Suite")
+}
+
+// sendRecorder is an interceptor recording the messages sent by the router
+type sendRecorder struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (recorder *sendRecorder) intercept(ctx context.Context, _ *router.Target, message string, params *types.Params, next router.SendFunc) error {
+	recorder.mutex.Lock()
+	recorder.messages = append(recorder.messages, message)
+	recorder.mutex.Unlock()
+	return next(ctx, message, params)
+}
+
+func (recorder *sendRecorder) Messages() []string {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]string{}, recorder.messages...)
+}
+
+func newRecordedRouter(logger types.StdLogger) (*router.ServiceRouter, *sendRecorder) {
+	sr, err := router.New(logger, "logger://")
+	Expect(err).NotTo(HaveOccurred())
+	recorder := &sendRecorder{}
+	sr.Use(recorder.intercept)
+	return sr, recorder
+}
@@ -0,0 +1,209 @@
+package logsink
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// DefaultMaxSize is the number of buffered bytes that causes a Writer to send the lines without waiting
+const DefaultMaxSize = 4096
+
+// WriterOptions are the options used by a Writer
+type WriterOptions struct {
+	// Targets and Tags selects the router targets that the lines are sent to. If both are empty, all the targets
+	// are used.
+	Targets []string
+	Tags    []string
+	// Params are passed to the services for every send
+	Params types.Params
+	// Level is used for the lines that does not have a level prefix
+	Level types.MessageLevel
+	// ParseLevel enables parsing a level prefix, like "ERROR:", "[warn]" or "level=info", from the start of the lines,
+	// or following the date and time written by a log.Logger
+	ParseLevel bool
+	// FlushInterval is the time to wait for more lines before sending, DefaultBatchDelay if zero
+	FlushInterval time.Duration
+	// MaxSize is the number of buffered bytes that causes the lines to be sent without waiting, DefaultMaxSize if
+	// zero. Lines that are longer than MaxSize are sent in parts.
+	MaxSize int
+	// OnError is called with the results of the sends that failed for any target
+	OnError func(results []router.SendResult)
+}
+
+// Writer is an io.Writer that sends the written lines to a ServiceRouter, one MessageItem per line.
+// Lines are buffered until FlushInterval has passed since the first line, or MaxSize bytes have been written, and
+// are then split to fit within the message limits of the services. Call Close to send any remaining output.
+type Writer struct {
+	batcher    *batcher
+	level      types.MessageLevel
+	parseLevel bool
+	maxSize    int
+	mutex      sync.Mutex
+	partial    []byte
+}
+
+// NewWriter creates a Writer sending lines to sr using the supplied options, which may be nil
+func NewWriter(sr *router.ServiceRouter, options *WriterOptions) *Writer {
+	if options == nil {
+		options = &WriterOptions{}
+	}
+
+	batch := batchOptions{
+		targets:  options.Targets,
+		tags:     options.Tags,
+		params:   options.Params,
+		delay:    options.FlushInterval,
+		maxBytes: options.MaxSize,
+		onError:  options.OnError,
+	}
+	if batch.delay <= 0 {
+		batch.delay = DefaultBatchDelay
+	}
+	if batch.maxBytes <= 0 {
+		batch.maxBytes = DefaultMaxSize
+	}
+
+	return &Writer{
+		batcher:    newBatcher(sr, batch),
+		level:      options.Level,
+		parseLevel: options.ParseLevel,
+		maxSize:    batch.maxBytes,
+	}
+}
+
+// Write buffers p, adding any complete lines to the current batch
+func (writer *Writer) Write(p []byte) (int, error) {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.partial = append(writer.partial, p...)
+	for {
+		end := bytes.IndexByte(writer.partial, '\n')
+		if end < 0 {
+			break
+		}
+		line := string(writer.partial[:end])
+		writer.partial = writer.partial[end+1:]
+		if err := writer.addLine(line); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(writer.partial) >= writer.maxSize {
+		if err := writer.addPartial(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush sends the complete lines that have been written, and waits for any sends in progress
+func (writer *Writer) Flush() error {
+	return writer.batcher.sendNow()
+}
+
+// Close sends all the buffered output, including any line that has not been terminated by a newline, and waits for
+// any sends in progress. Writes after Close returns ErrClosed.
+func (writer *Writer) Close() error {
+	writer.mutex.Lock()
+	err := writer.addPartial()
+	writer.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+	return writer.batcher.close()
+}
+
+// addPartial adds the buffered output that is not terminated by a newline as a line.
+// It must be called with the mutex held.
+func (writer *Writer) addPartial() error {
+	line := string(writer.partial)
+	writer.partial = nil
+	return writer.addLine(line)
+}
+
+func (writer *Writer) addLine(line string) error {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	level := writer.level
+	if writer.parseLevel {
+		if prefixLevel, text, found := parseLevelPrefix(line); found {
+			level = prefixLevel
+			line = text
+		}
+	}
+
+	return writer.batcher.add(types.MessageItem{
+		Text:      line,
+		Timestamp: time.Now(),
+		Level:     level,
+	})
+}
+
+var levelPrefixPattern = regexp.MustCompile(`^\s*(?:\[(\w+)\]:?|<(\w+)>|(\w+):|level=(\w+))\s*`)
+
+// logHeaderPattern matches the header written by a log.Logger using the Ldate or Ltime flags, with the logger prefix
+// before it and the file written using Lshortfile or Llongfile after it. A prefix written using Lmsgprefix follows
+// the header, and so is only matched if it is the level prefix itself.
+var logHeaderPattern = regexp.MustCompile(
+	`^.*?(?:\d{4}/\d{2}/\d{2} (?:\d{2}:\d{2}:\d{2}(?:\.\d+)? )?|\d{2}:\d{2}:\d{2}(?:\.\d+)? )(?:\S+\.go:\d+: )?`,
+)
+
+var levelPrefixNames = map[string]types.MessageLevel{
+	"trace":    types.Debug,
+	"debug":    types.Debug,
+	"dbg":      types.Debug,
+	"info":     types.Info,
+	"notice":   types.Info,
+	"warn":     types.Warning,
+	"warning":  types.Warning,
+	"err":      types.Error,
+	"error":    types.Error,
+	"crit":     types.Error,
+	"critical": types.Error,
+	"fatal":    types.Error,
+	"panic":    types.Error,
+}
+
+// parseLevelPrefix returns the level of the line prefix, and the line without the prefix, if the line starts with a
+// (case-insensitive) level name in brackets, followed by a colon or as a level key-value pair. The level prefix can
+// follow the header written by a log.Logger (its prefix, date, time and file), which is kept in the returned line.
+func parseLevelPrefix(line string) (types.MessageLevel, string, bool) {
+	if level, text, found := matchLevelPrefix(line); found {
+		return level, text, true
+	}
+
+	header := logHeaderPattern.FindString(line)
+	if header == "" {
+		return types.Unknown, line, false
+	}
+	if level, text, found := matchLevelPrefix(line[len(header):]); found {
+		return level, header + text, true
+	}
+	return types.Unknown, line, false
+}
+
+// matchLevelPrefix returns the level of the prefix at the start of line, and the line without the prefix
+func matchLevelPrefix(line string) (types.MessageLevel, string, bool) {
+	match := levelPrefixPattern.FindStringSubmatch(line)
+	if match == nil {
+		return types.Unknown, line, false
+	}
+
+	name := strings.Join(match[1:], "")
+	level, found := levelPrefixNames[strings.ToLower(name)]
+	if !found {
+		return types.Unknown, line, false
+	}
+	return level, line[len(match[0]):], true
+}
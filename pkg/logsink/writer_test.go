@@ -0,0 +1,147 @@
+package logsink
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the writer", func() {
+	It("should send the written lines as items", func() {
+		sr, recorder := newRecordedRouter(nil)
+		writer := NewWriter(sr, &WriterOptions{FlushInterval: 20 * time.Millisecond})
+		fmt.Fprint(writer, "first li")
+		fmt.Fprint(writer, "ne\r\n\nsecond line\nthird")
+		Eventually(recorder.Messages).Should(Equal([]string{"first line\nsecond line"}))
+
+		Expect(writer.Close()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"first line\nsecond line", "third"}))
+
+		_, err := writer.Write([]byte("too late\n"))
+		Expect(err).To(MatchError(ErrClosed))
+	})
+
+	It("should send the lines when the max size is reached", func() {
+		sr, recorder := newRecordedRouter(nil)
+		writer := NewWriter(sr, &WriterOptions{FlushInterval: time.Hour, MaxSize: 10})
+		logger := log.New(writer, "", 0)
+		logger.Print("short")
+		Consistently(recorder.Messages, 50*time.Millisecond).Should(BeEmpty())
+		logger.Print("longer")
+		Eventually(recorder.Messages).Should(Equal([]string{"short\nlonger"}))
+		Expect(writer.Close()).To(Succeed())
+	})
+
+	It("should send lines longer than the max size in parts", func() {
+		sr, recorder := newRecordedRouter(nil)
+		writer := NewWriter(sr, &WriterOptions{FlushInterval: time.Hour, MaxSize: 4})
+		fmt.Fprint(writer, "abcdef")
+		Expect(writer.Flush()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"abcdef"}))
+		Expect(writer.Close()).To(Succeed())
+	})
+
+	It("should send the complete lines when flushed", func() {
+		sr, recorder := newRecordedRouter(nil)
+		writer := NewWriter(sr, &WriterOptions{FlushInterval: time.Hour})
+		fmt.Fprint(writer, "complete\npartial")
+		Expect(writer.Flush()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"complete"}))
+		Expect(writer.Close()).To(Succeed())
+		Expect(recorder.Messages()).To(Equal([]string{"complete", "partial"}))
+	})
+
+	When("parsing level prefixes", func() {
+		It("should use the level of the prefix and remove it from the text", func() {
+			sr, _ := newRecordedRouter(nil)
+			writer := NewWriter(sr, &WriterOptions{FlushInterval: time.Hour, ParseLevel: true, Level: types.Info})
+			fmt.Fprint(writer, "ERROR: disk full\n[warn] disk almost full\n<debug> checking disk\nlevel=info msg=ok\n")
+			fmt.Fprint(writer, "note: not a level\nno prefix\n")
+
+			items := writer.batcher.items
+			levels := make([]types.MessageLevel, len(items))
+			texts := make([]string, len(items))
+			for i, item := range items {
+				levels[i] = item.Level
+				texts[i] = item.Text
+			}
+			Expect(levels).To(Equal([]types.MessageLevel{
+				types.Error, types.Warning, types.Debug, types.Info, types.Info, types.Info,
+			}))
+			Expect(texts).To(Equal([]string{
+				"disk full", "disk almost full", "checking disk", "msg=ok", "note: not a level", "no prefix",
+			}))
+			Expect(writer.Close()).To(Succeed())
+		})
+		It("should find the prefix after the header of a standard logger", func() {
+			sr, _ := newRecordedRouter(nil)
+			writer := NewWriter(sr, &WriterOptions{FlushInterval: time.Hour, ParseLevel: true, Level: types.Info})
+			log.New(writer, "", log.LstdFlags).Print("ERROR: disk full")
+			log.New(writer, "app ", log.LstdFlags|log.Lmicroseconds|log.Lshortfile).Print("[warn] disk almost full")
+			log.New(writer, "", log.Ltime).Print("see 2026/10/19 12:00:00 debug: not a level")
+
+			items := writer.batcher.items
+			Expect(items).To(HaveLen(3))
+			Expect(items[0].Level).To(Equal(types.Error))
+			Expect(items[0].Text).To(MatchRegexp(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} disk full$`))
+			Expect(items[1].Level).To(Equal(types.Warning))
+			Expect(items[1].Text).To(MatchRegexp(`^app \d{4}/\d{2}/\d{2} [\d:.]+ writer_test\.go:\d+: disk almost full$`))
+			Expect(items[2].Level).To(Equal(types.Info))
+			Expect(items[2].Text).To(HaveSuffix("see 2026/10/19 12:00:00 debug: not a level"))
+			Expect(writer.Close()).To(Succeed())
+		})
+		It("should keep the prefix unless enabled", func() {
+			sr, _ := newRecordedRouter(nil)
+			writer := NewWriter(sr, &WriterOptions{FlushInterval: time.Hour})
+			fmt.Fprint(writer, "ERROR: disk full\n")
+			Expect(writer.batcher.items[0].Text).To(Equal("ERROR: disk full"))
+			Expect(writer.batcher.items[0].Level).To(Equal(types.Unknown))
+			Expect(writer.Close()).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("the message chunking", func() {
+	items := func(texts ...string) []types.MessageItem {
+		items := make([]types.MessageItem, len(texts))
+		for i, text := range texts {
+			items[i] = types.MessageItem{Text: text, Level: types.Warning}
+		}
+		return items
+	}
+
+	It("should not split the items if the service has no limits", func() {
+		chunks := chunkItems(items("a", "b"), types.MessageLimit{}, false)
+		Expect(chunks).To(Equal([][]types.MessageItem{items("a", "b")}))
+	})
+	It("should count the newlines joining the items for plain services", func() {
+		limit := types.MessageLimit{ChunkSize: 7, TotalChunkSize: 7, ChunkCount: 1}
+		chunks := chunkItems(items("abc", "def", "ghi"), limit, false)
+		Expect(chunks).To(Equal([][]types.MessageItem{items("abc", "def"), items("ghi")}))
+	})
+	It("should limit the number of items for rich senders", func() {
+		limit := types.MessageLimit{ChunkSize: 10, TotalChunkSize: 100, ChunkCount: 3}
+		chunks := chunkItems(items("a", "b", "c", "d", "e"), limit, true)
+		Expect(chunks).To(Equal([][]types.MessageItem{items("a", "b"), items("c", "d"), items("e")}))
+	})
+	It("should split items longer than the chunk size, keeping the item level", func() {
+		limit := types.MessageLimit{ChunkSize: 4, TotalChunkSize: 4, ChunkCount: 1}
+		chunks := chunkItems(items("abcdefghij"), limit, false)
+		Expect(chunks).To(Equal([][]types.MessageItem{items("abcd"), items("efgh"), items("ij")}))
+	})
+	It("should not split multi-byte runes", func() {
+		Expect(splitText("aåäö", 4, types.ByteLength)).To(Equal([]string{"aå", "äö"}))
+		Expect(strings.Join(splitText("aåäö", 2, types.ByteLength), "")).To(Equal("aåäö"))
+	})
+	It("should measure the sizes in the unit of the limit", func() {
+		limit := types.MessageLimit{ChunkSize: 3, TotalChunkSize: 3, ChunkCount: 1, Unit: types.UTF16Length}
+		chunks := chunkItems(items("a😀b😀"), limit, false)
+		Expect(chunks).To(Equal([][]types.MessageItem{items("a😀"), items("b😀")}))
+	})
+})
@@ -0,0 +1,215 @@
+// Package metrics implements collectors for the send metrics of a ServiceRouter
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/internal/failures"
+	"github.com/dockerutil/shoutrrr/pkg/router"
+)
+
+// ContentType is the mime type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	failureTimeout = "timeout"
+	failureUnknown = "unknown"
+)
+
+// DefaultBuckets are the upper bounds (in seconds) of the send duration histogram buckets
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Prometheus collects router send metrics, and exposes them in the Prometheus text format when used as a http.Handler
+type Prometheus struct {
+	buckets  []float64
+	mutex    sync.Mutex
+	sends    map[targetKey]uint64
+	timeouts map[targetKey]uint64
+	failures map[failureKey]uint64
+	duration map[targetKey]*histogram
+}
+
+type targetKey struct {
+	scheme string
+	target string
+}
+
+type failureKey struct {
+	targetKey
+	failure string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewPrometheus returns a new Prometheus metrics collector, using the DefaultBuckets for the duration histogram
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		buckets:  DefaultBuckets,
+		sends:    make(map[targetKey]uint64),
+		timeouts: make(map[targetKey]uint64),
+		failures: make(map[failureKey]uint64),
+		duration: make(map[targetKey]*histogram),
+	}
+}
+
+// ObserveSend records the outcome and duration of a send
+func (prom *Prometheus) ObserveSend(result router.SendResult, duration time.Duration) {
+	key := targetKey{scheme: result.Scheme, target: result.Target}
+
+	prom.mutex.Lock()
+	defer prom.mutex.Unlock()
+
+	prom.sends[key]++
+
+	if result.Err != nil {
+		failure := FailureLabel(result.Err)
+		if failure == failureTimeout {
+			prom.timeouts[key]++
+		}
+		prom.failures[failureKey{targetKey: key, failure: failure}]++
+	}
+
+	hist, found := prom.duration[key]
+	if !found {
+		hist = &histogram{counts: make([]uint64, len(prom.buckets))}
+		prom.duration[key] = hist
+	}
+	seconds := duration.Seconds()
+	for i, bound := range prom.buckets {
+		if seconds <= bound {
+			hist.counts[i]++
+		}
+	}
+	hist.count++
+	hist.sum += seconds
+}
+
+// FailureLabel returns the label value used for err, which is either the FailureID, "timeout" or "unknown"
+func FailureLabel(err error) string {
+	if errors.Is(err, router.ErrTimeout) {
+		return failureTimeout
+	}
+
+	var failure failures.Failure
+	if errors.As(err, &failure) {
+		return strconv.Itoa(int(failure.ID()))
+	}
+
+	return failureUnknown
+}
+
+// ServeHTTP writes the collected metrics in the Prometheus text exposition format
+func (prom *Prometheus) ServeHTTP(res http.ResponseWriter, _ *http.Request) {
+	res.Header().Set("Content-Type", ContentType)
+	_ = prom.Write(res)
+}
+
+// Write writes the collected metrics to w in the Prometheus text exposition format
+func (prom *Prometheus) Write(w io.Writer) error {
+	prom.mutex.Lock()
+	defer prom.mutex.Unlock()
+
+	sb := strings.Builder{}
+
+	targets := make([]targetKey, 0, len(prom.sends))
+	for key := range prom.sends {
+		targets = append(targets, key)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].less(targets[j]) })
+
+	failureKeys := make([]failureKey, 0, len(prom.failures))
+	for key := range prom.failures {
+		failureKeys = append(failureKeys, key)
+	}
+	sort.Slice(failureKeys, func(i, j int) bool {
+		if failureKeys[i].targetKey == failureKeys[j].targetKey {
+			return failureKeys[i].failure < failureKeys[j].failure
+		}
+		return failureKeys[i].less(failureKeys[j].targetKey)
+	})
+
+	writeHeader(&sb, "shoutrrr_sends_total", "counter", "Total number of notifications sent, including failed ones.")
+	for _, key := range targets {
+		writeSample(&sb, "shoutrrr_sends_total", key.labels(), float64(prom.sends[key]))
+	}
+
+	writeHeader(&sb, "shoutrrr_send_failures_total", "counter", "Total number of failed notifications, by failure ID.")
+	for _, key := range failureKeys {
+		writeSample(&sb, "shoutrrr_send_failures_total", key.labels(), float64(prom.failures[key]))
+	}
+
+	writeHeader(&sb, "shoutrrr_send_timeouts_total", "counter", "Total number of notifications that timed out.")
+	for _, key := range targets {
+		writeSample(&sb, "shoutrrr_send_timeouts_total", key.labels(), float64(prom.timeouts[key]))
+	}
+
+	writeHeader(&sb, "shoutrrr_send_duration_seconds", "histogram", "Time taken to send notifications.")
+	for _, key := range targets {
+		hist := prom.duration[key]
+		for i, bound := range prom.buckets {
+			writeSample(&sb, "shoutrrr_send_duration_seconds_bucket", key.labels("le", formatFloat(bound)), float64(hist.counts[i]))
+		}
+		writeSample(&sb, "shoutrrr_send_duration_seconds_bucket", key.labels("le", "+Inf"), float64(hist.count))
+		writeSample(&sb, "shoutrrr_send_duration_seconds_sum", key.labels(), hist.sum)
+		writeSample(&sb, "shoutrrr_send_duration_seconds_count", key.labels(), float64(hist.count))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// labels returns the target labels followed by the extra labels, as alternating names and values
+func (key targetKey) labels(extra ...string) []string {
+	return append([]string{"scheme", key.scheme, "target", key.target}, extra...)
+}
+
+func (key targetKey) less(other targetKey) bool {
+	if key.scheme == other.scheme {
+		return key.target < other.target
+	}
+	return key.scheme < other.scheme
+}
+
+func (key failureKey) labels() []string {
+	return key.targetKey.labels("failure", key.failure)
+}
+
+func writeHeader(sb *strings.Builder, name string, metricType string, help string) {
+	_, _ = fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// writeSample writes a single sample line, with labels given as alternating names and values
+func writeSample(sb *strings.Builder, name string, labels []string, value float64) {
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(sb, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(formatFloat(value))
+	sb.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
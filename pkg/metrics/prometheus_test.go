@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/internal/failures"
+	"github.com/dockerutil/shoutrrr/pkg/metrics"
+	"github.com/dockerutil/shoutrrr/pkg/router"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMetrics(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr Metrics Suite")
+}
+
+var _ = Describe("the prometheus metrics", func() {
+	var prom *metrics.Prometheus
+
+	BeforeEach(func() {
+		prom = metrics.NewPrometheus()
+	})
+
+	output := func() string {
+		sb := strings.Builder{}
+		Expect(prom.Write(&sb)).To(Succeed())
+		return sb.String()
+	}
+
+	It("should count sends and record their duration", func() {
+		prom.ObserveSend(router.SendResult{Target: "ops", Scheme: "slack"}, 200*time.Millisecond)
+		prom.ObserveSend(router.SendResult{Target: "ops", Scheme: "slack"}, 3*time.Second)
+
+		out := output()
+		Expect(out).To(ContainSubstring("# TYPE shoutrrr_sends_total counter\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_sends_total{scheme="slack",target="ops"} 2` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_timeouts_total{scheme="slack",target="ops"} 0` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_duration_seconds_bucket{scheme="slack",target="ops",le="0.1"} 0` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_duration_seconds_bucket{scheme="slack",target="ops",le="0.25"} 1` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_duration_seconds_bucket{scheme="slack",target="ops",le="5"} 2` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_duration_seconds_bucket{scheme="slack",target="ops",le="+Inf"} 2` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_duration_seconds_sum{scheme="slack",target="ops"} 3.2` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_duration_seconds_count{scheme="slack",target="ops"} 2` + "\n"))
+		Expect(out).NotTo(ContainSubstring("shoutrrr_send_failures_total{"))
+	})
+
+	It("should count failures by failure ID and timeouts", func() {
+		timeout := fmt.Errorf("failed to send using ops: %w", router.ErrTimeout)
+		prom.ObserveSend(router.SendResult{Target: "ops", Scheme: "smtp", Err: failures.Wrap("failed", 4, nil)}, 0)
+		prom.ObserveSend(router.SendResult{Target: "ops", Scheme: "smtp", Err: timeout}, 0)
+		prom.ObserveSend(router.SendResult{Target: "ops", Scheme: "smtp", Err: errors.New("oops")}, 0)
+
+		out := output()
+		Expect(out).To(ContainSubstring(`shoutrrr_send_failures_total{scheme="smtp",target="ops",failure="4"} 1` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_failures_total{scheme="smtp",target="ops",failure="timeout"} 1` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_failures_total{scheme="smtp",target="ops",failure="unknown"} 1` + "\n"))
+		Expect(out).To(ContainSubstring(`shoutrrr_send_timeouts_total{scheme="smtp",target="ops"} 1` + "\n"))
+	})
+
+	It("should escape label values", func() {
+		prom.ObserveSend(router.SendResult{Target: "a \"quoted\"\\name\n", Scheme: "logger"}, 0)
+		Expect(output()).To(ContainSubstring(`{scheme="logger",target="a \"quoted\"\\name\n"} 1`))
+	})
+
+	It("should be served using the text exposition format", func() {
+		res := httptest.NewRecorder()
+		prom.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(res.Header().Get("Content-Type")).To(Equal(metrics.ContentType))
+		Expect(res.Body.String()).To(HavePrefix("# HELP shoutrrr_sends_total "))
+	})
+
+	When("used by a router", func() {
+		It("should observe the sends", func() {
+			sr, err := router.New(nil, "logger://")
+			Expect(err).NotTo(HaveOccurred())
+			sr.SetMetrics(prom)
+			sr.Send("message", nil)
+			Expect(output()).To(ContainSubstring(`shoutrrr_sends_total{scheme="logger",target="logger"} 1`))
+		})
+	})
+})
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a target circuit breaker
+type CircuitState string
+
+// Circuit breaker states
+const (
+	// CircuitClosed lets all sends through
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen rejects all sends until the cool-down has passed
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe send through, closing the circuit if it succeeds
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// BreakerConfig configures the circuit breakers of the router targets
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failures after which the circuit opens, or 0 to disable the breakers
+	Threshold int `mapstructure:"threshold"`
+	// CoolDown is the time the circuit stays open before a probe send is let through
+	CoolDown time.Duration `mapstructure:"cooldown"`
+}
+
+// circuitBreaker tracks the consecutive failures of a single target
+type circuitBreaker struct {
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{state: CircuitClosed}
+}
+
+// allow returns whether a send should be let through, moving an open circuit to half-open once the cool-down has
+// passed. Only a single probe is let through while the circuit is half-open.
+func (breaker *circuitBreaker) allow(config BreakerConfig, now time.Time) (allowed bool, state CircuitState) {
+	if breaker == nil || config.Threshold < 1 {
+		return true, CircuitClosed
+	}
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if breaker.state == CircuitOpen && now.Sub(breaker.openedAt) >= config.CoolDown {
+		breaker.state = CircuitHalfOpen
+		breaker.probing = false
+	}
+
+	switch breaker.state {
+	case CircuitOpen:
+		return false, breaker.state
+	case CircuitHalfOpen:
+		if breaker.probing {
+			return false, breaker.state
+		}
+		breaker.probing = true
+	}
+	return true, breaker.state
+}
+
+// release ends a probe without recording its outcome, e.g. when the send was canceled, letting the next send through
+// as the probe instead
+func (breaker *circuitBreaker) release() {
+	if breaker == nil {
+		return
+	}
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	breaker.probing = false
+}
+
+// record updates the breaker with the outcome of a send that was let through, returning the resulting state
+func (breaker *circuitBreaker) record(config BreakerConfig, err error, now time.Time) CircuitState {
+	if breaker == nil || config.Threshold < 1 {
+		return CircuitClosed
+	}
+
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+
+	if err == nil {
+		breaker.failures = 0
+		breaker.state = CircuitClosed
+		breaker.probing = false
+		return breaker.state
+	}
+
+	breaker.failures++
+	if breaker.state == CircuitHalfOpen || breaker.failures >= config.Threshold {
+		breaker.state = CircuitOpen
+		breaker.openedAt = now
+		breaker.probing = false
+	}
+	return breaker.state
+}
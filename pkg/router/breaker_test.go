@@ -0,0 +1,117 @@
+package router
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/internal/failures"
+	"github.com/dockerutil/shoutrrr/pkg/services/standard"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the circuit breaker", func() {
+	config := BreakerConfig{Threshold: 2, CoolDown: time.Minute}
+	errSend := errors.New("send failed")
+	start := time.Now()
+	var breaker *circuitBreaker
+
+	BeforeEach(func() {
+		breaker = newCircuitBreaker()
+	})
+
+	fail := func(now time.Time) CircuitState {
+		allowed, _ := breaker.allow(config, now)
+		Expect(allowed).To(BeTrue())
+		return breaker.record(config, errSend, now)
+	}
+
+	It("should open after the threshold of consecutive failures", func() {
+		Expect(fail(start)).To(Equal(CircuitClosed))
+		Expect(fail(start)).To(Equal(CircuitOpen))
+		allowed, state := breaker.allow(config, start.Add(time.Second))
+		Expect(allowed).To(BeFalse())
+		Expect(state).To(Equal(CircuitOpen))
+	})
+
+	It("should reset the failure count on success", func() {
+		Expect(fail(start)).To(Equal(CircuitClosed))
+		Expect(breaker.record(config, nil, start)).To(Equal(CircuitClosed))
+		Expect(fail(start)).To(Equal(CircuitClosed))
+	})
+
+	It("should let a single probe through after the cool-down", func() {
+		fail(start)
+		fail(start)
+
+		later := start.Add(config.CoolDown)
+		allowed, state := breaker.allow(config, later)
+		Expect(allowed).To(BeTrue())
+		Expect(state).To(Equal(CircuitHalfOpen))
+
+		allowed, _ = breaker.allow(config, later)
+		Expect(allowed).To(BeFalse())
+
+		Expect(breaker.record(config, nil, later)).To(Equal(CircuitClosed))
+		allowed, _ = breaker.allow(config, later)
+		Expect(allowed).To(BeTrue())
+	})
+
+	It("should open again if the probe fails", func() {
+		fail(start)
+		fail(start)
+		later := start.Add(config.CoolDown)
+		Expect(fail(later)).To(Equal(CircuitOpen))
+		allowed, _ := breaker.allow(config, later.Add(time.Second))
+		Expect(allowed).To(BeFalse())
+	})
+
+	It("should let another probe through if the probe is released", func() {
+		fail(start)
+		fail(start)
+
+		later := start.Add(config.CoolDown)
+		allowed, _ := breaker.allow(config, later)
+		Expect(allowed).To(BeTrue())
+		breaker.release()
+
+		allowed, state := breaker.allow(config, later)
+		Expect(allowed).To(BeTrue())
+		Expect(state).To(Equal(CircuitHalfOpen))
+		Expect(breaker.failures).To(Equal(2))
+	})
+
+	It("should let everything through when disabled", func() {
+		for i := 0; i < 5; i++ {
+			Expect(breaker.record(BreakerConfig{}, errSend, start)).To(Equal(CircuitClosed))
+		}
+		allowed, _ := breaker.allow(BreakerConfig{}, start)
+		Expect(allowed).To(BeTrue())
+	})
+
+	When("used by the router", func() {
+		It("should fail fast and report the state changes", func() {
+			output := &strings.Builder{}
+			router := &ServiceRouter{
+				logger:  log.New(output, "", 0),
+				Timeout: 10 * time.Second,
+				Breaker: BreakerConfig{Threshold: 1, CoolDown: time.Hour},
+			}
+			Expect(router.AddNamedService("broken", "generic://127.0.0.1:0/hook?disabletls=yes")).To(Succeed())
+
+			results := router.SendTo(router.Targets(), "message", nil)
+			Expect(results[0].Err).To(HaveOccurred())
+			Expect(results[0].Circuit).To(Equal(CircuitOpen))
+			Expect(output.String()).To(ContainSubstring("Circuit breaker state changed target=broken from=closed to=open"))
+
+			results = router.SendTo(router.Targets(), "message", nil)
+			Expect(results[0].Circuit).To(Equal(CircuitOpen))
+			var failure failures.Failure
+			Expect(errors.As(results[0].Err, &failure)).To(BeTrue())
+			Expect(failure.ID()).To(Equal(standard.FailCircuitOpen))
+		})
+	})
+})
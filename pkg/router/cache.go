@@ -0,0 +1,176 @@
+package router
+
+import (
+	"container/list"
+	"net/url"
+	"strings"
+	"sync"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// DefaultCacheSize is the number of initialized services kept by a router, unless CacheSize is set
+const DefaultCacheSize = 32
+
+// serviceCache is an LRU cache of initialized services, keyed by their canonical URL
+type serviceCache struct {
+	mutex   sync.Mutex
+	entries map[string]*list.Element
+	order   list.List
+	loading map[string]*cacheLoad
+}
+
+type cacheEntry struct {
+	key     string
+	service t.Service
+}
+
+// cacheLoad is a service being initialized, shared by all the loads of the same key
+type cacheLoad struct {
+	done    chan struct{}
+	service t.Service
+	err     error
+}
+
+// load returns the cached service for key, initializing it using locate if it is not cached. Concurrent loads of the
+// same key wait for a single call to locate. The service is not cached if the key is removed while it is initialized.
+func (cache *serviceCache) load(key string, size int, locate func() (t.Service, error)) (t.Service, error) {
+	cache.mutex.Lock()
+	if element, found := cache.entries[key]; found {
+		cache.order.MoveToFront(element)
+		cache.mutex.Unlock()
+		return element.Value.(*cacheEntry).service, nil
+	}
+	if load, found := cache.loading[key]; found {
+		cache.mutex.Unlock()
+		<-load.done
+		return load.service, load.err
+	}
+
+	load := &cacheLoad{done: make(chan struct{})}
+	if cache.loading == nil {
+		cache.loading = make(map[string]*cacheLoad)
+	}
+	cache.loading[key] = load
+	cache.mutex.Unlock()
+
+	defer close(load.done)
+	load.service, load.err = locate()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.loading[key] == load {
+		delete(cache.loading, key)
+		if load.err == nil {
+			cache.add(key, load.service, size)
+		}
+	}
+	return load.service, load.err
+}
+
+// add adds the service to the cache, evicting the least recently used services if it contains more than size.
+// The cache mutex must be held by the caller.
+func (cache *serviceCache) add(key string, service t.Service, size int) {
+	if cache.entries == nil {
+		cache.entries = make(map[string]*list.Element)
+	}
+
+	if element, found := cache.entries[key]; found {
+		element.Value.(*cacheEntry).service = service
+		cache.order.MoveToFront(element)
+	} else {
+		cache.entries[key] = cache.order.PushFront(&cacheEntry{key: key, service: service})
+	}
+
+	for cache.order.Len() > size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (cache *serviceCache) remove(key string) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	delete(cache.loading, key)
+	element, found := cache.entries[key]
+	if found {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+	}
+	return found
+}
+
+func (cache *serviceCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries = nil
+	cache.loading = nil
+	cache.order.Init()
+}
+
+func (cache *serviceCache) each(fn func(service t.Service)) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	for element := cache.order.Front(); element != nil; element = element.Next() {
+		fn(element.Value.(*cacheEntry).service)
+	}
+}
+
+// canonicalURL returns rawURL with the scheme in lower case and the query keys sorted, so that URLs that only differ
+// in those aspects share the same cached service. The host is kept as is, since many services use it for tokens.
+func canonicalURL(rawURL string) (string, error) {
+	serviceURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+
+	serviceURL.Scheme = strings.ToLower(serviceURL.Scheme)
+	serviceURL.RawQuery = serviceURL.Query().Encode()
+	serviceURL.Fragment = ""
+
+	return serviceURL.String(), nil
+}
+
+func (router *ServiceRouter) cacheSize() int {
+	if router.CacheSize == 0 {
+		return DefaultCacheSize
+	}
+	return router.CacheSize
+}
+
+// LocateCached returns an initialized service for the URL, reusing a previously initialized service for the same
+// URL if one is available in the cache. Cached services are shared, and should not be modified.
+func (router *ServiceRouter) LocateCached(rawURL string) (t.Service, error) {
+	size := router.cacheSize()
+	if size < 0 {
+		return router.Locate(rawURL)
+	}
+
+	key, err := canonicalURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return router.cache.load(key, size, func() (t.Service, error) {
+		return router.Locate(rawURL)
+	})
+}
+
+// Invalidate removes the service for the URL from the cache, making the next send using the URL initialize a new
+// service. Returns whether a cached service was removed.
+func (router *ServiceRouter) Invalidate(rawURL string) bool {
+	key, err := canonicalURL(rawURL)
+	if err != nil {
+		return false
+	}
+	return router.cache.remove(key)
+}
+
+// InvalidateAll removes all services from the cache
+func (router *ServiceRouter) InvalidateAll() {
+	router.cache.clear()
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dockerutil/shoutrrr/pkg/services/logger"
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the service cache", func() {
+	var router *ServiceRouter
+	BeforeEach(func() {
+		router = &ServiceRouter{}
+	})
+
+	It("should reuse the service for the same canonical URL", func() {
+		first, err := router.LocateCached("logger://?b=2&a=1")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := router.LocateCached("LOGGER://?a=1&b=2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(BeIdenticalTo(first))
+	})
+	It("should not share services for URLs with hosts that only differ in case", func() {
+		first, err := router.LocateCached("logger://Token")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := router.LocateCached("logger://token")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).NotTo(BeIdenticalTo(first))
+	})
+	It("should not cache services that fail to initialize", func() {
+		_, err := router.LocateCached("unknown://")
+		Expect(err).To(HaveOccurred())
+		Expect(router.cache.order.Len()).To(Equal(0))
+	})
+	It("should evict the least recently used service", func() {
+		router.CacheSize = 2
+		first, _ := router.LocateCached("logger://?id=1")
+		_, _ = router.LocateCached("logger://?id=2")
+		_, _ = router.LocateCached("logger://?id=1")
+		_, _ = router.LocateCached("logger://?id=3")
+
+		Expect(router.cache.order.Len()).To(Equal(2))
+		again, _ := router.LocateCached("logger://?id=1")
+		Expect(again).To(BeIdenticalTo(first))
+		Expect(router.Invalidate("logger://?id=2")).To(BeFalse())
+	})
+	It("should not cache services if the size is negative", func() {
+		router.CacheSize = -1
+		first, _ := router.LocateCached("logger://")
+		second, _ := router.LocateCached("logger://")
+		Expect(second).NotTo(BeIdenticalTo(first))
+	})
+	When("invalidating services", func() {
+		It("should initialize a new service for an invalidated URL", func() {
+			first, _ := router.LocateCached("logger://")
+			Expect(router.Invalidate("logger://")).To(BeTrue())
+			Expect(router.Invalidate("logger://")).To(BeFalse())
+			second, _ := router.LocateCached("logger://")
+			Expect(second).NotTo(BeIdenticalTo(first))
+		})
+		It("should remove all services", func() {
+			first, _ := router.LocateCached("logger://?id=1")
+			_, _ = router.LocateCached("logger://?id=2")
+			router.InvalidateAll()
+			Expect(router.cache.order.Len()).To(Equal(0))
+			second, _ := router.LocateCached("logger://?id=1")
+			Expect(second).NotTo(BeIdenticalTo(first))
+		})
+	})
+	It("should initialize the service once for concurrent loads of the same URL", func() {
+		var calls atomic.Int32
+		unblock := make(chan struct{})
+		locate := func() (t.Service, error) {
+			calls.Add(1)
+			<-unblock
+			return &logger.Service{}, nil
+		}
+
+		services := make(chan t.Service, 4)
+		for i := 0; i < 4; i++ {
+			go func() {
+				service, _ := router.cache.load("logger://", DefaultCacheSize, locate)
+				services <- service
+			}()
+		}
+		Eventually(calls.Load).Should(Equal(int32(1)))
+		close(unblock)
+
+		first := <-services
+		for i := 1; i < 4; i++ {
+			Expect(<-services).To(BeIdenticalTo(first))
+		}
+		Expect(calls.Load()).To(Equal(int32(1)))
+		Expect(router.cache.order.Len()).To(Equal(1))
+	})
+	It("should be safe to use concurrently", func() {
+		router.CacheSize = 4
+		wg := sync.WaitGroup{}
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					_, err := router.LocateCached(fmt.Sprintf("logger://?id=%d", (i+j)%8))
+					Expect(err).NotTo(HaveOccurred())
+					if j%10 == 0 {
+						router.Invalidate(fmt.Sprintf("logger://?id=%d", j%8))
+					}
+				}
+			}(i)
+		}
+		wg.Wait()
+		Expect(router.cache.order.Len()).To(BeNumerically("<=", 4))
+	})
+})
@@ -0,0 +1,42 @@
+package router
+
+import (
+	"context"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// SendFunc sends the message using a router target
+type SendFunc func(ctx context.Context, message string, params *t.Params) error
+
+// Interceptor is called around sending a message using a router target. It can modify the message and params before
+// passing them to next, skip the send by not calling next, or observe the error returned by next.
+type Interceptor func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error
+
+// Use adds interceptors that are called around the sends of all targets, in the order they are added.
+// Router interceptors are called before any target interceptors. Interceptors should be added before sending.
+func (router *ServiceRouter) Use(interceptors ...Interceptor) {
+	router.interceptors = append(router.interceptors, interceptors...)
+}
+
+// Use adds interceptors that are called around sends using the target, in the order they are added.
+// Interceptors should be added before sending.
+func (target *Target) Use(interceptors ...Interceptor) {
+	target.interceptors = append(target.interceptors, interceptors...)
+}
+
+// intercept calls the router and target interceptors, with send being the innermost next func
+func (router *ServiceRouter) intercept(ctx context.Context, target *Target, message string, params *t.Params, send SendFunc) error {
+	next := chain(target, router.interceptors, chain(target, target.interceptors, send))
+	return next(ctx, message, params)
+}
+
+func chain(target *Target, interceptors []Interceptor, next SendFunc) SendFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := interceptors[i], next
+		next = func(ctx context.Context, message string, params *t.Params) error {
+			return interceptor(ctx, target, message, params, inner)
+		}
+	}
+	return next
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTimeout is returned (wrapped) when a target did not finish sending within the router Timeout
+var ErrTimeout = errors.New("timed out")
+
+// Metrics collects measurements of the sends made by a ServiceRouter
+type Metrics interface {
+	// ObserveSend is called when a send using a target has completed (or timed out), with the time it took
+	ObserveSend(result SendResult, duration time.Duration)
+}
+
+// SetMetrics sets the metrics collector that is notified of every send made by the router
+func (router *ServiceRouter) SetMetrics(metrics Metrics) {
+	router.metrics = metrics
+}
@@ -0,0 +1,40 @@
+package router
+
+import "sync"
+
+// workerPool runs jobs using a bounded number of goroutines. Workers are started when jobs are submitted, and exit
+// once the queue is empty, so an idle pool does not use any goroutines.
+type workerPool struct {
+	mutex   sync.Mutex
+	queue   []func()
+	workers int
+}
+
+// submit queues the job, starting a new worker unless limit workers are already running
+func (pool *workerPool) submit(limit int, job func()) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.queue = append(pool.queue, job)
+	if pool.workers < limit {
+		pool.workers++
+		go pool.work()
+	}
+}
+
+func (pool *workerPool) work() {
+	for {
+		pool.mutex.Lock()
+		if len(pool.queue) == 0 {
+			pool.workers--
+			pool.mutex.Unlock()
+			return
+		}
+		job := pool.queue[0]
+		pool.queue[0] = nil
+		pool.queue = pool.queue[1:]
+		pool.mutex.Unlock()
+
+		job()
+	}
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the worker pool", func() {
+	It("should run all jobs without exceeding the limit", func() {
+		pool := &workerPool{}
+		var running, maxRunning, done atomic.Int32
+		mutex := sync.Mutex{}
+		wg := sync.WaitGroup{}
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			pool.submit(3, func() {
+				defer wg.Done()
+				current := running.Add(1)
+				mutex.Lock()
+				if current > maxRunning.Load() {
+					maxRunning.Store(current)
+				}
+				mutex.Unlock()
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+				done.Add(1)
+			})
+		}
+		wg.Wait()
+		Expect(done.Load()).To(Equal(int32(20)))
+		Expect(maxRunning.Load()).To(BeNumerically("<=", 3))
+		Eventually(func() int {
+			pool.mutex.Lock()
+			defer pool.mutex.Unlock()
+			return pool.workers
+		}).Should(BeZero())
+	})
+
+	When("used by the router", func() {
+		It("should limit the number of targets sent to in parallel", func() {
+			router := &ServiceRouter{Timeout: 10 * time.Second, Concurrency: 2}
+			for i := 0; i < 8; i++ {
+				Expect(router.AddNamedService(fmt.Sprintf("target-%d", i), "logger://")).To(Succeed())
+			}
+
+			var running, maxRunning atomic.Int32
+			mutex := sync.Mutex{}
+			router.Use(func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+				current := running.Add(1)
+				mutex.Lock()
+				if current > maxRunning.Load() {
+					maxRunning.Store(current)
+				}
+				mutex.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				defer running.Add(-1)
+				return next(ctx, message, params)
+			})
+
+			results := router.SendTo(router.Targets(), "message", nil)
+			Expect(Errors(results)).To(HaveEach(BeNil()))
+			Expect(results).To(HaveLen(8))
+			Expect(maxRunning.Load()).To(Equal(int32(2)))
+		})
+		It("should keep using a slot for a timed out send until the service returns", func() {
+			router := &ServiceRouter{Timeout: 10 * time.Millisecond, Concurrency: 1}
+			Expect(router.AddNamedService("slow", "logger://")).To(Succeed())
+			Expect(router.AddNamedService("fast", "logger://")).To(Succeed())
+
+			unblock := make(chan struct{})
+			started := make(chan string, 2)
+			router.Use(func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+				started <- target.Name
+				if target.Name == "slow" {
+					<-unblock
+				}
+				return next(ctx, message, params)
+			})
+
+			results := make(chan []SendResult, 1)
+			go func() {
+				results <- router.SendTo(router.Targets(), "message", nil)
+			}()
+			Eventually(started).Should(Receive(Equal("slow")))
+			Consistently(started, 50*time.Millisecond).ShouldNot(Receive())
+
+			close(unblock)
+			Eventually(started).Should(Receive(Equal("fast")))
+			var sent []SendResult
+			Eventually(results).Should(Receive(&sent))
+			Expect(sent[0].Err).To(MatchError(ErrTimeout))
+			Expect(sent[1].Err).NotTo(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,78 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// Profile is a set of named notification targets, usually loaded from a config file
+type Profile struct {
+	Targets []TargetConfig `mapstructure:"targets"`
+	// AllowedSchemes, if not empty, restricts the services that can be used by the router
+	AllowedSchemes []string `mapstructure:"allowedschemes"`
+	// Breaker, if it has a Threshold, replaces the circuit breaker config of the router
+	Breaker BreakerConfig `mapstructure:"breaker"`
+	// Concurrency, if set, replaces the max number of targets the router sends to in parallel
+	Concurrency int `mapstructure:"concurrency"`
+}
+
+// TargetConfig is the configuration of a single named target in a Profile
+type TargetConfig struct {
+	Name string   `mapstructure:"name"`
+	URL  string   `mapstructure:"url"`
+	Tags []string `mapstructure:"tags"`
+}
+
+// LoadProfile reads a Profile from the config file at path. Any format supported by viper (YAML, JSON, TOML etc.)
+// can be used, and is determined by the file extension.
+func LoadProfile(path string) (*Profile, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
+
+	profile := &Profile{}
+	if err := v.Unmarshal(profile); err != nil {
+		return nil, fmt.Errorf("failed to parse profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// NewFromProfile creates a new service router using the specified logger and the targets of the profile
+func NewFromProfile(logger t.StdLogger, profile *Profile) (*ServiceRouter, error) {
+	router, err := New(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := router.AddProfile(profile); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// AddProfile initializes and adds all the targets of the profile. If the profile has AllowedSchemes, a Breaker or
+// Concurrency, they replace the ones of the router.
+func (router *ServiceRouter) AddProfile(profile *Profile) error {
+	if len(profile.AllowedSchemes) > 0 {
+		router.AllowedSchemes = profile.AllowedSchemes
+	}
+	if profile.Breaker.Threshold > 0 {
+		router.Breaker = profile.Breaker
+	}
+	if profile.Concurrency > 0 {
+		router.Concurrency = profile.Concurrency
+	}
+	for _, target := range profile.Targets {
+		if err := router.AddNamedService(target.Name, target.URL, target.Tags...); err != nil {
+			return fmt.Errorf("error initializing target %q: %w", target.Name, err)
+		}
+	}
+	return nil
+}
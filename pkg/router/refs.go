@@ -0,0 +1,66 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// ErrTargetNotFound is returned when updating or deleting messages using a result that was not returned by the router,
+// and whose target name does not match exactly one of the router targets
+var ErrTargetNotFound = errors.New("target not found")
+
+// Update replaces the text of the messages referenced by result with message, using the target that sent them.
+// When a message was split into several parts, the first part of each chat or channel is updated and the other
+// parts are deleted. The interceptors and timeout of the router are not used for updates.
+func (router *ServiceRouter) Update(result SendResult, message string, params *t.Params) error {
+	updater, err := router.updater(result)
+	if err != nil {
+		return err
+	}
+	updated := make(map[string]bool, len(result.Refs))
+	for _, ref := range result.Refs {
+		if updated[ref.Channel] {
+			err = updater.Delete(ref)
+		} else {
+			err = updater.Update(ref, message, params)
+			updated[ref.Channel] = true
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update using %v: %w", result.Target, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes every message referenced by result, using the target that sent them
+func (router *ServiceRouter) Delete(result SendResult) error {
+	updater, err := router.updater(result)
+	if err != nil {
+		return err
+	}
+	for _, ref := range result.Refs {
+		if err := updater.Delete(ref); err != nil {
+			return fmt.Errorf("failed to delete using %v: %w", result.Target, err)
+		}
+	}
+	return nil
+}
+
+// updater returns the service of the target that sent result, if it can update the messages
+func (router *ServiceRouter) updater(result SendResult) (t.MessageUpdater, error) {
+	target := result.sender
+	if target == nil {
+		targets := router.Select([]string{result.Target}, nil)
+		if len(targets) != 1 {
+			return nil, fmt.Errorf("failed to find %v: %w", result.Target, ErrTargetNotFound)
+		}
+		target = targets[0]
+	}
+	updater, ok := target.Service.(t.MessageUpdater)
+	if !ok {
+		return nil, fmt.Errorf("failed to update using %v: %w", result.Target, t.ErrUpdateNotSupported)
+	}
+	return updater, nil
+}
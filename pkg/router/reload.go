@@ -0,0 +1,166 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// reloadDelay is the time to wait for further changes to the profile file before reloading it, since editors
+// usually write files using several operations
+var reloadDelay = 100 * time.Millisecond
+
+// NewFromWatchedProfile creates a new service router using the specified logger and the targets of the profile file
+// at path, and reloads the targets whenever the file changes, until ctx is done. See WatchProfile.
+func NewFromWatchedProfile(ctx context.Context, logger t.StdLogger, path string, onReload func(err error)) (*ServiceRouter, error) {
+	profile, err := LoadProfile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := NewFromProfile(logger, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := router.WatchProfile(ctx, path, onReload); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// WatchProfile reloads the router targets from the profile file at path whenever it changes, until ctx is done.
+// The result of every reload is logged, and passed to onReload if it is not nil. A profile that cannot be loaded
+// is reported without changing the current targets.
+func (router *ServiceRouter) WatchProfile(ctx context.Context, path string, onReload func(err error)) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch profile: %w", err)
+	}
+
+	// The directory is watched, since editors often replace the file instead of writing to it
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("failed to watch profile: %w", err)
+	}
+
+	go router.watchProfile(ctx, watcher, path, onReload)
+	return nil
+}
+
+func (router *ServiceRouter) watchProfile(ctx context.Context, watcher *fsnotify.Watcher, path string, onReload func(err error)) {
+	defer watcher.Close()
+
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create) {
+				timer.Reset(reloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			router.log().Error("Error watching profile", "path", path, "error", err)
+		case <-timer.C:
+			err := router.reloadProfileFile(path)
+			if err != nil {
+				router.log().Error("Failed to reload profile, keeping the current targets", "path", path, "error", err)
+			} else {
+				router.log().Info("Reloaded profile", "path", path, "targets", len(router.Targets()))
+			}
+			if onReload != nil {
+				onReload(err)
+			}
+		}
+	}
+}
+
+func (router *ServiceRouter) reloadProfileFile(path string) error {
+	profile, err := LoadProfile(path)
+	if err != nil {
+		return err
+	}
+	return router.ReloadProfile(profile)
+}
+
+// ReloadProfile replaces the router targets with the targets of profile. Targets with the same name, URL and tags as
+// before are kept as they are, while new and changed targets are initialized before all the targets are replaced at
+// once. Changed targets keep the interceptors of the previous target with the same name, and its circuit breaker
+// state unless the URL has changed. Sends that are in progress complete using the previous targets. If any of the targets cannot be initialized,
+// an error is returned and the current targets are kept.
+// The other profile settings are not reloaded, and the AllowedSchemes of the router still apply to the new targets.
+// Targets added while reloading are added once the reload has finished, so that they are not lost.
+func (router *ServiceRouter) ReloadProfile(profile *Profile) error {
+	router.changeMutex.Lock()
+	defer router.changeMutex.Unlock()
+
+	current := make(map[string]*Target)
+	for _, target := range router.Targets() {
+		current[target.Name] = target
+	}
+
+	targets := make([]*Target, 0, len(profile.Targets))
+	names := make(map[string]bool, len(profile.Targets))
+	for _, config := range profile.Targets {
+		scheme, _, err := router.ExtractServiceName(config.URL)
+		if err != nil {
+			return fmt.Errorf("error initializing target %q: %w", config.Name, err)
+		}
+
+		name := config.Name
+		if name == "" {
+			name = scheme
+		}
+		if names[name] {
+			return fmt.Errorf("a target named %q has already been added", name)
+		}
+		names[name] = true
+
+		if target, found := current[name]; found && target.url == config.URL && slices.Equal(target.Tags, config.Tags) {
+			targets = append(targets, target)
+			continue
+		}
+
+		service, err := router.initService(config.URL)
+		if err != nil {
+			return fmt.Errorf("error initializing target %q: %w", name, err)
+		}
+
+		target := newTarget(name, config.Tags, scheme, service)
+		target.url = config.URL
+		if previous, found := current[name]; found {
+			target.interceptors = previous.interceptors
+			if previous.url == config.URL {
+				target.breaker = previous.breaker
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	router.targetsMutex.Lock()
+	defer router.targetsMutex.Unlock()
+	router.targets = targets
+	return nil
+}
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("reloading profiles", func() {
+	var router *ServiceRouter
+	BeforeEach(func() {
+		var err error
+		router, err = NewFromProfile(nil, &Profile{Targets: []TargetConfig{
+			{Name: "ops", URL: "logger://", Tags: []string{"alerts"}},
+			{Name: "dev", URL: "logger://?id=dev"},
+			{Name: "old", URL: "logger://?id=old"},
+		}})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should keep unchanged targets and replace the changed ones", func() {
+		ops, dev := router.Targets()[0], router.Targets()[1]
+		Expect(router.ReloadProfile(&Profile{Targets: []TargetConfig{
+			{Name: "ops", URL: "logger://", Tags: []string{"alerts"}},
+			{Name: "dev", URL: "logger://?id=changed"},
+			{Name: "new", URL: "logger://?id=new"},
+		}})).To(Succeed())
+
+		targets := router.Targets()
+		Expect(targets).To(HaveLen(3))
+		Expect(targets[0]).To(BeIdenticalTo(ops))
+		Expect(targets[1].Name).To(Equal("dev"))
+		Expect(targets[1]).NotTo(BeIdenticalTo(dev))
+		Expect(targets[2].Name).To(Equal("new"))
+	})
+	It("should replace targets with changed tags", func() {
+		ops := router.Targets()[0]
+		Expect(router.ReloadProfile(&Profile{Targets: []TargetConfig{
+			{Name: "ops", URL: "logger://", Tags: []string{"builds"}},
+		}})).To(Succeed())
+		Expect(router.Targets()[0]).NotTo(BeIdenticalTo(ops))
+		Expect(router.Targets()[0].Tags).To(Equal([]string{"builds"}))
+	})
+	It("should keep the interceptors and circuit breaker of changed targets", func() {
+		ops, dev := router.Targets()[0], router.Targets()[1]
+		var calls []string
+		mutex := sync.Mutex{}
+		interceptor := func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+			mutex.Lock()
+			calls = append(calls, target.Name)
+			mutex.Unlock()
+			return next(ctx, message, params)
+		}
+		ops.Use(interceptor)
+		dev.Use(interceptor)
+
+		Expect(router.ReloadProfile(&Profile{Targets: []TargetConfig{
+			{Name: "ops", URL: "logger://", Tags: []string{"builds"}},
+			{Name: "dev", URL: "logger://?id=changed"},
+		}})).To(Succeed())
+
+		targets := router.Targets()
+		Expect(targets[0].breaker).To(BeIdenticalTo(ops.breaker))
+		Expect(targets[1].breaker).NotTo(BeIdenticalTo(dev.breaker))
+		Expect(Errors(router.SendTo(targets, "message", nil))).To(Equal([]error{nil, nil}))
+		Expect(calls).To(ConsistOf("ops", "dev"))
+	})
+	It("should keep the current targets if a target cannot be initialized", func() {
+		targets := router.Targets()
+		Expect(router.ReloadProfile(&Profile{Targets: []TargetConfig{
+			{Name: "ops", URL: "logger://?id=changed"},
+			{Name: "broken", URL: "unknown://"},
+		}})).To(MatchError(ContainSubstring(`"broken"`)))
+		Expect(router.Targets()).To(Equal(targets))
+	})
+	It("should return an error for duplicate target names", func() {
+		Expect(router.ReloadProfile(&Profile{Targets: []TargetConfig{
+			{Name: "ops", URL: "logger://"},
+			{Name: "ops", URL: "logger://?id=other"},
+		}})).To(HaveOccurred())
+		Expect(router.Targets()).To(HaveLen(3))
+	})
+	It("should still apply the allowed schemes of the router", func() {
+		router.AllowedSchemes = []string{"logger"}
+		Expect(router.ReloadProfile(&Profile{
+			Targets:        []TargetConfig{{Name: "hook", URL: "generic://example.com/hook"}},
+			AllowedSchemes: []string{"generic"},
+		})).To(HaveOccurred())
+	})
+	It("should not interrupt concurrent sends", func() {
+		wg := sync.WaitGroup{}
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					Expect(router.Send("message", nil)).To(HaveEach(BeNil()))
+				}
+			}()
+		}
+		for i := 0; i < 20; i++ {
+			Expect(router.ReloadProfile(&Profile{Targets: []TargetConfig{
+				{Name: "ops", URL: "logger://"},
+				{Name: "dev", URL: "logger://?id=" + string(rune('a'+i))},
+			}})).To(Succeed())
+		}
+		wg.Wait()
+	})
+
+	When("watching the profile file", func() {
+		var path string
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "profile.yaml")
+			writeProfile(path, "targets:\n  - name: ops\n    url: logger://\n")
+		})
+
+		It("should reload the targets when the file changes", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reloads := make(chan error, 10)
+			router, err := NewFromWatchedProfile(ctx, nil, path, func(err error) { reloads <- err })
+			Expect(err).NotTo(HaveOccurred())
+			Expect(router.Targets()).To(HaveLen(1))
+
+			writeProfile(path, "targets:\n  - name: ops\n    url: logger://\n  - name: dev\n    url: logger://?id=dev\n")
+			Eventually(reloads).Should(Receive(BeNil()))
+			Expect(router.Targets()).To(HaveLen(2))
+
+			writeProfile(path, "targets:\n  - name: broken\n    url: unknown://\n")
+			Eventually(reloads).Should(Receive(HaveOccurred()))
+			Expect(router.Targets()).To(HaveLen(2))
+		})
+		It("should reload the targets when the file is replaced", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			reloads := make(chan error, 10)
+			router, err := NewFromWatchedProfile(ctx, nil, path, func(err error) { reloads <- err })
+			Expect(err).NotTo(HaveOccurred())
+
+			replacement := path + ".tmp"
+			writeProfile(replacement, "targets:\n  - name: dev\n    url: logger://?id=dev\n")
+			Expect(os.Rename(replacement, path)).To(Succeed())
+			Eventually(reloads).Should(Receive(BeNil()))
+			Expect(router.Targets()[0].Name).To(Equal("dev"))
+		})
+		It("should return an error if the initial profile is invalid", func() {
+			writeProfile(path, "targets:\n  - name: broken\n    url: unknown://\n")
+			_, err := NewFromWatchedProfile(context.Background(), nil, path, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+func writeProfile(path string, content string) {
+	Expect(os.WriteFile(path, []byte(content), 0o600)).To(Succeed())
+}
@@ -1,21 +1,45 @@
 package router
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/url"
-	"reflect"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/logging"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
 )
 
 // ServiceRouter is responsible for routing a message to a specific notification service using the notification URL
 type ServiceRouter struct {
-	logger   t.StdLogger
-	services []t.Service
-	queue    []string
-	Timeout  time.Duration
+	logger       t.StdLogger
+	metrics      Metrics
+	tracer       t.Tracer
+	interceptors []Interceptor
+	targets      []*Target
+	targetsMutex sync.RWMutex
+	changeMutex  sync.Mutex // held while adding or reloading targets, without blocking sends during initialization
+	queue        []string
+	pool         workerPool
+	cache        serviceCache
+	Timeout      time.Duration
+	// AllowedSchemes, if not empty, are the only service schemes that can be used
+	AllowedSchemes []string
+	// Breaker configures the circuit breakers that stop sending using targets that keep failing
+	Breaker BreakerConfig
+	// CacheSize is the number of initialized services kept for reuse by Route and LocateCached. If 0, the
+	// DefaultCacheSize is used, and if negative, services are not cached.
+	CacheSize int
+	// Concurrency is the max number of targets that are sent to in parallel, shared by all sends made by the router.
+	// A send that times out keeps using one of them until the service returns, so that services that keep hanging
+	// can't make the router start more sends. If 0, all targets are sent to in parallel.
+	Concurrency int
 }
 
 // New creates a new service router using the specified logger and service URLs
@@ -35,11 +59,96 @@ func New(logger t.StdLogger, serviceURLs ...string) (*ServiceRouter, error) {
 
 // AddService initializes the specified service from its URL, and adds it if no errors occur
 func (router *ServiceRouter) AddService(serviceURL string) error {
+	return router.AddNamedService("", serviceURL)
+}
+
+// AddNamedService initializes the specified service from its URL, and adds it as a target identified by name and
+// tags if no errors occur. If name is empty, the service scheme is used as the target name.
+func (router *ServiceRouter) AddNamedService(name string, serviceURL string, tags ...string) error {
+	router.changeMutex.Lock()
+	defer router.changeMutex.Unlock()
+
+	if err := router.checkTargetName(name); err != nil {
+		return err
+	}
+
+	scheme, _, err := router.ExtractServiceName(serviceURL)
+	if err != nil {
+		return err
+	}
+
 	service, err := router.initService(serviceURL)
-	if err == nil {
-		router.services = append(router.services, service)
+	if err != nil {
+		return err
+	}
+
+	router.addTarget(name, tags, scheme, service, serviceURL)
+	return nil
+}
+
+// AddServiceConfig initializes a service using config instead of a URL, and adds it if no errors occur
+func (router *ServiceRouter) AddServiceConfig(config t.ServiceConfig) error {
+	return router.AddNamedServiceConfig("", config)
+}
+
+// AddNamedServiceConfig initializes a service using config instead of a URL, and adds it as a target identified by
+// name and tags if no errors occur. If name is empty, the service scheme is used as the target name.
+func (router *ServiceRouter) AddNamedServiceConfig(name string, config t.ServiceConfig, tags ...string) error {
+	router.changeMutex.Lock()
+	defer router.changeMutex.Unlock()
+
+	if err := router.checkTargetName(name); err != nil {
+		return err
+	}
+
+	serviceURL := config.GetURL()
+	scheme := strings.ToLower(serviceURL.Scheme)
+	if !router.schemeAllowed(scheme) {
+		return fmt.Errorf("service %q is not allowed", scheme)
+	}
+
+	service, err := newService(scheme)
+	if err != nil {
+		return err
+	}
+
+	initializer, ok := service.(t.ConfigInitializer)
+	if !ok {
+		return fmt.Errorf("service %q cannot be initialized using a config", scheme)
+	}
+
+	if err := initializer.InitializeConfig(config, router.logger); err != nil {
+		return err
+	}
+
+	router.addTarget(name, tags, scheme, service, serviceURL.String())
+	return nil
+}
+
+// checkTargetName returns an error if a target named name has already been added. The changeMutex must be held by the
+// caller, so that no target with the same name can be added before the new target.
+func (router *ServiceRouter) checkTargetName(name string) error {
+	if name != "" {
+		for _, target := range router.Targets() {
+			if target.Name == name {
+				return fmt.Errorf("a target named %q has already been added", name)
+			}
+		}
 	}
-	return err
+	return nil
+}
+
+func (router *ServiceRouter) addTarget(name string, tags []string, scheme string, service t.Service, serviceURL string) {
+	if name == "" {
+		name = scheme
+	}
+
+	target := newTarget(name, tags, scheme, service)
+	target.url = serviceURL
+
+	router.targetsMutex.Lock()
+	defer router.targetsMutex.Unlock()
+	router.targets = append(router.targets, target)
 }
 
 // Send sends the specified message using the routers underlying services
@@ -48,15 +157,7 @@ func (router *ServiceRouter) Send(message string, params *t.Params) []error {
 		return []error{fmt.Errorf("error sending message: no senders")}
 	}
 
-	serviceCount := len(router.services)
-	errors := make([]error, serviceCount)
-	results := router.SendAsync(message, params)
-
-	for i := range router.services {
-		errors[i] = <-results
-	}
-
-	return errors
+	return Errors(router.SendTo(router.Targets(), message, params))
 }
 
 // SendItems sends the specified message items using the routers underlying services
@@ -65,63 +166,244 @@ func (router *ServiceRouter) SendItems(items []t.MessageItem, params t.Params) [
 		return []error{fmt.Errorf("error sending message: no senders")}
 	}
 
-	// Fallback using old API for now
-	message := strings.Builder{}
-	for _, item := range items {
-		message.WriteString(item.Text)
+	return Errors(router.SendItemsTo(router.Targets(), items, &params))
+}
+
+// SendAsync sends the specified message using the routers underlying services
+func (router *ServiceRouter) SendAsync(message string, params *t.Params) chan error {
+	targets := router.Targets()
+	targetCount := len(targets)
+	errors := make(chan error, targetCount)
+	results := router.sendAsync(context.Background(), targets, message, sendPlain, params)
+
+	go func() {
+		for result := range results {
+			errors <- result.Err
+		}
+		close(errors)
+	}()
+
+	return errors
+}
+
+// SendTo sends the specified message using the supplied targets, returning the result for each of them in the same
+// order as the targets
+func (router *ServiceRouter) SendTo(targets []*Target, message string, params *t.Params) []SendResult {
+	return router.SendToContext(context.Background(), targets, message, params)
+}
+
+// SendToContext is like SendTo, but uses ctx as the parent of any trace spans. If ctx is done before a target has
+// finished sending, the context error is returned for that target.
+func (router *ServiceRouter) SendToContext(ctx context.Context, targets []*Target, message string, params *t.Params) []SendResult {
+	return router.collect(ctx, targets, message, sendPlain, params)
+}
+
+// SendItemsTo sends the specified message items using the supplied targets, returning the result for each of them
+// in the same order as the targets. Services that does not implement the RichSender API will receive the items
+// joined as a plain text message.
+func (router *ServiceRouter) SendItemsTo(targets []*Target, items []t.MessageItem, params *t.Params) []SendResult {
+	return router.SendItemsToContext(context.Background(), targets, items, params)
+}
+
+// SendItemsToContext is like SendItemsTo, but uses ctx as the parent of any trace spans. If ctx is done before a
+// target has finished sending, the context error is returned for that target.
+// Interceptors receive the items joined as a plain text message. If they change it, the changed message is sent as
+// plain text instead of the items.
+// Services that translate the service independent priorities receive the priority of the highest item level, unless
+// the priority param is set.
+// Attachments are read before sending, and sending them to a service that does not implement AttachmentSender fails
+// with ErrAttachmentsNotSupported.
+func (router *ServiceRouter) SendItemsToContext(ctx context.Context, targets []*Target, items []t.MessageItem, params *t.Params) []SendResult {
+	results := make([]SendResult, len(targets))
+	readErr := t.ReadAttachments(items)
+
+	// Targets that cannot send the attachments fails without sending anything
+	var sendTargets []*Target
+	var sendIndices []int
+	for i, target := range targets {
+		err := readErr
+		if err == nil {
+			err = t.CheckAttachments(target.Service, items)
+		}
+		if err != nil {
+			results[i] = SendResult{
+				Target: target.Name,
+				Scheme: target.Scheme,
+				Err:    fmt.Errorf("failed to send using %v: %w", target.Name, err),
+				sender: target,
+			}
+			continue
+		}
+		sendTargets = append(sendTargets, target)
+		sendIndices = append(sendIndices, i)
+	}
+
+	plain := strings.TrimSuffix(t.ItemsToPlain(items), "\n")
+	sent := router.collect(ctx, sendTargets, plain, func(target *Target, service t.Service, message string, params *t.Params) ([]t.MessageRef, error) {
+		if _, isMapper := service.(priority.Mapper); isMapper && !target.hasPriority() {
+			params = priority.WithItemsLevel(params, items)
+		}
+		if richSender, isRich := service.(t.RichSender); isRich && message == plain {
+			if params == nil {
+				return nil, richSender.SendItems(items, nil)
+			}
+			return nil, richSender.SendItems(items, *params)
+		}
+		return sendPlain(target, service, message, params)
+	}, params)
+
+	for i, result := range sent {
+		results[sendIndices[i]] = result
 	}
+	return results
+}
 
-	serviceCount := len(router.services)
-	errors := make([]error, serviceCount)
-	results := router.SendAsync(message.String(), &params)
+// sendFunc sends the message using service, which is the service of target bound to the context of the send
+type sendFunc func(target *Target, service t.Service, message string, params *t.Params) ([]t.MessageRef, error)
 
-	for i := range router.services {
-		errors[i] = <-results
+// sendPlain sends the message, returning the references to the sent messages if the service is a RefSender
+func sendPlain(_ *Target, service t.Service, message string, params *t.Params) ([]t.MessageRef, error) {
+	if refSender, ok := service.(t.RefSender); ok {
+		return refSender.SendRefs(message, params)
 	}
+	return nil, service.Send(message, params)
+}
 
-	return errors
+func (router *ServiceRouter) collect(ctx context.Context, targets []*Target, message string, send sendFunc, params *t.Params) []SendResult {
+	results := make([]SendResult, len(targets))
+	indices := make(map[*Target]int, len(targets))
+	for i, target := range targets {
+		indices[target] = i
+	}
+
+	for result := range router.sendAsync(ctx, targets, message, send, params) {
+		results[indices[result.sender]] = result
+	}
+
+	return results
 }
 
-// SendAsync sends the specified message using the routers underlying services
-func (router *ServiceRouter) SendAsync(message string, params *t.Params) chan error {
-	serviceCount := len(router.services)
-	proxy := make(chan error, serviceCount)
-	errors := make(chan error, serviceCount)
+func (router *ServiceRouter) sendAsync(ctx context.Context, targets []*Target, message string, send sendFunc, params *t.Params) chan SendResult {
+	targetCount := len(targets)
+	proxy := make(chan SendResult, targetCount)
+	results := make(chan SendResult, targetCount)
 
 	if params == nil {
 		params = &t.Params{}
 	}
-	for _, service := range router.services {
-		go sendToService(service, proxy, router.Timeout, message, *params)
+	for _, target := range targets {
+		if router.Concurrency > 0 {
+			router.pool.submit(router.Concurrency, func() {
+				<-router.sendToService(ctx, target, proxy, message, send, *params)
+			})
+		} else {
+			go router.sendToService(ctx, target, proxy, message, send, *params)
+		}
 	}
 
 	go func() {
-		for i := 0; i < serviceCount; i++ {
-			errors <- <-proxy
+		for i := 0; i < targetCount; i++ {
+			results <- <-proxy
 		}
-		close(errors)
+		close(results)
 	}()
 
-	return errors
+	return results
 }
 
-func sendToService(service t.Service, results chan error, timeout time.Duration, message string, params t.Params) {
-	result := make(chan error)
+// sendToService sends using the target, and returns a channel that is closed once the service has returned, which is
+// after the result has been sent to results if the send timed out
+func (router *ServiceRouter) sendToService(ctx context.Context, target *Target, results chan SendResult, message string, send sendFunc, params t.Params) <-chan struct{} {
+	start := time.Now()
+	ctx, span := router.startSpan(ctx, t.SpanSend, target)
+
+	var err error
+	var refs []t.MessageRef
+	returned := make(chan struct{})
+	allowed, state := target.breaker.allow(router.Breaker, start)
+	if allowed {
+		refs, err = router.send(ctx, target, message, send, &params, returned)
+		if errors.Is(err, context.Canceled) {
+			target.breaker.release()
+		} else {
+			state = router.recordCircuit(target, err, state)
+		}
+	} else {
+		err = fmt.Errorf("failed to send using %v: %w", target.Name, standard.Failure(standard.FailCircuitOpen, nil))
+		close(returned)
+	}
+	span.End(spanStatus(err), err)
+
+	sendResult := SendResult{
+		Target: target.Name,
+		Scheme: target.Scheme,
+		Err:    err,
+		Refs:   refs,
+		sender: target,
+	}
+	if router.Breaker.Threshold > 0 {
+		sendResult.Circuit = state
+	}
+
+	if router.metrics != nil {
+		router.metrics.ObserveSend(sendResult, time.Since(start))
+	}
+
+	results <- sendResult
+	return returned
+}
 
-	// TODO: There really ought to be a better way to name the services
-	pkg := reflect.TypeOf(service).Elem().PkgPath()
-	serviceName := pkg[strings.LastIndex(pkg, "/")+1:]
+// send calls the interceptors and sends using the target service, returning an error if the router Timeout is
+// reached or ctx is done before it has finished. returned is closed once the interceptors and service have returned.
+func (router *ServiceRouter) send(ctx context.Context, target *Target, message string, send sendFunc, params *t.Params, returned chan struct{}) ([]t.MessageRef, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, router.Timeout, ErrTimeout)
+	defer cancel()
 
-	go func() { result <- service.Send(message, &params) }()
+	type sent struct {
+		refs []t.MessageRef
+		err  error
+	}
+	result := make(chan sent, 1)
+	go func() {
+		defer close(returned)
+		var refs []t.MessageRef
+		err := router.intercept(ctx, target, message, params, func(ctx context.Context, message string, params *t.Params) error {
+			var err error
+			refs, err = router.attempt(ctx, target, message, send, params)
+			return err
+		})
+		result <- sent{refs: refs, err: err}
+	}()
 
 	select {
 	case res := <-result:
-		results <- res
-	case <-time.After(timeout):
-		results <- fmt.Errorf("failed to send using %v: timed out", serviceName)
+		return res.refs, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("failed to send using %v: %w", target.Name, context.Cause(ctx))
 	}
 }
 
+// recordCircuit records the send outcome in the target circuit breaker, logging any state change
+func (router *ServiceRouter) recordCircuit(target *Target, err error, previous CircuitState) CircuitState {
+	state := target.breaker.record(router.Breaker, err, time.Now())
+	if state != previous {
+		router.log().Warn("Circuit breaker state changed", "target", target.Name, "from", previous, "to", state)
+	}
+	return state
+}
+
+// attempt sends using the target service, making the requests of services that support it using ctx
+func (router *ServiceRouter) attempt(ctx context.Context, target *Target, message string, send sendFunc, params *t.Params) ([]t.MessageRef, error) {
+	ctx, span := router.startSpan(ctx, t.SpanAttempt, target)
+	service := target.Service
+	if contextService, ok := service.(t.ContextService); ok {
+		service = contextService.WithContext(ctx)
+	}
+	refs, err := send(target, service, message, params)
+	span.End(spanStatus(err), err)
+	return refs, err
+}
+
 // Enqueue adds the message to an internal queue and sends it when Flush is invoked
 func (router *ServiceRouter) Enqueue(message string, v ...interface{}) {
 	if len(v) > 0 {
@@ -140,9 +422,12 @@ func (router *ServiceRouter) Flush(params *t.Params) {
 // SetLogger sets the logger that the services will use to write progress logs
 func (router *ServiceRouter) SetLogger(logger t.StdLogger) {
 	router.logger = logger
-	for _, service := range router.services {
-		service.SetLogger(logger)
+	for _, target := range router.Targets() {
+		target.Service.SetLogger(logger)
 	}
+	router.cache.each(func(service t.Service) {
+		service.SetLogger(logger)
+	})
 }
 
 // ExtractServiceName from a notification URL
@@ -165,7 +450,7 @@ func (router *ServiceRouter) ExtractServiceName(rawURL string) (string, *url.URL
 // Route a message to a specific notification service using the notification URL
 func (router *ServiceRouter) Route(rawURL string, message string) error {
 
-	service, err := router.Locate(rawURL)
+	service, err := router.LocateCached(rawURL)
 	if err != nil {
 		return err
 	}
@@ -180,13 +465,17 @@ func (router *ServiceRouter) initService(rawURL string) (t.Service, error) {
 		return nil, err
 	}
 
+	if !router.schemeAllowed(scheme) {
+		return nil, fmt.Errorf("service %q is not allowed", scheme)
+	}
+
 	service, err := newService(scheme)
 	if err != nil {
 		return nil, err
 	}
 
 	if configURL.Scheme != scheme {
-		router.log("Got custom URL:", configURL.String())
+		router.log().Debug("Got custom URL", "url", configURL.String())
 		customURLService, ok := service.(t.CustomURLService)
 		if !ok {
 			return nil, fmt.Errorf("custom URLs are not supported by '%s' service", scheme)
@@ -195,7 +484,7 @@ func (router *ServiceRouter) initService(rawURL string) (t.Service, error) {
 		if err != nil {
 			return nil, err
 		}
-		router.log("Converted service URL:", configURL.String())
+		router.log().Debug("Converted service URL", "url", configURL.String())
 	}
 
 	err = service.Initialize(configURL, router.logger)
@@ -206,6 +495,18 @@ func (router *ServiceRouter) initService(rawURL string) (t.Service, error) {
 	return service, nil
 }
 
+func (router *ServiceRouter) schemeAllowed(scheme string) bool {
+	if len(router.AllowedSchemes) == 0 {
+		return true
+	}
+	for _, allowed := range router.AllowedSchemes {
+		if strings.EqualFold(allowed, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewService returns a new uninitialized service instance
 func (*ServiceRouter) NewService(serviceScheme string) (t.Service, error) {
 	return newService(serviceScheme)
@@ -239,9 +540,10 @@ func (router *ServiceRouter) Locate(rawURL string) (t.Service, error) {
 	return service, err
 }
 
-func (router *ServiceRouter) log(v ...interface{}) {
+// log returns the router logger as a leveled Logger, discarding the logs if no logger has been set
+func (router *ServiceRouter) log() t.Logger {
 	if router.logger == nil {
-		return
+		return logging.Leveled(util.DiscardLogger)
 	}
-	router.logger.Println(v...)
+	return logging.Leveled(router.logger)
 }
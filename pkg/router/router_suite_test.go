@@ -1,18 +1,31 @@
 package router
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/internal/testutils"
+	"github.com/dockerutil/shoutrrr/pkg/services/discord"
+	"github.com/dockerutil/shoutrrr/pkg/services/gotify"
+	"github.com/dockerutil/shoutrrr/pkg/services/logger"
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/jarcoal/httpmock"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
-func TestRouter(t *testing.T) {
+func TestRouter(test *testing.T) {
 	RegisterFailHandler(Fail)
-	RunSpecs(t, "Router Suite")
+	RunSpecs(test, "Router Suite")
 }
 
 var sr ServiceRouter
@@ -24,7 +37,8 @@ const (
 var _ = Describe("the router suite", func() {
 	BeforeEach(func() {
 		sr = ServiceRouter{
-			logger: log.New(GinkgoWriter, "Test", log.LstdFlags),
+			logger:  log.New(GinkgoWriter, "Test", log.LstdFlags),
+			Timeout: 10 * time.Second,
 		}
 	})
 
@@ -117,6 +131,388 @@ var _ = Describe("the router suite", func() {
 			})
 		})
 	})
+	When("adding named services", func() {
+		It("should use the scheme as the name for unnamed services", func() {
+			Expect(sr.AddService("logger://")).To(Succeed())
+			Expect(sr.Targets()).To(HaveLen(1))
+			Expect(sr.Targets()[0].Name).To(Equal("logger"))
+			Expect(sr.Targets()[0].Scheme).To(Equal("logger"))
+		})
+		It("should return an error if the name is already in use", func() {
+			Expect(sr.AddNamedService("foo", "logger://")).To(Succeed())
+			Expect(sr.AddNamedService("foo", "logger://")).NotTo(Succeed())
+		})
+		It("should only add one of the targets using the same name concurrently", func() {
+			errs := make(chan error, 32)
+			start := make(chan struct{})
+			for i := 0; i < cap(errs); i++ {
+				go func() {
+					<-start
+					errs <- sr.AddNamedService("foo", "logger://")
+				}()
+			}
+			close(start)
+			added := 0
+			for i := 0; i < cap(errs); i++ {
+				if <-errs == nil {
+					added++
+				}
+			}
+			Expect(added).To(Equal(1))
+			Expect(sr.Targets()).To(HaveLen(1))
+		})
+	})
+	When("selecting targets", func() {
+		BeforeEach(func() {
+			Expect(sr.AddNamedService("a", "logger://", "ops")).To(Succeed())
+			Expect(sr.AddNamedService("b", "logger://", "ops", "dev")).To(Succeed())
+			Expect(sr.AddNamedService("c", "logger://")).To(Succeed())
+		})
+		It("should return all targets if no names or tags are given", func() {
+			Expect(sr.Select(nil, nil)).To(HaveLen(3))
+		})
+		It("should return targets matching either a name or a tag", func() {
+			targets := sr.Select([]string{"c"}, []string{"dev"})
+			Expect(targets).To(HaveLen(2))
+			Expect(targets[0].Name).To(Equal("b"))
+			Expect(targets[1].Name).To(Equal("c"))
+		})
+		It("should return the results in target order", func() {
+			results := sr.SendTo(sr.Select(nil, []string{"ops"}), "message", nil)
+			Expect(results).To(HaveLen(2))
+			Expect(results[0].Target).To(Equal("a"))
+			Expect(results[1].Target).To(Equal("b"))
+			Expect(Errors(results)).To(Equal([]error{nil, nil}))
+		})
+	})
+	When("a tracer has been set", func() {
+		var tracer *recordingTracer
+		BeforeEach(func() {
+			tracer = &recordingTracer{}
+			sr.SetTracer(tracer)
+		})
+		AfterEach(func() {
+			sr.SetTracer(nil)
+		})
+		It("should start and end spans around the send and each attempt", func() {
+			Expect(sr.AddNamedService("ops", "logger://")).To(Succeed())
+			ctx := context.WithValue(context.Background(), tracerKey{}, "parent")
+			results := sr.SendToContext(ctx, sr.Targets(), "message", nil)
+			Expect(Errors(results)).To(Equal([]error{nil}))
+			Expect(tracer.spans()).To(ConsistOf(
+				recordedSpan{name: t.SpanSend, target: "ops", scheme: "logger", parent: "parent", status: t.SpanStatusOK},
+				recordedSpan{name: t.SpanAttempt, target: "ops", scheme: "logger", parent: t.SpanSend, status: t.SpanStatusOK},
+			))
+		})
+		It("should trace the HTTP requests as children of the attempt and report the errors", func() {
+			Expect(sr.AddNamedService("broken", "generic://127.0.0.1:0/hook?disabletls=yes")).To(Succeed())
+			results := sr.SendTo(sr.Targets(), "message", nil)
+			Expect(results[0].Err).To(HaveOccurred())
+			parents := map[string]interface{}{}
+			for _, span := range tracer.spans() {
+				Expect(span.status).To(Equal(t.SpanStatusError))
+				Expect(span.err).To(HaveOccurred())
+				if span.name == t.SpanHTTP {
+					Expect(span.target).To(Equal("127.0.0.1:0"))
+				}
+				parents[span.name] = span.parent
+			}
+			Expect(parents).To(Equal(map[string]interface{}{
+				t.SpanSend:    nil,
+				t.SpanAttempt: t.SpanSend,
+				t.SpanHTTP:    t.SpanAttempt,
+			}))
+		})
+		It("should not trace the HTTP requests of other routers", func() {
+			other, err := New(nil, "generic://127.0.0.1:0/hook?disabletls=yes")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.SendTo(other.Targets(), "message", nil)[0].Err).To(HaveOccurred())
+			Expect(tracer.spans()).To(BeEmpty())
+		})
+		It("should report timed out sends", func() {
+			// Use a separate router, since the timed out send will still be running after the test
+			router := &ServiceRouter{}
+			router.SetTracer(tracer)
+			Expect(router.AddNamedService("ops", "logger://")).To(Succeed())
+			results := router.SendTo(router.Targets(), "message", nil)
+			Expect(results[0].Err).To(MatchError(ErrTimeout))
+			Expect(tracer.spans()).To(ContainElement(
+				recordedSpan{name: t.SpanSend, target: "ops", scheme: "logger", status: t.SpanStatusTimeout, err: results[0].Err},
+			))
+		})
+	})
+	When("interceptors have been added", func() {
+		var output *strings.Builder
+		var calls []string
+		BeforeEach(func() {
+			output = &strings.Builder{}
+			calls = nil
+			sr.logger = log.New(output, "", 0)
+			Expect(sr.AddNamedService("ops", "logger://")).To(Succeed())
+			Expect(sr.AddNamedService("dev", "logger://")).To(Succeed())
+		})
+		recorder := func(name string) Interceptor {
+			return func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+				calls = append(calls, name+":"+target.Name)
+				return next(ctx, message, params)
+			}
+		}
+		It("should call the router interceptors before the target interceptors", func() {
+			sr.Use(recorder("first"), recorder("second"))
+			sr.Targets()[0].Use(recorder("target"))
+			results := sr.SendTo(sr.Select([]string{"ops"}, nil), "message", nil)
+			Expect(Errors(results)).To(Equal([]error{nil}))
+			Expect(calls).To(Equal([]string{"first:ops", "second:ops", "target:ops"}))
+		})
+		It("should send the modified message and params", func() {
+			sr.Use(func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+				params.SetTitle("Title")
+				return next(ctx, "["+target.Name+"] "+message, params)
+			})
+			sr.SendTo(sr.Select([]string{"ops"}, nil), "message", nil)
+			Expect(output.String()).To(Equal("[ops] message\n"))
+		})
+		It("should skip the send if next is not called", func() {
+			sr.Targets()[1].Use(func(context.Context, *Target, string, *t.Params, SendFunc) error {
+				return nil
+			})
+			Expect(Errors(sr.SendTo(sr.Targets(), "message", nil))).To(Equal([]error{nil, nil}))
+			Expect(output.String()).To(Equal("message\n"))
+		})
+		It("should be able to observe and replace the send error", func() {
+			Expect(sr.AddNamedService("broken", "generic://127.0.0.1:0/hook?disabletls=yes")).To(Succeed())
+			var observed error
+			sr.Targets()[2].Use(func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+				observed = next(ctx, message, params)
+				return fmt.Errorf("audited: %w", observed)
+			})
+			results := sr.SendTo(sr.Select([]string{"broken"}, nil), "message", nil)
+			Expect(observed).To(HaveOccurred())
+			Expect(results[0].Err).To(MatchError(observed))
+			Expect(results[0].Err.Error()).To(HavePrefix("audited: "))
+		})
+		It("should send items as plain text if the message was modified", func() {
+			sr.Use(func(ctx context.Context, target *Target, message string, params *t.Params, next SendFunc) error {
+				return next(ctx, strings.ToUpper(message), params)
+			})
+			items := []t.MessageItem{{Text: "first"}, {Text: "second"}}
+			sr.SendItemsTo(sr.Select([]string{"ops"}, nil), items, nil)
+			Expect(output.String()).To(Equal("FIRST\nSECOND\n"))
+		})
+	})
+	When("sending items to rich senders", func() {
+		It("should send the items with the params", func() {
+			service := &richService{Service: &logger.Service{}}
+			Expect(service.Initialize(testutils.URLMust("logger://"), nil)).To(Succeed())
+			router, err := New(nil)
+			Expect(err).NotTo(HaveOccurred())
+			router.addTarget("rich", nil, "logger", service, "logger://")
+
+			items := []t.MessageItem{{Text: "first"}, {Text: "second"}}
+			Expect(router.SendItemsTo(router.Targets(), items, &t.Params{"title": "report"})[0].Err).NotTo(HaveOccurred())
+			Expect(service.items).To(Equal(items))
+			Expect(service.params).To(Equal(t.Params{"title": "report"}))
+			Expect(router.SendItemsTo(router.Targets(), items, nil)[0].Err).NotTo(HaveOccurred())
+			Expect(service.params).To(BeEmpty())
+		})
+	})
+	When("sending items with attachments", func() {
+		It("should fail for services that does not support attachments without sending", func() {
+			output := &strings.Builder{}
+			router, err := New(log.New(output, "", 0), "logger://")
+			Expect(err).NotTo(HaveOccurred())
+
+			item := t.MessageItem{Text: "report"}
+			item.WithAttachment(t.Attachment{Name: "report.txt", Reader: strings.NewReader("content")})
+			results := router.SendItemsTo(router.Targets(), []t.MessageItem{item}, nil)
+
+			Expect(results[0].Err).To(MatchError(t.ErrAttachmentsNotSupported))
+			Expect(results[0].Scheme).To(Equal("logger"))
+			Expect(output.String()).To(BeEmpty())
+			Expect(string(item.Attachments[0].Data)).To(Equal("content"))
+		})
+	})
+	When("sending items to services with a native priority", func() {
+		var priorities []string
+		BeforeEach(func() {
+			priorities = nil
+			httpmock.Activate()
+			httpmock.RegisterResponder("POST", "https://api.pushover.net/1/messages.json", func(req *http.Request) (*http.Response, error) {
+				if err := req.ParseForm(); err != nil {
+					return nil, err
+				}
+				priorities = append(priorities, req.PostForm.Get("priority"))
+				return httpmock.NewStringResponse(200, "{}"), nil
+			})
+		})
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+		It("should set the priority from the highest level of the items", func() {
+			router, err := New(nil, "pushover://:apptoken@usertoken")
+			Expect(err).NotTo(HaveOccurred())
+
+			items := []t.MessageItem{{Text: "deployed", Level: t.Info}, {Text: "disk almost full", Level: t.Warning}}
+			Expect(router.SendItemsTo(router.Targets(), items, nil)[0].Err).NotTo(HaveOccurred())
+			Expect(router.SendItemsTo(router.Targets(), items, &t.Params{"priority": "min"})[0].Err).NotTo(HaveOccurred())
+			Expect(priorities).To(Equal([]string{"1", "-2"}))
+		})
+		It("should not replace the priority set in the service URL", func() {
+			var gotifyPriorities []float64
+			httpmock.RegisterResponder("POST", "https://my.gotify.tld/message?token=Aaa.bbb.ccc.ddd", func(req *http.Request) (*http.Response, error) {
+				var body map[string]interface{}
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					return nil, err
+				}
+				gotifyPriorities = append(gotifyPriorities, body["priority"].(float64))
+				return httpmock.NewStringResponse(200, "{}"), nil
+			})
+			router, err := New(nil, "gotify://my.gotify.tld/Aaa.bbb.ccc.ddd?priority=8", "pushover://:apptoken@usertoken?priority=-1")
+			Expect(err).NotTo(HaveOccurred())
+			httpmock.ActivateNonDefault(router.Targets()[0].Service.(*gotify.Service).GetHTTPClient())
+
+			items := []t.MessageItem{{Text: "disk full", Level: t.Error}}
+			for _, result := range router.SendItemsTo(router.Targets(), items, nil) {
+				Expect(result.Err).NotTo(HaveOccurred())
+			}
+			Expect(gotifyPriorities).To(Equal([]float64{8}))
+			Expect(priorities).To(Equal([]string{"-1"}))
+		})
+		It("should not replace a priority set using an alias of the priority key", func() {
+			var levels []string
+			httpmock.RegisterResponder("POST", "https://hostname/push", func(req *http.Request) (*http.Response, error) {
+				var body map[string]interface{}
+				if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+					return nil, err
+				}
+				levels = append(levels, body["level"].(string))
+				return httpmock.NewStringResponse(200, `{"code": 200, "message": "OK"}`), nil
+			})
+			router, err := New(nil, "bark://:devicekey@hostname?level=critical")
+			Expect(err).NotTo(HaveOccurred())
+
+			items := []t.MessageItem{{Text: "deployed", Level: t.Info}}
+			Expect(router.SendItemsTo(router.Targets(), items, nil)[0].Err).NotTo(HaveOccurred())
+			Expect(levels).To(Equal([]string{"critical"}))
+		})
+	})
+	When("updating sent messages", func() {
+		const webhookURL = "https://discord.com/api/webhooks/1234/token"
+		BeforeEach(func() {
+			httpmock.Activate()
+		})
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+		It("should return the message references and update the messages using them", func() {
+			httpmock.RegisterResponder("POST", webhookURL+"?wait=true", httpmock.NewStringResponder(200, `{"id": "42"}`))
+			httpmock.RegisterResponder("PATCH", webhookURL+"/messages/42", httpmock.NewStringResponder(200, `{"id": "42"}`))
+			httpmock.RegisterResponder("DELETE", webhookURL+"/messages/42", httpmock.NewStringResponder(204, ""))
+			router, err := New(nil, "discord://token@1234")
+			Expect(err).NotTo(HaveOccurred())
+
+			result := router.SendTo(router.Targets(), "Deploy started", nil)[0]
+			Expect(result.Err).NotTo(HaveOccurred())
+			Expect(result.Refs).To(Equal([]t.MessageRef{{ID: "42"}}))
+			Expect(router.Update(result, "Deploy finished", nil)).To(Succeed())
+			Expect(router.Delete(result)).To(Succeed())
+			Expect(httpmock.GetCallCountInfo()["PATCH "+webhookURL+"/messages/42"]).To(Equal(1))
+		})
+		It("should update the first part of split messages using the target that sent them", func() {
+			const otherURL = "https://discord.com/api/webhooks/5678/other"
+			id := 0
+			httpmock.RegisterResponder("POST", otherURL+"?wait=true", func(req *http.Request) (*http.Response, error) {
+				id++
+				return httpmock.NewStringResponse(200, fmt.Sprintf(`{"id": "%d"}`, id)), nil
+			})
+			httpmock.RegisterResponder("PATCH", otherURL+"/messages/1", httpmock.NewStringResponder(200, `{"id": "1"}`))
+			httpmock.RegisterResponder("DELETE", otherURL+"/messages/2", httpmock.NewStringResponder(204, ""))
+			router, err := New(nil, "discord://token@1234", "discord://other@5678")
+			Expect(err).NotTo(HaveOccurred())
+
+			params := t.Params{"splitLines": "no"}
+			result := router.SendTo(router.Targets()[1:], strings.Repeat("The build failed. ", 400), &params)[0]
+			Expect(result.Err).NotTo(HaveOccurred())
+			Expect(result.Target).To(Equal("discord"))
+			Expect(result.Refs).To(Equal([]t.MessageRef{{ID: "1"}, {ID: "2"}}))
+
+			Expect(router.Update(result, "Deploy finished", nil)).To(Succeed())
+			Expect(httpmock.GetCallCountInfo()["PATCH "+otherURL+"/messages/1"]).To(Equal(1))
+			Expect(httpmock.GetCallCountInfo()["DELETE "+otherURL+"/messages/2"]).To(Equal(1))
+			Expect(httpmock.GetTotalCallCount()).To(Equal(4))
+
+			Expect(router.Update(SendResult{Target: "discord", Refs: result.Refs}, "Deploy finished", nil)).
+				To(MatchError(ErrTargetNotFound))
+		})
+		It("should return an error for services that cannot update messages", func() {
+			router, err := New(nil, "logger://")
+			Expect(err).NotTo(HaveOccurred())
+
+			result := router.SendTo(router.Targets(), "Deploy started", nil)[0]
+			Expect(result.Refs).To(BeEmpty())
+			Expect(router.Update(result, "Deploy finished", nil)).To(MatchError(t.ErrUpdateNotSupported))
+			Expect(router.Delete(SendResult{Target: "unknown"})).To(MatchError(ErrTargetNotFound))
+		})
+	})
+	When("adding services using configs", func() {
+		It("should add the initialized services as targets", func() {
+			output := &strings.Builder{}
+			router, err := New(log.New(output, "", 0))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(router.AddServiceConfig(&logger.Config{})).To(Succeed())
+			Expect(router.AddNamedServiceConfig("chat", &discord.Config{WebhookID: "id", Token: "token"}, "alerts")).To(Succeed())
+
+			targets := router.Targets()
+			Expect(targets).To(HaveLen(2))
+			Expect(targets[0].Name).To(Equal("logger"))
+			Expect(targets[1].Name).To(Equal("chat"))
+			Expect(targets[1].Scheme).To(Equal("discord"))
+			Expect(targets[1].Tags).To(Equal([]string{"alerts"}))
+
+			Expect(router.SendTo(router.Select([]string{"logger"}, nil), "message", nil)[0].Err).NotTo(HaveOccurred())
+			Expect(output.String()).To(Equal("message\n"))
+		})
+		It("should return an error for duplicate names", func() {
+			Expect(sr.AddNamedServiceConfig("ops", &logger.Config{})).To(Succeed())
+			Expect(sr.AddNamedServiceConfig("ops", &logger.Config{})).To(HaveOccurred())
+		})
+		It("should only initialize services using the allowed schemes", func() {
+			sr.AllowedSchemes = []string{"logger"}
+			Expect(sr.AddServiceConfig(&discord.Config{WebhookID: "id", Token: "token"})).To(MatchError(`service "discord" is not allowed`))
+		})
+	})
+	When("the allowed schemes are set", func() {
+		It("should only initialize services using those schemes", func() {
+			sr.AllowedSchemes = []string{"Logger"}
+			Expect(sr.AddService("logger://")).To(Succeed())
+			Expect(sr.AddService("generic+https://example.com/hook")).To(MatchError(`service "generic" is not allowed`))
+			Expect(sr.Route("generic://example.com/hook", "message")).To(HaveOccurred())
+		})
+	})
+	When("loading a profile", func() {
+		It("should add the named targets", func() {
+			file, err := os.CreateTemp("", "profile-*.yaml")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(file.Name())
+			_, err = file.WriteString("targets:\n  - name: ops\n    url: logger://\n    tags: [alerts]\n")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(file.Close()).To(Succeed())
+
+			profile, err := LoadProfile(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+
+			router, err := NewFromProfile(nil, profile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(router.Targets()).To(HaveLen(1))
+			Expect(router.Targets()[0].Name).To(Equal("ops"))
+			Expect(router.Targets()[0].Tags).To(Equal([]string{"alerts"}))
+		})
+		It("should return an error if the file does not exist", func() {
+			_, err := LoadProfile("profile_that_should_not_exist.yaml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
 	When("router has not been provided a logger", func() {
 		It("should not crash when trying to log", func() {
 			router := ServiceRouter{}
@@ -149,3 +545,59 @@ func ExampleServiceRouter_Enqueue() {
 	// hello
 	// world
 }
+
+type tracerKey struct{}
+
+// richService records the items and params sent to it
+type richService struct {
+	*logger.Service
+	items  []t.MessageItem
+	params t.Params
+}
+
+func (service *richService) SendItems(items []t.MessageItem, params t.Params) error {
+	service.items, service.params = items, params
+	return nil
+}
+
+type recordedSpan struct {
+	name   string
+	scheme string
+	target string
+	parent interface{}
+	status string
+	err    error
+}
+
+type recordingTracer struct {
+	mutex    sync.Mutex
+	recorded []recordedSpan
+}
+
+func (tracer *recordingTracer) StartSpan(ctx context.Context, name string, attributes t.SpanAttributes) (context.Context, t.Span) {
+	return context.WithValue(ctx, tracerKey{}, name), &recordingSpan{tracer: tracer, span: recordedSpan{
+		name:   name,
+		scheme: attributes.Scheme,
+		target: attributes.Target,
+		parent: ctx.Value(tracerKey{}),
+	}}
+}
+
+func (tracer *recordingTracer) spans() []recordedSpan {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	return append([]recordedSpan{}, tracer.recorded...)
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	span   recordedSpan
+}
+
+func (span *recordingSpan) End(status string, err error) {
+	span.span.status = status
+	span.span.err = err
+	span.tracer.mutex.Lock()
+	defer span.tracer.mutex.Unlock()
+	span.tracer.recorded = append(span.tracer.recorded, span.span)
+}
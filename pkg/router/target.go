@@ -0,0 +1,120 @@
+package router
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/format"
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
+)
+
+// Target is a service instance in a ServiceRouter, identified by a name and an optional set of tags
+type Target struct {
+	Name         string
+	Tags         []string
+	Scheme       string
+	Service      t.Service
+	url          string
+	interceptors []Interceptor
+	breaker      *circuitBreaker
+}
+
+func newTarget(name string, tags []string, scheme string, service t.Service) *Target {
+	return &Target{
+		Name:    name,
+		Tags:    tags,
+		Scheme:  scheme,
+		Service: service,
+		breaker: newCircuitBreaker(),
+	}
+}
+
+// HasTag returns whether the target has been tagged with tag
+func (target *Target) HasTag(tag string) bool {
+	for _, targetTag := range target.Tags {
+		if targetTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPriority returns whether the service URL of the target sets the priority, using any of the keys of the service
+// config prop that the priority param sets. Such a priority should not be replaced by the levels of the message items.
+func (target *Target) hasPriority() bool {
+	serviceURL, err := url.Parse(target.url)
+	if err != nil {
+		return false
+	}
+	resolver := format.NewPropKeyResolver(format.GetServiceConfig(target.Service))
+	keys := resolver.KeyAliases(priority.Param)
+	for key := range serviceURL.Query() {
+		if slices.Contains(keys, strings.ToLower(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SendResult is the outcome of sending a message using a single router Target
+type SendResult struct {
+	Target string
+	Scheme string
+	Err    error
+	// Circuit is the state of the target circuit breaker after the send, or empty if the breakers are disabled
+	Circuit CircuitState
+	// Refs references the messages sent by services implementing RefSender, and can be used to update or delete the
+	// messages using the router. Message items sent using a RichSender return no references.
+	Refs []t.MessageRef
+	// sender is the target that sent the messages, used to update them even if other targets share its name
+	sender *Target
+}
+
+// Errors returns the errors of the results, in the same order as the results
+func Errors(results []SendResult) []error {
+	errors := make([]error, len(results))
+	for i, result := range results {
+		errors[i] = result.Err
+	}
+	return errors
+}
+
+// Select returns the router targets matching any of the supplied names or tags.
+// If neither names nor tags are supplied, all targets are returned.
+func (router *ServiceRouter) Select(names []string, tags []string) []*Target {
+	targets := router.Targets()
+	if len(names) == 0 && len(tags) == 0 {
+		return targets
+	}
+
+	selected := make([]*Target, 0, len(targets))
+	for _, target := range targets {
+		if targetMatches(target, names, tags) {
+			selected = append(selected, target)
+		}
+	}
+	return selected
+}
+
+// Targets returns the router targets
+func (router *ServiceRouter) Targets() []*Target {
+	router.targetsMutex.RLock()
+	defer router.targetsMutex.RUnlock()
+	return router.targets
+}
+
+func targetMatches(target *Target, names []string, tags []string) bool {
+	for _, name := range names {
+		if target.Name == name {
+			return true
+		}
+	}
+	for _, tag := range tags {
+		if target.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
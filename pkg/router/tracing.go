@@ -0,0 +1,40 @@
+package router
+
+import (
+	"context"
+	"errors"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/tracing"
+)
+
+// SetTracer sets the tracer that is called around every send and attempt made by the router, and the HTTP round-trips
+// made by the services while sending. It only applies to this router, see tracing.SetTracer for other requests.
+func (router *ServiceRouter) SetTracer(tracer t.Tracer) {
+	router.tracer = tracer
+}
+
+// startSpan starts a span for the target using the router tracer, if one has been set
+func (router *ServiceRouter) startSpan(ctx context.Context, name string, target *Target) (context.Context, t.Span) {
+	if router.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return router.tracer.StartSpan(tracing.WithTracer(ctx, router.tracer), name, t.SpanAttributes{
+		Scheme: target.Scheme,
+		Target: target.Name,
+	})
+}
+
+func spanStatus(err error) string {
+	if err == nil {
+		return t.SpanStatusOK
+	}
+	if errors.Is(err, ErrTimeout) {
+		return t.SpanStatusTimeout
+	}
+	return t.SpanStatusError
+}
+
+type noopSpan struct{}
+
+func (noopSpan) End(string, error) {}
@@ -0,0 +1,303 @@
+// Package server implements an HTTP API for sending notifications using a ServiceRouter
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dockerutil/shoutrrr/pkg/metrics"
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+)
+
+// MaxRequestSize is the maximum number of bytes accepted in a request body
+const MaxRequestSize = 1 << 20
+
+// Server exposes a ServiceRouter as an HTTP API
+type Server struct {
+	router   *router.ServiceRouter
+	logger   types.StdLogger
+	auth     *authenticator
+	alerts   *alertReceiver
+	webhooks map[string]*webhook
+	metrics  *metrics.Prometheus
+	mux      *http.ServeMux
+	ready    atomic.Bool
+}
+
+// New creates a new Server that sends notifications using the targets of serviceRouter.
+// If config is nil or does not contain any clients, requests are not authenticated.
+// The metrics of serviceRouter are set to a Prometheus collector, exposed on /metrics.
+func New(serviceRouter *router.ServiceRouter, config *Config, logger types.StdLogger) (*Server, error) {
+	if logger == nil {
+		logger = util.DiscardLogger
+	}
+	if config == nil {
+		config = &Config{}
+	}
+
+	auth, err := newAuthenticator(config.Clients)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts, err := newAlertReceiver(config.Alertmanager)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alertmanager config: %w", err)
+	}
+
+	webhooks := make(map[string]*webhook, len(config.Webhooks))
+	for _, webhookConfig := range config.Webhooks {
+		hook, err := newWebhook(webhookConfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := webhooks[hook.name]; exists {
+			return nil, fmt.Errorf("a webhook named %q has already been added", hook.name)
+		}
+		if hook.verify == verifyNone && len(config.Clients) == 0 {
+			logger.Printf("Warning: requests to webhook %v will NOT be verified", hook.name)
+		}
+		webhooks[hook.name] = hook
+	}
+
+	server := &Server{
+		router:   serviceRouter,
+		logger:   logger,
+		auth:     auth,
+		alerts:   alerts,
+		webhooks: webhooks,
+		metrics:  metrics.NewPrometheus(),
+		mux:      http.NewServeMux(),
+	}
+	server.ready.Store(true)
+	serviceRouter.SetMetrics(server.metrics)
+
+	server.mux.HandleFunc("POST /notify", server.handleNotify)
+	server.mux.HandleFunc("POST /alertmanager", server.handleAlertmanager)
+	server.mux.HandleFunc("POST /webhooks/{name}", server.handleWebhook)
+	server.mux.HandleFunc("GET /healthz", server.handleHealth)
+	server.mux.HandleFunc("GET /readyz", server.handleReady)
+	server.mux.Handle("GET /metrics", server.metrics)
+
+	if !auth.enabled() {
+		logger.Println("Warning: no clients configured, API requests will NOT be authenticated")
+	}
+
+	return server, nil
+}
+
+// ServeHTTP dispatches the request to the matching API endpoint handler
+func (server *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	server.mux.ServeHTTP(res, req)
+}
+
+// SetReady sets whether the readiness endpoint reports the server as ready to accept requests.
+// It should be set to false before shutting down, to let load balancers stop sending new requests.
+func (server *Server) SetReady(ready bool) {
+	server.ready.Store(ready)
+}
+
+func (server *Server) handleNotify(res http.ResponseWriter, req *http.Request) {
+	body, err := readBody(res, req)
+	if err != nil {
+		server.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	client, authorized := server.authenticate(res, req, body)
+	if !authorized {
+		return
+	}
+
+	request := NotifyRequest{}
+	if err := decodeRequest(body, &request); err != nil {
+		server.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	if request.Message == "" && len(request.Items) == 0 {
+		server.writeError(res, http.StatusBadRequest, errors.New("either message or items must be provided"))
+		return
+	}
+
+	items, err := request.messageItems()
+	if err != nil {
+		server.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	targets := server.router.Select(request.Targets, request.Tags)
+	if client != nil {
+		explicit := len(request.Targets) > 0 || len(request.Tags) > 0
+		if targets, err = authorizeTargets(client, targets, explicit); err != nil {
+			server.reject(req, client.name, err)
+			server.writeError(res, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	if len(targets) == 0 {
+		server.writeError(res, http.StatusNotFound, errors.New("no matching targets"))
+		return
+	}
+
+	var results []router.SendResult
+	if items != nil {
+		results = server.router.SendItemsToContext(req.Context(), targets, items, request.params())
+	} else {
+		results = server.router.SendToContext(req.Context(), targets, request.Message, request.params())
+	}
+
+	server.writeResults(res, results)
+}
+
+// delivery is a notification rendered for a specific target
+type delivery struct {
+	target *router.Target
+	items  []types.MessageItem
+	params *types.Params
+	// err is set if the notification could not be rendered, and is returned as the result instead of sending
+	err error
+}
+
+// deliver sends the notifications in parallel, returning the results in the same order as the deliveries
+func (server *Server) deliver(ctx context.Context, deliveries []delivery) []router.SendResult {
+	results := make([]router.SendResult, len(deliveries))
+	wg := sync.WaitGroup{}
+	for i, d := range deliveries {
+		if d.err != nil {
+			results[i] = router.SendResult{Target: d.target.Name, Scheme: d.target.Scheme, Err: d.err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, d delivery) {
+			defer wg.Done()
+			results[i] = server.router.SendItemsToContext(ctx, []*router.Target{d.target}, d.items, d.params)[0]
+		}(i, d)
+	}
+	wg.Wait()
+	return results
+}
+
+// writeResults logs any failed results and writes them as a NotifyResponse
+func (server *Server) writeResults(res http.ResponseWriter, results []router.SendResult) {
+	status := http.StatusOK
+	for _, result := range results {
+		if result.Err != nil {
+			server.logger.Printf("Failed to send notification using %v: %v", result.Target, result.Err)
+			status = http.StatusBadGateway
+		}
+	}
+
+	server.writeJSON(res, status, newNotifyResponse(results))
+}
+
+func (server *Server) handleHealth(res http.ResponseWriter, _ *http.Request) {
+	server.writeJSON(res, http.StatusOK, statusResponse{Status: "ok"})
+}
+
+func (server *Server) handleReady(res http.ResponseWriter, _ *http.Request) {
+	if !server.ready.Load() {
+		server.writeJSON(res, http.StatusServiceUnavailable, statusResponse{Status: "unavailable"})
+		return
+	}
+	server.writeJSON(res, http.StatusOK, statusResponse{Status: "ready"})
+}
+
+// authenticate resolves the client of the request and applies its rate limit. If the request is rejected, the
+// response is written and false is returned. The returned client is nil if authentication is disabled.
+func (server *Server) authenticate(res http.ResponseWriter, req *http.Request, body []byte) (*client, bool) {
+	if !server.auth.enabled() {
+		return nil, true
+	}
+
+	client, err := server.auth.authenticate(req, body)
+	if err != nil {
+		server.reject(req, "", err)
+		res.Header().Set("WWW-Authenticate", "Bearer")
+		server.writeError(res, http.StatusUnauthorized, err)
+		return nil, false
+	}
+
+	if !server.allowRate(res, req, client.name, client.limiter) {
+		return nil, false
+	}
+
+	return client, true
+}
+
+// allowRate consumes a token from limiter, if set. If the rate limit is exceeded, the response is written and false
+// is returned.
+func (server *Server) allowRate(res http.ResponseWriter, req *http.Request, name string, limiter *rateLimiter) bool {
+	if limiter == nil {
+		return true
+	}
+	if allowed, wait := limiter.allow(); !allowed {
+		server.reject(req, name, errors.New("rate limit exceeded"))
+		res.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		server.writeError(res, http.StatusTooManyRequests, errors.New("rate limit exceeded"))
+		return false
+	}
+	return true
+}
+
+// authorizeTargets returns the targets that client may use. If the targets were explicitly requested, an error is
+// returned if any of them are not allowed, otherwise they are just filtered.
+func authorizeTargets(client *client, targets []*router.Target, explicit bool) ([]*router.Target, error) {
+	allowed := make([]*router.Target, 0, len(targets))
+	for _, target := range targets {
+		if client.allows(target) {
+			allowed = append(allowed, target)
+		} else if explicit {
+			return nil, fmt.Errorf("not authorized to use target %q", target.Name)
+		}
+	}
+	return allowed, nil
+}
+
+func (server *Server) reject(req *http.Request, clientName string, reason error) {
+	if clientName == "" {
+		clientName = "(unauthenticated)"
+	}
+	server.logger.Printf("Rejected %v %v from %v, client %v: %v", req.Method, req.URL.Path, req.RemoteAddr, clientName, reason)
+}
+
+func readBody(res http.ResponseWriter, req *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(res, req.Body, MaxRequestSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request: %w", err)
+	}
+	return body, nil
+}
+
+func decodeRequest(body []byte, request interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(request); err != nil {
+		return fmt.Errorf("invalid request payload: %w", err)
+	}
+	return nil
+}
+
+func (server *Server) writeError(res http.ResponseWriter, status int, err error) {
+	server.writeJSON(res, status, errorResponse{Error: err.Error()})
+}
+
+func (server *Server) writeJSON(res http.ResponseWriter, status int, payload interface{}) {
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	if err := json.NewEncoder(res).Encode(payload); err != nil {
+		server.logger.Printf("Failed to write response: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+const (
+	defaultAlertTitle   = `[{{ .Status | toUpper }}{{ if eq .Status "firing" }}:{{ len .Alerts.Firing }}{{ end }}] {{ .CommonLabels.alertname }}`
+	defaultAlertMessage = `{{ range .Alerts }}[{{ .Status | toUpper }}] ` +
+		`{{ with .Annotations.summary }}{{ . }}{{ else }}{{ .Labels.alertname }}{{ end }}` +
+		`{{ with .Annotations.description }}
+{{ . }}{{ end }}
+{{ end }}`
+)
+
+// alertReceiver routes and renders Alertmanager webhook payloads
+type alertReceiver struct {
+	templater *notificationTemplater
+	routes    []alertRoute
+}
+
+type alertRoute struct {
+	match   map[string]string
+	matchRE map[string]*regexp.Regexp
+	targets []string
+	tags    []string
+}
+
+// alertGroup contains the alerts that matched a route
+type alertGroup struct {
+	route   *alertRoute
+	message AlertmanagerMessage
+}
+
+func newAlertReceiver(config AlertmanagerConfig) (*alertReceiver, error) {
+	defaults := config.Templates
+	if defaults.Title == "" && defaults.TitleFile == "" {
+		defaults.Title = defaultAlertTitle
+	}
+	if defaults.Message == "" && defaults.MessageFile == "" {
+		defaults.Message = defaultAlertMessage
+	}
+
+	templater, err := newNotificationTemplater(defaults, config.Overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	receiver := &alertReceiver{
+		templater: templater,
+		routes:    make([]alertRoute, 0, len(config.Routes)),
+	}
+
+	for _, routeConfig := range config.Routes {
+		route := alertRoute{
+			match:   routeConfig.Match,
+			matchRE: make(map[string]*regexp.Regexp, len(routeConfig.MatchRE)),
+			targets: routeConfig.Targets,
+			tags:    routeConfig.Tags,
+		}
+		for label, expr := range routeConfig.MatchRE {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression for label %q: %w", label, err)
+			}
+			route.matchRE[label] = re
+		}
+		receiver.routes = append(receiver.routes, route)
+	}
+
+	return receiver, nil
+}
+
+// group splits the alerts of the message by the first route that they match. Alerts that does not match any route
+// are dropped. If no routes are configured, all alerts are returned in a single group without a route.
+func (receiver *alertReceiver) group(message AlertmanagerMessage) []alertGroup {
+	if len(receiver.routes) == 0 {
+		return []alertGroup{{message: message}}
+	}
+
+	routeAlerts := make([]Alerts, len(receiver.routes))
+	for _, alert := range message.Alerts {
+		for r := range receiver.routes {
+			if receiver.routes[r].matches(alert.Labels) {
+				routeAlerts[r] = append(routeAlerts[r], alert)
+				break
+			}
+		}
+	}
+
+	groups := make([]alertGroup, 0, len(receiver.routes))
+	for r, alerts := range routeAlerts {
+		if len(alerts) > 0 {
+			groups = append(groups, alertGroup{
+				route:   &receiver.routes[r],
+				message: message.withAlerts(alerts),
+			})
+		}
+	}
+	return groups
+}
+
+func (route *alertRoute) matches(labels map[string]string) bool {
+	for name, value := range route.match {
+		if actual, found := labelValue(labels, name); !found || actual != value {
+			return false
+		}
+	}
+	for name, re := range route.matchRE {
+		actual, _ := labelValue(labels, name)
+		if !re.MatchString(actual) {
+			return false
+		}
+	}
+	return true
+}
+
+// labelValue returns the value of the label, falling back to a case-insensitive match since config keys are
+// normalized to lower case when loaded
+func labelValue(labels map[string]string, name string) (string, bool) {
+	if value, found := labels[name]; found {
+		return value, true
+	}
+	for key, value := range labels {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// render returns the title of the message for the target, and an item for each of its alerts. The message template is
+// rendered for each alert on its own, so that the item level can match the status of the alert.
+func (receiver *alertReceiver) render(target *router.Target, message AlertmanagerMessage) (string, []types.MessageItem, error) {
+	title, err := receiver.templater.execute(target, titleTemplate, message)
+	if err != nil {
+		return "", nil, err
+	}
+
+	items := make([]types.MessageItem, 0, len(message.Alerts))
+	for _, alert := range message.Alerts {
+		text, err := receiver.templater.execute(target, messageTemplate, message.withAlerts(Alerts{alert}))
+		if err != nil {
+			return "", nil, err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			items = append(items, types.MessageItem{Text: text, Level: alertLevel(alert.Status)})
+		}
+	}
+	return strings.TrimSpace(title), items, nil
+}
+
+// alertLevel returns the MessageLevel corresponding to the alert status
+func alertLevel(status string) types.MessageLevel {
+	if status == alertStatusFiring {
+		return types.Error
+	}
+	return types.Info
+}
+
+func (server *Server) handleAlertmanager(res http.ResponseWriter, req *http.Request) {
+	body, err := readBody(res, req)
+	if err != nil {
+		server.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	client, authorized := server.authenticate(res, req, body)
+	if !authorized {
+		return
+	}
+
+	message := AlertmanagerMessage{}
+	if err := json.Unmarshal(body, &message); err != nil {
+		server.writeError(res, http.StatusBadRequest, fmt.Errorf("invalid request payload: %w", err))
+		return
+	}
+
+	if message.Version != alertmanagerVersion {
+		server.writeError(res, http.StatusBadRequest, fmt.Errorf("unsupported payload version %q", message.Version))
+		return
+	}
+
+	var deliveries []delivery
+	for _, group := range server.alerts.group(message) {
+		var names, tags []string
+		if group.route != nil {
+			names, tags = group.route.targets, group.route.tags
+		}
+
+		targets := server.router.Select(names, tags)
+		if client != nil {
+			targets, _ = authorizeTargets(client, targets, false)
+		}
+
+		for _, target := range targets {
+			title, items, err := server.alerts.render(target, group.message)
+			params := types.Params{}
+			if title != "" {
+				params.SetTitle(title)
+			}
+			deliveries = append(deliveries, delivery{
+				target: target,
+				items:  items,
+				params: &params,
+				err:    err,
+			})
+		}
+	}
+
+	if len(deliveries) == 0 {
+		server.logger.Printf("No targets matched the %d alert(s) in group %v", len(message.Alerts), message.GroupKey)
+	}
+
+	server.writeResults(res, server.deliver(req.Context(), deliveries))
+}
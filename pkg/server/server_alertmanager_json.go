@@ -0,0 +1,68 @@
+package server
+
+import "time"
+
+const (
+	alertStatusFiring   = "firing"
+	alertStatusResolved = "resolved"
+	// alertmanagerVersion is the only supported version of the Alertmanager webhook payload
+	alertmanagerVersion = "4"
+)
+
+// AlertmanagerMessage is the webhook payload sent by Prometheus Alertmanager, and the data used for the templates
+type AlertmanagerMessage struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            Alerts            `json:"alerts"`
+}
+
+// Alert is a single alert in an AlertmanagerMessage
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Alerts is a list of Alert, with helpers for use in templates
+type Alerts []Alert
+
+// Firing returns the alerts that are firing
+func (alerts Alerts) Firing() Alerts {
+	return alerts.withStatus(alertStatusFiring)
+}
+
+// Resolved returns the alerts that have been resolved
+func (alerts Alerts) Resolved() Alerts {
+	return alerts.withStatus(alertStatusResolved)
+}
+
+func (alerts Alerts) withStatus(status string) Alerts {
+	filtered := make(Alerts, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.Status == status {
+			filtered = append(filtered, alert)
+		}
+	}
+	return filtered
+}
+
+// withAlerts returns a copy of the message containing only the specified alerts, with the status updated to match
+func (message AlertmanagerMessage) withAlerts(alerts Alerts) AlertmanagerMessage {
+	message.Alerts = alerts
+	message.Status = alertStatusResolved
+	if len(alerts.Firing()) > 0 {
+		message.Status = alertStatusFiring
+	}
+	return message
+}
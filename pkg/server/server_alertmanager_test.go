@@ -0,0 +1,165 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"DiskFull\"}",
+  "status": "firing",
+  "receiver": "shoutrrr",
+  "groupLabels": {"alertname": "DiskFull"},
+  "commonLabels": {"alertname": "DiskFull"},
+  "commonAnnotations": {},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "DiskFull", "severity": "critical", "instance": "db-1"},
+      "annotations": {"summary": "Disk on db-1 is full"},
+      "startsAt": "2024-01-01T00:00:00Z"
+    },
+    {
+      "status": "resolved",
+      "labels": {"alertname": "DiskFull", "severity": "warning", "instance": "web-1"},
+      "annotations": {"description": "Disk usage back to normal"},
+      "startsAt": "2024-01-01T00:00:00Z",
+      "endsAt": "2024-01-01T01:00:00Z"
+    }
+  ]
+}`
+
+var _ = Describe("the alertmanager receiver", func() {
+	var receiver *alertReceiver
+	var message AlertmanagerMessage
+	target := &router.Target{Name: "ops", Scheme: "slack"}
+
+	BeforeEach(func() {
+		message = AlertmanagerMessage{
+			Version:      "4",
+			Status:       alertStatusFiring,
+			CommonLabels: map[string]string{"alertname": "DiskFull"},
+			Alerts: Alerts{
+				{Status: alertStatusFiring, Labels: map[string]string{"alertname": "DiskFull", "severity": "critical"},
+					Annotations: map[string]string{"summary": "Disk is full"}, StartsAt: time.Now()},
+				{Status: alertStatusResolved, Labels: map[string]string{"alertname": "DiskFull", "severity": "warning"}},
+			},
+		}
+	})
+
+	When("using the default templates", func() {
+		It("should render the title and message", func() {
+			var err error
+			receiver, err = newAlertReceiver(AlertmanagerConfig{})
+			Expect(err).NotTo(HaveOccurred())
+
+			title, text, err := receiver.templater.render(target, message)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(title).To(Equal("[FIRING:1] DiskFull"))
+			Expect(text).To(Equal("[FIRING] Disk is full\n[RESOLVED] DiskFull\n"))
+		})
+	})
+
+	When("using template overrides", func() {
+		BeforeEach(func() {
+			var err error
+			receiver, err = newAlertReceiver(AlertmanagerConfig{
+				Templates: TemplateConfig{Message: "default: {{ len .Alerts }}"},
+				Overrides: map[string]TemplateConfig{
+					"slack": {Message: "slack: {{ len .Alerts.Firing }}"},
+					"dev":   {Title: "dev title"},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should use the template for the service scheme", func() {
+			_, text, _ := receiver.templater.render(target, message)
+			Expect(text).To(Equal("slack: 1"))
+		})
+		It("should fall back to the default templates", func() {
+			title, text, _ := receiver.templater.render(&router.Target{Name: "dev", Scheme: "discord"}, message)
+			Expect(title).To(Equal("dev title"))
+			Expect(text).To(Equal("default: 2"))
+		})
+	})
+
+	When("an invalid template is configured", func() {
+		It("should return an error", func() {
+			_, err := newAlertReceiver(AlertmanagerConfig{Templates: TemplateConfig{Title: "{{ .Status "}})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("routes are configured", func() {
+		BeforeEach(func() {
+			var err error
+			receiver, err = newAlertReceiver(AlertmanagerConfig{
+				Routes: []AlertRoute{
+					{Match: map[string]string{"severity": "critical"}, Targets: []string{"ops"}},
+					{MatchRE: map[string]string{"Severity": "warn.*"}, Tags: []string{"dev"}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should group the alerts by the first matching route", func() {
+			groups := receiver.group(message)
+			Expect(groups).To(HaveLen(2))
+			Expect(groups[0].route.targets).To(Equal([]string{"ops"}))
+			Expect(groups[0].message.Alerts).To(HaveLen(1))
+			Expect(groups[0].message.Status).To(Equal(alertStatusFiring))
+			Expect(groups[1].route.tags).To(Equal([]string{"dev"}))
+			Expect(groups[1].message.Status).To(Equal(alertStatusResolved))
+		})
+		It("should drop alerts that does not match any route", func() {
+			message.Alerts[0].Labels["severity"] = "info"
+			Expect(receiver.group(message)).To(HaveLen(1))
+		})
+	})
+
+	It("should render an item for each alert with the level of its own status", func() {
+		receiver, err := newAlertReceiver(AlertmanagerConfig{})
+		Expect(err).NotTo(HaveOccurred())
+		title, items, err := receiver.render(target, message)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(title).To(Equal("[FIRING:1] DiskFull"))
+		Expect(items).To(Equal([]types.MessageItem{
+			{Text: "[FIRING] Disk is full", Level: types.Error},
+			{Text: "[RESOLVED] DiskFull", Level: types.Info},
+		}))
+	})
+
+	It("should map the alert status to a message level", func() {
+		Expect(alertLevel(alertStatusFiring)).To(Equal(types.Error))
+		Expect(alertLevel(alertStatusResolved)).To(Equal(types.Info))
+	})
+
+	Describe("the alertmanager endpoint", func() {
+		BeforeEach(func() {
+			setupServer(&Config{
+				Alertmanager: AlertmanagerConfig{
+					Templates: TemplateConfig{Message: "{{ range .Alerts }}{{ .Labels.instance }} {{ end }}"},
+					Routes: []AlertRoute{
+						{Match: map[string]string{"severity": "critical"}, Targets: []string{"ops"}},
+					},
+				},
+			})
+		})
+		It("should send the routed alerts to the matching targets", func() {
+			res := sendRequest("/alertmanager", alertmanagerPayload, nil)
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(logBuffer.String()).To(Equal("db-1\n"))
+		})
+		It("should reject unsupported payload versions", func() {
+			res := sendRequest("/alertmanager", `{"version": "3", "alerts": []}`, nil)
+			Expect(res.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+)
+
+const (
+	// ClientHeader is the request header identifying the client of an HMAC signed request
+	ClientHeader = "X-Shoutrrr-Client"
+	// TimestampHeader is the request header containing the unix timestamp of an HMAC signed request
+	TimestampHeader = "X-Shoutrrr-Timestamp"
+	// SignatureHeader is the request header containing the "sha256=<hex>" HMAC signature of a request
+	SignatureHeader = "X-Shoutrrr-Signature"
+	// MaxSignatureAge is the max difference between the timestamp of a signed request and the server time
+	MaxSignatureAge = 5 * time.Minute
+
+	signaturePrefix = "sha256="
+	allTargets      = "*"
+)
+
+var errUnauthenticated = errors.New("missing or invalid credentials")
+
+// client is an authenticated API client and the targets it is authorized to use
+type client struct {
+	name    string
+	token   []byte
+	secret  []byte
+	targets []string
+	tags    []string
+	limiter *rateLimiter
+}
+
+func newClient(config ClientConfig) (*client, error) {
+	if config.Name == "" {
+		return nil, errors.New("client name is missing")
+	}
+
+	token, err := valueOrFile(config.Token, config.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token for client %q: %w", config.Name, err)
+	}
+
+	secret, err := valueOrFile(config.Secret, config.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret for client %q: %w", config.Name, err)
+	}
+
+	if token == "" && secret == "" {
+		return nil, fmt.Errorf("client %q has neither a token nor a secret", config.Name)
+	}
+
+	c := &client{
+		name:    config.Name,
+		token:   []byte(token),
+		secret:  []byte(secret),
+		targets: config.Targets,
+		tags:    config.Tags,
+	}
+
+	if config.RateLimit > 0 {
+		c.limiter = newRateLimiter(config.RateLimit, config.Burst)
+	}
+
+	return c, nil
+}
+
+// valueOrFile returns value if it's set, otherwise the trimmed contents of the file at path (if set)
+func valueOrFile(value string, path string) (string, error) {
+	if value != "" || path == "" {
+		return value, nil
+	}
+
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+// allows returns whether the client is authorized to send notifications using target
+func (c *client) allows(target *router.Target) bool {
+	for _, name := range c.targets {
+		if name == allTargets || name == target.Name {
+			return true
+		}
+	}
+	for _, tag := range c.tags {
+		if target.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticator resolves the client of a request from either its bearer token or HMAC signature
+type authenticator struct {
+	clients []*client
+	now     func() time.Time
+
+	// used holds the accepted signatures until their timestamp is out of range, to reject replayed requests
+	mutex sync.Mutex
+	used  map[string]time.Time
+}
+
+func newAuthenticator(configs []ClientConfig) (*authenticator, error) {
+	auth := &authenticator{
+		clients: make([]*client, 0, len(configs)),
+		now:     time.Now,
+		used:    map[string]time.Time{},
+	}
+
+	for _, config := range configs {
+		c, err := newClient(config)
+		if err != nil {
+			return nil, err
+		}
+		for _, existing := range auth.clients {
+			if existing.name == c.name {
+				return nil, fmt.Errorf("a client named %q has already been added", c.name)
+			}
+		}
+		auth.clients = append(auth.clients, c)
+	}
+
+	return auth, nil
+}
+
+// enabled returns whether any clients have been configured, if not, all requests are allowed
+func (auth *authenticator) enabled() bool {
+	return len(auth.clients) > 0
+}
+
+func (auth *authenticator) authenticate(req *http.Request, body []byte) (*client, error) {
+	if authorization := req.Header.Get("Authorization"); authorization != "" {
+		token, found := strings.CutPrefix(authorization, "Bearer ")
+		if !found {
+			return nil, errUnauthenticated
+		}
+		return auth.authenticateToken([]byte(token))
+	}
+
+	if signature := req.Header.Get(SignatureHeader); signature != "" {
+		return auth.authenticateSignature(req.Header.Get(ClientHeader), req.Header.Get(TimestampHeader), signature, body)
+	}
+
+	return nil, errUnauthenticated
+}
+
+func (auth *authenticator) authenticateToken(token []byte) (*client, error) {
+	for _, c := range auth.clients {
+		if len(c.token) > 0 && subtle.ConstantTimeCompare(c.token, token) == 1 {
+			return c, nil
+		}
+	}
+	return nil, errUnauthenticated
+}
+
+func (auth *authenticator) authenticateSignature(name string, timestamp string, signature string, body []byte) (*client, error) {
+	var c *client
+	for _, candidate := range auth.clients {
+		if candidate.name == name && len(candidate.secret) > 0 {
+			c = candidate
+			break
+		}
+	}
+	if c == nil {
+		return nil, errUnauthenticated
+	}
+
+	unixTime, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid signature timestamp")
+	}
+
+	now := auth.now()
+	signedAt := time.Unix(unixTime, 0)
+	age := now.Sub(signedAt)
+	if age > MaxSignatureAge || age < -MaxSignatureAge {
+		return nil, errors.New("signature timestamp is out of range")
+	}
+
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !hmac.Equal(expected, Sign(c.secret, timestamp, body)) {
+		return nil, errUnauthenticated
+	}
+
+	if !auth.useSignature(c.name+":"+hex.EncodeToString(expected), signedAt.Add(MaxSignatureAge), now) {
+		return nil, errors.New("signature has already been used")
+	}
+
+	return c, nil
+}
+
+// useSignature records the signature as used until expires, returning false if it has already been used. Signatures
+// whose timestamp is out of range are removed, since those requests are rejected anyway.
+func (auth *authenticator) useSignature(key string, expires time.Time, now time.Time) bool {
+	auth.mutex.Lock()
+	defer auth.mutex.Unlock()
+
+	for usedKey, usedExpires := range auth.used {
+		if now.After(usedExpires) {
+			delete(auth.used, usedKey)
+		}
+	}
+
+	if _, found := auth.used[key]; found {
+		return false
+	}
+	auth.used[key] = expires
+	return true
+}
+
+// Sign returns the HMAC-SHA256 signature of a request, calculated over the timestamp and body separated by a "."
+func Sign(secret []byte, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
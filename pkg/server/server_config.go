@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"github.com/dockerutil/shoutrrr/pkg/util/netpolicy"
+)
+
+// Config contains the server specific settings, usually loaded from the same file as the router profile
+type Config struct {
+	Clients      []ClientConfig     `mapstructure:"clients"`
+	Alertmanager AlertmanagerConfig `mapstructure:"alertmanager"`
+	Webhooks     []WebhookConfig    `mapstructure:"webhooks"`
+	// Destinations is the policy restricting the hosts notifications can be sent to. If nil, only private, loopback
+	// and link-local addresses are denied.
+	Destinations *netpolicy.Policy `mapstructure:"destinations"`
+	// MaxConnsPerHost limits the number of connections per host made by the services, or 0 for no limit
+	MaxConnsPerHost int `mapstructure:"maxconnsperhost"`
+}
+
+// ClientConfig is an API client credential and the targets it is authorized to use
+type ClientConfig struct {
+	Name string `mapstructure:"name"`
+	// Token is the bearer token used to authenticate the client
+	Token     string `mapstructure:"token"`
+	TokenFile string `mapstructure:"tokenfile"`
+	// Secret is the key used to verify HMAC signed requests from the client
+	Secret     string `mapstructure:"secret"`
+	SecretFile string `mapstructure:"secretfile"`
+	// Targets are the names of the targets the client may use, or "*" for all targets
+	Targets []string `mapstructure:"targets"`
+	// Tags are the tags of the targets the client may use
+	Tags []string `mapstructure:"tags"`
+	// RateLimit is the max number of requests per minute, or 0 for no limit
+	RateLimit int `mapstructure:"ratelimit"`
+	// Burst is the number of requests that can be made in excess of the rate limit, defaults to RateLimit
+	Burst int `mapstructure:"burst"`
+}
+
+// AlertmanagerConfig contains the templates and routes used by the Alertmanager webhook receiver
+type AlertmanagerConfig struct {
+	// Templates are the default templates, used unless overridden
+	Templates TemplateConfig `mapstructure:"templates"`
+	// Overrides are templates used for specific targets, keyed by either target name or service scheme
+	Overrides map[string]TemplateConfig `mapstructure:"overrides"`
+	// Routes are used to select targets using the alert labels. The first matching route is used for each alert.
+	// If no routes are configured, all targets are used.
+	Routes []AlertRoute `mapstructure:"routes"`
+}
+
+// TemplateConfig contains text/template templates used for rendering notifications, either set inline or loaded
+// from a file. Empty templates are not used.
+type TemplateConfig struct {
+	Title       string `mapstructure:"title"`
+	TitleFile   string `mapstructure:"titlefile"`
+	Message     string `mapstructure:"message"`
+	MessageFile string `mapstructure:"messagefile"`
+}
+
+// AlertRoute selects the targets for alerts with matching labels
+type AlertRoute struct {
+	// Match contains labels that must have the exact value
+	Match map[string]string `mapstructure:"match"`
+	// MatchRE contains labels that must match the regular expression (anchored at both ends)
+	MatchRE map[string]string `mapstructure:"matchre"`
+	Targets []string          `mapstructure:"targets"`
+	Tags    []string          `mapstructure:"tags"`
+}
+
+// WebhookConfig is an inbound webhook endpoint that maps the request payload to a notification.
+// The Title, Message, Level and Params values are either JSONPath expressions (e.g. "$.repository.name") or
+// text/template templates executed with the decoded JSON payload as data.
+type WebhookConfig struct {
+	// Name is used as the endpoint path, i.e. /webhooks/<name>
+	Name string `mapstructure:"name"`
+	// Preset provides defaults for the mapping and verification, one of "json", "github", "gitea" or "grafana"
+	Preset string `mapstructure:"preset"`
+	// Verify is the method used to verify requests, one of "none", "github", "gitea" or "header"
+	Verify     string `mapstructure:"verify"`
+	Secret     string `mapstructure:"secret"`
+	SecretFile string `mapstructure:"secretfile"`
+	// Header is the request header containing the shared secret when using the "header" verify method
+	Header  string            `mapstructure:"header"`
+	Title   string            `mapstructure:"title"`
+	Message string            `mapstructure:"message"`
+	Level   string            `mapstructure:"level"`
+	Params  map[string]string `mapstructure:"params"`
+	// Targets are the names of the targets used for the notifications, or "*" for all targets.
+	// At least one target or tag is required.
+	Targets []string `mapstructure:"targets"`
+	Tags    []string `mapstructure:"tags"`
+	// RateLimit is the max number of requests per minute to the webhook, or 0 for no limit
+	RateLimit int `mapstructure:"ratelimit"`
+	// Burst is the number of requests that can be made in excess of the rate limit, defaults to RateLimit
+	Burst int `mapstructure:"burst"`
+}
+
+// LoadConfig reads the server Config from the config file at path.
+// Any format supported by viper (YAML, JSON, TOML etc.) can be used, and is determined by the file extension.
+func LoadConfig(path string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read server config: %w", err)
+	}
+
+	config := &Config{}
+	if err := v.Unmarshal(config); err != nil {
+		return nil, fmt.Errorf("failed to parse server config: %w", err)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// NotifyRequest is the payload accepted by the notify endpoint
+type NotifyRequest struct {
+	Message string            `json:"message"`
+	Title   string            `json:"title,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+	Level   string            `json:"level,omitempty"`
+	Items   []NotifyItem      `json:"items,omitempty"`
+	Targets []string          `json:"targets,omitempty"`
+	Tags    []string          `json:"tags,omitempty"`
+}
+
+// NotifyItem is a single message item in a NotifyRequest
+type NotifyItem struct {
+	Text      string         `json:"text"`
+	Level     string         `json:"level,omitempty"`
+	Timestamp time.Time      `json:"timestamp,omitempty"`
+	Fields    []NotifyField  `json:"fields,omitempty"`
+	Actions   []NotifyAction `json:"actions,omitempty"`
+}
+
+// NotifyField is a key/value pair of extra data in a NotifyItem
+type NotifyField struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// NotifyAction is a button or link in a NotifyItem, sending a HTTP request instead of opening the URL if Method is set
+type NotifyAction struct {
+	Label   string            `json:"label"`
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// NotifyResponse is the payload returned by the notify endpoint
+type NotifyResponse struct {
+	Results []NotifyResult `json:"results"`
+}
+
+// NotifyResult is the outcome of sending the notification using a single target
+type NotifyResult struct {
+	Target  string `json:"target"`
+	Service string `json:"service"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	// Circuit is the state of the target circuit breaker, if enabled
+	Circuit string `json:"circuit,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type statusResponse struct {
+	Status string `json:"status"`
+}
+
+func (req *NotifyRequest) params() *types.Params {
+	params := make(types.Params, len(req.Params)+1)
+	for key, value := range req.Params {
+		params[key] = value
+	}
+	if req.Title != "" {
+		params.SetTitle(req.Title)
+	}
+	return &params
+}
+
+// messageItems returns the request items as MessageItems, or nil if the request is a plain message
+func (req *NotifyRequest) messageItems() ([]types.MessageItem, error) {
+	defaultLevel := types.Unknown
+	if req.Level != "" {
+		level, valid := types.ParseMessageLevel(req.Level)
+		if !valid {
+			return nil, fmt.Errorf("invalid level %q", req.Level)
+		}
+		defaultLevel = level
+	}
+
+	if len(req.Items) == 0 {
+		if req.Level == "" {
+			return nil, nil
+		}
+		return []types.MessageItem{{Text: req.Message, Level: defaultLevel}}, nil
+	}
+
+	items := make([]types.MessageItem, 0, len(req.Items))
+	for _, reqItem := range req.Items {
+		item := types.MessageItem{
+			Text:      reqItem.Text,
+			Timestamp: reqItem.Timestamp,
+			Level:     defaultLevel,
+		}
+		if reqItem.Level != "" {
+			level, valid := types.ParseMessageLevel(reqItem.Level)
+			if !valid {
+				return nil, fmt.Errorf("invalid level %q", reqItem.Level)
+			}
+			item.Level = level
+		}
+		for _, field := range reqItem.Fields {
+			item.WithField(field.Key, field.Value)
+		}
+		for _, action := range reqItem.Actions {
+			item.WithAction(types.Action(action))
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func newNotifyResponse(results []router.SendResult) NotifyResponse {
+	response := NotifyResponse{
+		Results: make([]NotifyResult, 0, len(results)),
+	}
+	for _, result := range results {
+		notifyResult := NotifyResult{
+			Target:  result.Target,
+			Service: result.Scheme,
+			Success: result.Err == nil,
+			Circuit: string(result.Circuit),
+		}
+		if result.Err != nil {
+			notifyResult.Error = result.Err.Error()
+		}
+		response.Results = append(response.Results, notifyResult)
+	}
+	return response
+}
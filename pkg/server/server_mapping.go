@@ -0,0 +1,139 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/services/standard"
+)
+
+const (
+	jsonPathRoot = "$"
+	// noValue is what text/template renders for missing keys in maps with interface values
+	noValue = "<no value>"
+)
+
+// payloadMapper extracts values from decoded JSON payloads using either JSONPath expressions or templates
+type payloadMapper struct {
+	standard.Templater
+	paths map[string][]string
+}
+
+func newPayloadMapper() *payloadMapper {
+	mapper := &payloadMapper{
+		paths: make(map[string][]string),
+	}
+	mapper.SetTemplateFuncs(templateFuncs)
+	return mapper
+}
+
+// setMapping sets the mapping identified by id, treating expr as a JSONPath expression if it starts with "$",
+// otherwise as a template
+func (mapper *payloadMapper) setMapping(id string, expr string) error {
+	if expr == "" {
+		return nil
+	}
+
+	if expr == jsonPathRoot || strings.HasPrefix(expr, jsonPathRoot+".") || strings.HasPrefix(expr, jsonPathRoot+"[") {
+		path, err := parseJSONPath(expr)
+		if err != nil {
+			return fmt.Errorf("invalid path for %q: %w", id, err)
+		}
+		mapper.paths[id] = path
+		return nil
+	}
+
+	if err := mapper.SetTemplateString(id, expr); err != nil {
+		return fmt.Errorf("invalid template for %q: %w", id, err)
+	}
+	return nil
+}
+
+// value returns the mapped value identified by id, or an empty string if no such mapping exists
+func (mapper *payloadMapper) value(id string, payload interface{}) (string, error) {
+	if path, found := mapper.paths[id]; found {
+		return jsonValueString(resolveJSONPath(payload, path))
+	}
+
+	tpl, found := mapper.GetTemplate(id)
+	if !found {
+		return "", nil
+	}
+
+	sb := strings.Builder{}
+	if err := tpl.Execute(&sb, payload); err != nil {
+		return "", fmt.Errorf("failed to render %v template: %w", id, err)
+	}
+	return strings.ReplaceAll(sb.String(), noValue, ""), nil
+}
+
+// parseJSONPath parses a subset of JSONPath, supporting child (".key" and "['key']") and index ("[0]") segments
+func parseJSONPath(expr string) ([]string, error) {
+	rest := strings.TrimPrefix(expr, jsonPathRoot)
+	path := make([]string, 0, strings.Count(rest, ".")+strings.Count(rest, "["))
+
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in %q", expr)
+			}
+			path = append(path, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated bracket in %q", expr)
+			}
+			path = append(path, strings.Trim(rest[1:end], `'"`))
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q", rest[0], expr)
+		}
+	}
+
+	return path, nil
+}
+
+// resolveJSONPath returns the value at the path of a decoded JSON value, or nil if it does not exist
+func resolveJSONPath(value interface{}, path []string) interface{} {
+	for _, segment := range path {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil
+			}
+			value = node[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// jsonValueString returns scalar values as plain strings, and objects and arrays as JSON
+func jsonValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		bytes, err := json.Marshal(v)
+		return string(bytes), err
+	}
+}
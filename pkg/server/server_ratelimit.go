@@ -0,0 +1,50 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that is refilled continuously at a fixed rate
+type rateLimiter struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRateLimiter(perMinute int, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = perMinute
+	}
+	return &rateLimiter{
+		rate:   float64(perMinute) / time.Minute.Seconds(),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+	}
+}
+
+// allow consumes a token if one is available, otherwise it returns the time until the next token is available
+func (limiter *rateLimiter) allow() (bool, time.Duration) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := limiter.now()
+	if !limiter.last.IsZero() {
+		elapsed := now.Sub(limiter.last).Seconds()
+		limiter.tokens = math.Min(limiter.burst, limiter.tokens+elapsed*limiter.rate)
+	}
+	limiter.last = now
+
+	if limiter.tokens >= 1 {
+		limiter.tokens--
+		return true, 0
+	}
+
+	wait := (1 - limiter.tokens) / limiter.rate
+	return false, time.Duration(wait * float64(time.Second))
+}
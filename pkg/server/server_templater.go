@@ -0,0 +1,104 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/services/standard"
+)
+
+const (
+	titleTemplate   = "title"
+	messageTemplate = "message"
+)
+
+// templateFuncs are the functions available to notification templates, in addition to the text/template builtins
+var templateFuncs = template.FuncMap{
+	"toUpper":   strings.ToUpper,
+	"toLower":   strings.ToLower,
+	"trimSpace": strings.TrimSpace,
+	"join": func(sep string, values []string) string {
+		return strings.Join(values, sep)
+	},
+}
+
+// notificationTemplater renders the title and message for targets, using templates that can be overridden per
+// target name or service scheme
+type notificationTemplater struct {
+	standard.Templater
+}
+
+func newNotificationTemplater(defaults TemplateConfig, overrides map[string]TemplateConfig) (*notificationTemplater, error) {
+	templater := &notificationTemplater{}
+	templater.SetTemplateFuncs(templateFuncs)
+
+	if err := templater.setTemplates("", defaults); err != nil {
+		return nil, err
+	}
+
+	for key, config := range overrides {
+		if err := templater.setTemplates(key, config); err != nil {
+			return nil, err
+		}
+	}
+
+	return templater, nil
+}
+
+func (templater *notificationTemplater) setTemplates(key string, config TemplateConfig) error {
+	if err := templater.setTemplate(templateID(key, titleTemplate), config.Title, config.TitleFile); err != nil {
+		return err
+	}
+	return templater.setTemplate(templateID(key, messageTemplate), config.Message, config.MessageFile)
+}
+
+func (templater *notificationTemplater) setTemplate(id string, body string, file string) error {
+	var err error
+	if body != "" {
+		err = templater.SetTemplateString(id, body)
+	} else if file != "" {
+		err = templater.SetTemplateFile(id, file)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to load template %q: %w", id, err)
+	}
+	return nil
+}
+
+// render executes the title and message templates for the target. If a template is missing, an empty string is
+// returned in its place.
+func (templater *notificationTemplater) render(target *router.Target, data interface{}) (title string, message string, err error) {
+	if title, err = templater.execute(target, titleTemplate, data); err != nil {
+		return "", "", err
+	}
+	if message, err = templater.execute(target, messageTemplate, data); err != nil {
+		return "", "", err
+	}
+	return title, message, nil
+}
+
+func (templater *notificationTemplater) execute(target *router.Target, name string, data interface{}) (string, error) {
+	for _, key := range []string{target.Name, target.Scheme, ""} {
+		tpl, found := templater.GetTemplate(templateID(key, name))
+		if !found {
+			continue
+		}
+
+		sb := strings.Builder{}
+		if err := tpl.Execute(&sb, data); err != nil {
+			return "", fmt.Errorf("failed to render %v template: %w", name, err)
+		}
+		return sb.String(), nil
+	}
+	return "", nil
+}
+
+func templateID(key string, name string) string {
+	if key == "" {
+		return name
+	}
+	return key + "." + name
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/internal/testutils"
+	"github.com/dockerutil/shoutrrr/pkg/router"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestServer(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr Server Suite")
+}
+
+var (
+	logBuffer *strings.Builder
+	api       *Server
+)
+
+var _ = Describe("the server", func() {
+	BeforeEach(func() {
+		setupServer(nil)
+	})
+
+	Describe("the notify endpoint", func() {
+		When("sending to a named target", func() {
+			It("should return the result for the target", func() {
+				res, response := postNotify(`{"message": "hello", "targets": ["ops"]}`)
+				Expect(res.Code).To(Equal(http.StatusOK))
+				Expect(response.Results).To(HaveLen(1))
+				Expect(response.Results[0]).To(Equal(NotifyResult{
+					Target:  "ops",
+					Service: "logger",
+					Success: true,
+				}))
+				Expect(logBuffer.String()).To(Equal("hello\n"))
+			})
+		})
+		When("sending to a tag", func() {
+			It("should send to all targets with the tag and report failures", func() {
+				res, response := postNotify(`{"message": "hello", "tags": ["builds"]}`)
+				Expect(res.Code).To(Equal(http.StatusBadGateway))
+				Expect(response.Results).To(HaveLen(2))
+				Expect(response.Results[0].Target).To(Equal("dev"))
+				Expect(response.Results[0].Success).To(BeTrue())
+				Expect(response.Results[1].Target).To(Equal("broken"))
+				Expect(response.Results[1].Success).To(BeFalse())
+				Expect(response.Results[1].Error).NotTo(BeEmpty())
+			})
+		})
+		When("sending items with levels", func() {
+			It("should send the items using the target", func() {
+				res, _ := postNotify(`{"items": [{"text": "one"}, {"text": "two", "level": "error"}], "targets": ["ops"]}`)
+				Expect(res.Code).To(Equal(http.StatusOK))
+				Expect(logBuffer.String()).To(Equal("one\ntwo\n"))
+			})
+		})
+		When("sending items with actions", func() {
+			It("should append the actions as links for services without buttons", func() {
+				res, _ := postNotify(`{"items": [{"text": "failed", "actions": [{"label": "Open runbook", "url": "https://example.com/runbook"}]}], "targets": ["ops"]}`)
+				Expect(res.Code).To(Equal(http.StatusOK))
+				Expect(logBuffer.String()).To(Equal("failed\nOpen runbook: https://example.com/runbook\n"))
+			})
+		})
+		When("no targets match", func() {
+			It("should return not found", func() {
+				res, _ := postNotify(`{"message": "hello", "targets": ["missing"]}`)
+				Expect(res.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+		When("the request is invalid", func() {
+			It("should return bad request for an empty message", func() {
+				res, _ := postNotify(`{"targets": ["ops"]}`)
+				Expect(res.Code).To(Equal(http.StatusBadRequest))
+			})
+			It("should return bad request for an unknown level", func() {
+				res, _ := postNotify(`{"message": "hello", "level": "panic"}`)
+				Expect(res.Code).To(Equal(http.StatusBadRequest))
+			})
+			It("should return bad request for malformed JSON", func() {
+				res, _ := postNotify(`{"message": `)
+				Expect(res.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("authentication", func() {
+		BeforeEach(func() {
+			var err error
+			api, err = New(api.router, &Config{
+				Clients: []ClientConfig{
+					{Name: "ci", Token: "ci-token", Tags: []string{"builds"}},
+					{Name: "signer", Secret: "signer-secret", Targets: []string{"*"}},
+					{Name: "limited", Token: "limited-token", Targets: []string{"ops"}, RateLimit: 1},
+				},
+			}, testutils.TestLogger())
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should reject requests without credentials", func() {
+			res := sendNotify(`{"message": "hello"}`, nil)
+			Expect(res.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("should reject requests with an unknown token", func() {
+			res := sendNotify(`{"message": "hello"}`, bearer("bad-token"))
+			Expect(res.Code).To(Equal(http.StatusUnauthorized))
+		})
+		It("should only send to the targets the client is allowed to use", func() {
+			res := sendNotify(`{"message": "hello"}`, bearer("limited-token"))
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(logBuffer.String()).To(Equal("hello\n"))
+		})
+		It("should return forbidden when requesting a target the client is not allowed to use", func() {
+			res := sendNotify(`{"message": "hello", "targets": ["ops"]}`, bearer("ci-token"))
+			Expect(res.Code).To(Equal(http.StatusForbidden))
+			Expect(logBuffer.String()).To(BeEmpty())
+		})
+		It("should apply the rate limit of the client", func() {
+			Expect(sendNotify(`{"message": "hello"}`, bearer("limited-token")).Code).To(Equal(http.StatusOK))
+			res := sendNotify(`{"message": "hello"}`, bearer("limited-token"))
+			Expect(res.Code).To(Equal(http.StatusTooManyRequests))
+			Expect(res.Header().Get("Retry-After")).To(Equal("60"))
+		})
+		When("using HMAC signed requests", func() {
+			body := `{"message": "hello", "targets": ["ops"]}`
+			It("should accept a valid signature", func() {
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+				res := sendNotify(body, signed("signer", "signer-secret", timestamp, body))
+				Expect(res.Code).To(Equal(http.StatusOK))
+			})
+			It("should reject a signature made with the wrong secret", func() {
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+				res := sendNotify(body, signed("signer", "wrong-secret", timestamp, body))
+				Expect(res.Code).To(Equal(http.StatusUnauthorized))
+			})
+			It("should reject a replayed signature", func() {
+				timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+				headers := signed("signer", "signer-secret", timestamp, body)
+				Expect(sendNotify(body, headers).Code).To(Equal(http.StatusOK))
+				res := sendNotify(body, headers)
+				Expect(res.Code).To(Equal(http.StatusUnauthorized))
+				Expect(logBuffer.String()).To(Equal("hello\n"))
+			})
+			It("should reject an expired signature", func() {
+				timestamp := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+				res := sendNotify(body, signed("signer", "signer-secret", timestamp, body))
+				Expect(res.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+		When("loading the token from a file", func() {
+			It("should use the trimmed file contents", func() {
+				file, err := os.CreateTemp("", "token")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Remove(file.Name())
+				_, _ = file.WriteString("file-token\n")
+				Expect(file.Close()).To(Succeed())
+
+				api, err = New(api.router, &Config{
+					Clients: []ClientConfig{{Name: "file", TokenFile: file.Name(), Targets: []string{"ops"}}},
+				}, testutils.TestLogger())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(sendNotify(`{"message": "hello"}`, bearer("file-token")).Code).To(Equal(http.StatusOK))
+			})
+		})
+		When("a client has no credentials", func() {
+			It("should return an error", func() {
+				_, err := New(api.router, &Config{Clients: []ClientConfig{{Name: "none"}}}, nil)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("the health endpoints", func() {
+		It("should report the server as healthy", func() {
+			res := httptest.NewRecorder()
+			api.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			Expect(res.Code).To(Equal(http.StatusOK))
+		})
+		It("should report the server as ready until set otherwise", func() {
+			res := httptest.NewRecorder()
+			api.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			Expect(res.Code).To(Equal(http.StatusOK))
+
+			api.SetReady(false)
+			res = httptest.NewRecorder()
+			api.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			Expect(res.Code).To(Equal(http.StatusServiceUnavailable))
+		})
+	})
+
+	Describe("the metrics endpoint", func() {
+		It("should expose the router send metrics", func() {
+			postNotify(`{"message": "hello", "targets": ["ops"]}`)
+			res := httptest.NewRecorder()
+			api.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(res.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+			Expect(res.Body.String()).To(ContainSubstring(`shoutrrr_sends_total{scheme="logger",target="ops"} 1` + "\n"))
+		})
+	})
+})
+
+// setupServer creates the api server using config, with the targets ops, dev and broken logging to logBuffer
+func setupServer(config *Config) {
+	logBuffer = &strings.Builder{}
+	sr := newTestRouter(logBuffer)
+	Expect(sr.AddNamedService("ops", "logger://", "alerts")).To(Succeed())
+	Expect(sr.AddNamedService("dev", "logger://", "builds")).To(Succeed())
+	Expect(sr.AddNamedService("broken", "generic://127.0.0.1:0/hook?disabletls=yes", "builds")).To(Succeed())
+	var err error
+	api, err = New(sr, config, testutils.TestLogger())
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func newTestRouter(output *strings.Builder) *router.ServiceRouter {
+	sr, err := router.New(log.New(output, "", 0))
+	Expect(err).NotTo(HaveOccurred())
+	return sr
+}
+
+func sendNotify(body string, headers http.Header) *httptest.ResponseRecorder {
+	return sendRequest("/notify", body, headers)
+}
+
+func sendRequest(path string, body string, headers http.Header) *httptest.ResponseRecorder {
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for key, values := range headers {
+		req.Header[key] = values
+	}
+	api.ServeHTTP(res, req)
+	return res
+}
+
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": []string{"Bearer " + token}}
+}
+
+func signed(client string, secret string, timestamp string, body string) http.Header {
+	signature := hex.EncodeToString(Sign([]byte(secret), timestamp, []byte(body)))
+	headers := http.Header{}
+	headers.Set(ClientHeader, client)
+	headers.Set(TimestampHeader, timestamp)
+	headers.Set(SignatureHeader, "sha256="+signature)
+	return headers
+}
+
+func postNotify(body string) (*httptest.ResponseRecorder, NotifyResponse) {
+	res := httptest.NewRecorder()
+	api.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body)))
+	response := NotifyResponse{}
+	_ = json.Unmarshal(res.Body.Bytes(), &response)
+	return res, response
+}
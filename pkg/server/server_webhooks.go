@@ -0,0 +1,331 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+const (
+	verifyNone   = "none"
+	verifyGitHub = "github"
+	verifyGitea  = "gitea"
+	verifyHeader = "header"
+
+	gitHubSignatureHeader = "X-Hub-Signature-256"
+	giteaSignatureHeader  = "X-Gitea-Signature"
+	defaultSecretHeader   = "Authorization"
+
+	levelMapping      = "level"
+	paramMappingGroup = "params."
+	defaultPreset     = "json"
+)
+
+const gitMessageTemplate = `{{ with .sender }}{{ .login }} {{ end }}{{ with .action }}{{ . }} {{ end }}` +
+	`{{ with .pull_request }}pull request #{{ .number }}: {{ .title }} {{ .html_url }}` +
+	`{{ else }}{{ with .issue }}issue #{{ .number }}: {{ .title }} {{ .html_url }}` +
+	`{{ else }}{{ with .head_commit }}pushed {{ .id }}: {{ .message }} {{ .url }}{{ end }}{{ end }}{{ end }}`
+
+// webhookPresets contains the default mappings and verify methods for well-known webhook senders
+var webhookPresets = map[string]WebhookConfig{
+	"json": {
+		Title:   "$.title",
+		Message: "$.message",
+		Level:   "$.level",
+	},
+	"github": {
+		Verify:  verifyGitHub,
+		Title:   "$.repository.full_name",
+		Message: gitMessageTemplate,
+	},
+	"gitea": {
+		Verify:  verifyGitea,
+		Title:   "$.repository.full_name",
+		Message: gitMessageTemplate,
+	},
+	"grafana": {
+		Title:   "$.title",
+		Message: "$.message",
+		Level:   `{{ if eq .status "firing" }}error{{ else }}info{{ end }}`,
+	},
+}
+
+// webhook is an inbound webhook endpoint, mapping request payloads to notifications
+type webhook struct {
+	name    string
+	verify  string
+	secret  []byte
+	header  string
+	mapper  *payloadMapper
+	params  []string
+	targets []string
+	tags    []string
+	limiter *rateLimiter
+}
+
+func newWebhook(config WebhookConfig) (*webhook, error) {
+	if config.Name == "" {
+		return nil, errors.New("webhook name is missing")
+	}
+
+	if config.Preset == "" {
+		config.Preset = defaultPreset
+	}
+
+	preset, found := webhookPresets[strings.ToLower(config.Preset)]
+	if !found {
+		return nil, fmt.Errorf("unknown preset %q for webhook %q", config.Preset, config.Name)
+	}
+	config = withPresetDefaults(config, preset)
+
+	secret, err := valueOrFile(config.Secret, config.SecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret for webhook %q: %w", config.Name, err)
+	}
+
+	hook := &webhook{
+		name:    config.Name,
+		verify:  resolveVerifyMethod(config.Verify, secret),
+		secret:  []byte(secret),
+		header:  config.Header,
+		mapper:  newPayloadMapper(),
+		targets: config.Targets,
+		tags:    config.Tags,
+	}
+
+	if hook.header == "" {
+		hook.header = defaultSecretHeader
+	}
+
+	if len(hook.targets) == 0 && len(hook.tags) == 0 {
+		return nil, fmt.Errorf("webhook %q has no targets or tags, use %q to send to all targets", config.Name, allTargets)
+	}
+
+	if config.RateLimit > 0 {
+		hook.limiter = newRateLimiter(config.RateLimit, config.Burst)
+	}
+
+	switch hook.verify {
+	case verifyNone:
+	case verifyGitHub, verifyGitea, verifyHeader:
+		if secret == "" {
+			return nil, fmt.Errorf("webhook %q uses %q verification, but has no secret", config.Name, hook.verify)
+		}
+	default:
+		return nil, fmt.Errorf("unknown verify method %q for webhook %q", config.Verify, config.Name)
+	}
+
+	mappings := map[string]string{
+		titleTemplate:   config.Title,
+		messageTemplate: config.Message,
+		levelMapping:    config.Level,
+	}
+	for key, expr := range config.Params {
+		mappings[paramMappingGroup+key] = expr
+		hook.params = append(hook.params, key)
+	}
+
+	for id, expr := range mappings {
+		if err := hook.mapper.setMapping(id, expr); err != nil {
+			return nil, fmt.Errorf("webhook %q: %w", config.Name, err)
+		}
+	}
+
+	return hook, nil
+}
+
+func withPresetDefaults(config WebhookConfig, preset WebhookConfig) WebhookConfig {
+	if config.Verify == "" {
+		config.Verify = preset.Verify
+	}
+	if config.Title == "" {
+		config.Title = preset.Title
+	}
+	if config.Message == "" {
+		config.Message = preset.Message
+	}
+	if config.Level == "" {
+		config.Level = preset.Level
+	}
+	return config
+}
+
+// resolveVerifyMethod returns the verify method to use, defaulting to "header" if a secret is set, otherwise "none"
+func resolveVerifyMethod(method string, secret string) string {
+	if method != "" {
+		return strings.ToLower(method)
+	}
+	if secret != "" {
+		return verifyHeader
+	}
+	return verifyNone
+}
+
+// verifyRequest checks the request signature or shared secret according to the webhook verify method
+func (hook *webhook) verifyRequest(req *http.Request, body []byte) error {
+	switch hook.verify {
+	case verifyGitHub:
+		signature, found := strings.CutPrefix(req.Header.Get(gitHubSignatureHeader), signaturePrefix)
+		if !found {
+			return fmt.Errorf("missing %v header", gitHubSignatureHeader)
+		}
+		return verifyHMAC(hook.secret, body, signature)
+	case verifyGitea:
+		signature := req.Header.Get(giteaSignatureHeader)
+		if signature == "" {
+			return fmt.Errorf("missing %v header", giteaSignatureHeader)
+		}
+		return verifyHMAC(hook.secret, body, signature)
+	case verifyHeader:
+		value := []byte(strings.TrimPrefix(req.Header.Get(hook.header), "Bearer "))
+		if subtle.ConstantTimeCompare(value, hook.secret) != 1 {
+			return fmt.Errorf("missing or invalid %v header", hook.header)
+		}
+	}
+	return nil
+}
+
+func verifyHMAC(secret []byte, body []byte, signature string) error {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return errors.New("invalid signature encoding")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(expected, mac.Sum(nil)) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// notification maps the payload to a message item and params
+func (hook *webhook) notification(payload interface{}) (types.MessageItem, *types.Params, error) {
+	item := types.MessageItem{}
+	params := types.Params{}
+
+	message, err := hook.mapper.value(messageTemplate, payload)
+	if err != nil {
+		return item, nil, err
+	}
+	item.Text = strings.TrimSpace(message)
+
+	title, err := hook.mapper.value(titleTemplate, payload)
+	if err != nil {
+		return item, nil, err
+	}
+	if title = strings.TrimSpace(title); title != "" {
+		params.SetTitle(title)
+	}
+
+	levelName, err := hook.mapper.value(levelMapping, payload)
+	if err != nil {
+		return item, nil, err
+	}
+	if level, valid := types.ParseMessageLevel(strings.TrimSpace(levelName)); valid {
+		item.Level = level
+	}
+
+	for _, key := range hook.params {
+		value, err := hook.mapper.value(paramMappingGroup+key, payload)
+		if err != nil {
+			return item, nil, err
+		}
+		if value != "" {
+			params[key] = value
+		}
+	}
+
+	return item, &params, nil
+}
+
+func (server *Server) handleWebhook(res http.ResponseWriter, req *http.Request) {
+	hook, found := server.webhooks[req.PathValue("name")]
+	if !found {
+		server.writeError(res, http.StatusNotFound, errors.New("unknown webhook"))
+		return
+	}
+
+	body, err := readBody(res, req)
+	if err != nil {
+		server.writeError(res, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := hook.verifyRequest(req, body); err != nil {
+		server.reject(req, "webhook "+hook.name, err)
+		server.writeError(res, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Webhooks without a secret are authenticated using the API clients, if any are configured
+	var client *client
+	if hook.verify == verifyNone {
+		var authorized bool
+		if client, authorized = server.authenticate(res, req, body); !authorized {
+			return
+		}
+	}
+
+	if !server.allowRate(res, req, "webhook "+hook.name, hook.limiter) {
+		return
+	}
+
+	targets, explicit := hook.selectTargets(server.router)
+	if client != nil {
+		var err error
+		if targets, err = authorizeTargets(client, targets, explicit); err != nil {
+			server.reject(req, client.name, err)
+			server.writeError(res, http.StatusForbidden, err)
+			return
+		}
+	}
+
+	if len(targets) == 0 {
+		server.writeError(res, http.StatusNotFound, errors.New("no matching targets"))
+		return
+	}
+
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		server.writeError(res, http.StatusBadRequest, fmt.Errorf("invalid request payload: %w", err))
+		return
+	}
+
+	item, params, err := hook.notification(payload)
+	if err != nil {
+		server.writeError(res, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if item.Text == "" {
+		server.logger.Printf("Ignoring request to webhook %v, the mapped message is empty", hook.name)
+		server.writeResults(res, []router.SendResult{})
+		return
+	}
+
+	server.writeResults(res, server.router.SendItemsToContext(req.Context(), targets, []types.MessageItem{item}, params))
+}
+
+// selectTargets returns the targets of the webhook, or all the router targets if the targets contain "*". The
+// returned explicit is false for all targets, letting clients use the webhook with the targets they are allowed to.
+func (hook *webhook) selectTargets(serviceRouter *router.ServiceRouter) (targets []*router.Target, explicit bool) {
+	for _, name := range hook.targets {
+		if name == allTargets {
+			return serviceRouter.Targets(), false
+		}
+	}
+	return serviceRouter.Select(hook.targets, hook.tags), true
+}
@@ -0,0 +1,216 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const gitHubPayload = `{
+  "action": "opened",
+  "number": 1347,
+  "pull_request": {"number": 1347, "title": "Fix the thing", "html_url": "https://github.com/octocat/Hello-World/pull/1347"},
+  "repository": {"full_name": "octocat/Hello-World"},
+  "sender": {"login": "octocat"}
+}`
+
+var _ = Describe("the payload mapper", func() {
+	payload := map[string]interface{}{
+		"title":  "Title",
+		"count":  float64(3),
+		"nested": map[string]interface{}{"list": []interface{}{"first", map[string]interface{}{"key": "value"}}},
+	}
+
+	DescribeTable("resolving JSONPath expressions",
+		func(expr string, expected string) {
+			mapper := newPayloadMapper()
+			Expect(mapper.setMapping("id", expr)).To(Succeed())
+			Expect(mapper.value("id", payload)).To(Equal(expected))
+		},
+		Entry("a top level key", "$.title", "Title"),
+		Entry("an array index", "$.nested.list[0]", "first"),
+		Entry("a quoted key", "$.nested.list[1]['key']", "value"),
+		Entry("an object", "$.nested.list[1]", `{"key":"value"}`),
+		Entry("a missing key", "$.missing.key", ""),
+		Entry("an out of range index", "$.nested.list[7]", ""),
+	)
+
+	It("should render templates, treating missing keys as empty", func() {
+		mapper := newPayloadMapper()
+		Expect(mapper.setMapping("id", "{{ .title | toUpper }}{{ .missing }}")).To(Succeed())
+		Expect(mapper.value("id", payload)).To(Equal("TITLE"))
+	})
+
+	It("should return an error for invalid paths", func() {
+		Expect(newPayloadMapper().setMapping("id", "$.unterminated[0")).NotTo(Succeed())
+		Expect(newPayloadMapper().setMapping("id", "$..empty")).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("the webhook endpoints", func() {
+	BeforeEach(func() {
+		setupServer(&Config{
+			Webhooks: []WebhookConfig{
+				{Name: "json", Targets: []string{"ops"}},
+				{Name: "github", Preset: "github", Secret: "gh-secret", Targets: []string{"ops"}},
+				{Name: "gitea", Preset: "gitea", Secret: "gitea-secret", Targets: []string{"ops"}},
+				{Name: "shared", Secret: "shared-secret", Header: "X-Token", Message: "{{ .text }}", Targets: []string{"ops"}},
+			},
+		})
+	})
+
+	It("should map generic JSON payloads using the default keys", func() {
+		res := sendRequest("/webhooks/json", `{"title": "Hello", "message": "world", "level": "warning"}`, nil)
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(logBuffer.String()).To(Equal("world\n"))
+
+		item, params, err := api.webhooks["json"].notification(map[string]interface{}{
+			"title": "Hello", "message": "world", "level": "warning",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(item.Level).To(Equal(types.Warning))
+		title, _ := params.Title()
+		Expect(title).To(Equal("Hello"))
+	})
+
+	It("should map params using paths and templates", func() {
+		hook, err := newWebhook(WebhookConfig{
+			Name:    "params",
+			Message: "$.text",
+			Targets: []string{"ops"},
+			Params:  map[string]string{"color": "$.meta.color", "icon": "{{ .meta.icon }}", "empty": "$.missing"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, params, err := hook.notification(map[string]interface{}{
+			"text": "hi",
+			"meta": map[string]interface{}{"color": "red", "icon": "bell"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*params).To(Equal(types.Params{"color": "red", "icon": "bell"}))
+	})
+
+	It("should ignore payloads that map to an empty message", func() {
+		res := sendRequest("/webhooks/json", `{"title": "Hello"}`, nil)
+		Expect(res.Code).To(Equal(http.StatusOK))
+		Expect(res.Body.String()).To(MatchJSON(`{"results": []}`))
+		Expect(logBuffer.String()).To(BeEmpty())
+	})
+
+	It("should return not found for unknown webhooks", func() {
+		Expect(sendRequest("/webhooks/missing", `{}`, nil).Code).To(Equal(http.StatusNotFound))
+	})
+
+	When("verifying GitHub signatures", func() {
+		It("should accept a valid signature", func() {
+			headers := http.Header{}
+			headers.Set("X-Hub-Signature-256", "sha256="+hmacHex("gh-secret", gitHubPayload))
+			res := sendRequest("/webhooks/github", gitHubPayload, headers)
+			Expect(res.Code).To(Equal(http.StatusOK))
+			Expect(logBuffer.String()).To(Equal(
+				"octocat opened pull request #1347: Fix the thing https://github.com/octocat/Hello-World/pull/1347\n"))
+		})
+		It("should reject an invalid signature", func() {
+			headers := http.Header{}
+			headers.Set("X-Hub-Signature-256", "sha256="+hmacHex("wrong", gitHubPayload))
+			Expect(sendRequest("/webhooks/github", gitHubPayload, headers).Code).To(Equal(http.StatusUnauthorized))
+			Expect(logBuffer.String()).To(BeEmpty())
+		})
+		It("should reject a missing signature", func() {
+			Expect(sendRequest("/webhooks/github", gitHubPayload, nil).Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("verifying Gitea signatures", func() {
+		It("should accept a valid signature", func() {
+			headers := http.Header{}
+			headers.Set("X-Gitea-Signature", hmacHex("gitea-secret", gitHubPayload))
+			Expect(sendRequest("/webhooks/gitea", gitHubPayload, headers).Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("verifying a shared secret header", func() {
+		It("should accept the secret with or without a bearer prefix", func() {
+			headers := http.Header{}
+			headers.Set("X-Token", "shared-secret")
+			Expect(sendRequest("/webhooks/shared", `{"text": "a"}`, headers).Code).To(Equal(http.StatusOK))
+			headers.Set("X-Token", "Bearer shared-secret")
+			Expect(sendRequest("/webhooks/shared", `{"text": "b"}`, headers).Code).To(Equal(http.StatusOK))
+			Expect(logBuffer.String()).To(Equal("a\nb\n"))
+		})
+		It("should reject an invalid secret", func() {
+			headers := http.Header{}
+			headers.Set("X-Token", "nope")
+			Expect(sendRequest("/webhooks/shared", `{"text": "a"}`, headers).Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	When("the webhook config is invalid", func() {
+		It("should return an error for unknown presets", func() {
+			_, err := newWebhook(WebhookConfig{Name: "foo", Preset: "bitbucket"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return an error if signature verification is used without a secret", func() {
+			_, err := newWebhook(WebhookConfig{Name: "foo", Preset: "github"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return an error if no targets or tags are set", func() {
+			_, err := newWebhook(WebhookConfig{Name: "foo", Secret: "secret"})
+			Expect(err).To(MatchError(ContainSubstring("no targets or tags")))
+		})
+		It("should allow disabling verification explicitly", func() {
+			hook, err := newWebhook(WebhookConfig{Name: "foo", Preset: "github", Verify: "none", Tags: []string{"builds"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hook.verify).To(Equal(verifyNone))
+		})
+	})
+})
+
+var _ = Describe("the webhook endpoints when clients are configured", func() {
+	BeforeEach(func() {
+		setupServer(&Config{
+			Clients: []ClientConfig{
+				{Name: "ci", Token: "ci-token", Targets: []string{"ops"}},
+				{Name: "limited", Token: "limited-token", Targets: []string{"dev"}},
+			},
+			Webhooks: []WebhookConfig{
+				{Name: "open", Targets: []string{"ops"}},
+				{Name: "all", Targets: []string{"*"}},
+				{Name: "shared", Secret: "shared-secret", Message: "{{ .text }}", Targets: []string{"ops"}, RateLimit: 1},
+			},
+		})
+	})
+
+	It("should require a client credential for webhooks without a secret", func() {
+		Expect(sendRequest("/webhooks/open", `{"message": "a"}`, nil).Code).To(Equal(http.StatusUnauthorized))
+		Expect(sendRequest("/webhooks/open", `{"message": "b"}`, bearer("ci-token")).Code).To(Equal(http.StatusOK))
+		Expect(logBuffer.String()).To(Equal("b\n"))
+	})
+
+	It("should only send to the targets the client is authorized to use", func() {
+		Expect(sendRequest("/webhooks/open", `{"message": "a"}`, bearer("limited-token")).Code).To(Equal(http.StatusForbidden))
+		Expect(sendRequest("/webhooks/all", `{"message": "b"}`, bearer("ci-token")).Code).To(Equal(http.StatusOK))
+		Expect(logBuffer.String()).To(Equal("b\n"))
+	})
+
+	It("should verify webhooks with a secret without a client credential, and apply the rate limit", func() {
+		headers := http.Header{}
+		headers.Set("Authorization", "shared-secret")
+		Expect(sendRequest("/webhooks/shared", `{"text": "a"}`, headers).Code).To(Equal(http.StatusOK))
+		res := sendRequest("/webhooks/shared", `{"text": "b"}`, headers)
+		Expect(res.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(res.Header().Get("Retry-After")).NotTo(BeEmpty())
+		Expect(logBuffer.String()).To(Equal("a\n"))
+	})
+})
+
+func hmacHex(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -1,17 +1,30 @@
 package bark
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/util/jsonclient"
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
 )
 
+// priorities maps the service independent priorities to the Bark interruption levels
+var priorities = priority.Mapping{
+	priority.Priorities.Min:    "passive",
+	priority.Priorities.Low:    "passive",
+	priority.Priorities.Normal: "active",
+	priority.Priorities.High:   "timeSensitive",
+	priority.Priorities.Urgent: "critical",
+}
+
 // Service sends notifications Bark
 type Service struct {
 	standard.Standard
@@ -19,21 +32,57 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Bark
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
+	return service.send(message, nil, params)
+}
+
+// SendItems sends the items as a plain text message. The first action of the items is opened when the notification
+// is clicked, unless the url is set, and the rest of the actions are appended as links.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	message := strings.TrimSuffix(types.ItemsToPlain(types.ItemsWithoutActions(items)), "\n")
+	return service.send(message, types.LinkActions(types.ItemsActions(items)), &params)
+}
+
+func (service *Service) send(message string, actions []types.Action, params *types.Params) error {
+	config := *service.config
+	params = priorities.Params(params)
 
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
 		return err
 	}
 
-	if err := service.sendAPI(config, message); err != nil {
+	if len(actions) > 0 && config.URL == "" {
+		config.URL = actions[0].URL
+		actions = actions[1:]
+	}
+	if links := types.ActionsToPlain(actions); links != "" {
+		message += "\n" + links
+	}
+
+	if config.Format == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
+
+	if err := service.sendAPI(&config, message); err != nil {
 		return fmt.Errorf("failed to send bark notification: %w", err)
 	}
 
 	return nil
 }
 
+// PriorityMapping returns the Bark interruption levels used for the service independent priorities
+func (service *Service) PriorityMapping() priority.Mapping {
+	return priorities
+}
+
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
 func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) error {
 	service.Logger.SetLogger(logger)
@@ -46,6 +95,24 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
 func (service *Service) sendAPI(config *Config, message string) error {
 	response := apiResponse{}
 	request := PushPayload{
@@ -59,8 +126,9 @@ func (service *Service) sendAPI(config *Config, message string) error {
 		Badge:     &config.Badge,
 		Icon:      config.Icon,
 		URL:       config.URL,
+		Level:     config.Level,
 	}
-	jsonClient := jsonclient.NewClient()
+	jsonClient := jsonclient.NewWithHTTPClient(service.HTTPClient())
 
 	if err := jsonClient.Post(config.GetAPIURL("push"), &request, &response); err != nil {
 		if jsonClient.ErrorResponse(err, &response) {
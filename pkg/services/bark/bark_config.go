@@ -5,13 +5,12 @@ import (
 	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Config for use within the bark service
 type Config struct {
-	standard.EnumlessConfig
 	Title     string `key:"title"    default:""      desc:"Notification title, optionally set by the sender"`
 	Host      string `url:"host"                     desc:"Server hostname and port"`
 	Path      string `url:"path"     default:"/"     desc:"Server path"`
@@ -24,6 +23,16 @@ type Config struct {
 	URL       string `key:"url"      default:""      desc:"Url that will jump when click notification"`
 	Category  string `key:"category" default:""      desc:"Reserved field, no use yet"`
 	Copy      string `key:"copy"     default:""      desc:"The value to be copied"`
+	Level     string `key:"level,priority" default:"" desc:"Interruption level: active, timeSensitive, passive or critical. Also accepts the Min, Low, Normal, High and Urgent priorities"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
@@ -78,7 +87,7 @@ func (config *Config) setURL(resolver types.ConfigQueryResolver, url *url.URL) e
 	config.Host = url.Host
 	config.Path = url.Path
 
-	for key, vals := range url.Query() {
+	for key, vals := range priorities.Query(url.Query()) {
 		if err := resolver.Set(key, vals[0]); err != nil {
 			return err
 		}
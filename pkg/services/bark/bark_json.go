@@ -12,6 +12,7 @@ type PushPayload struct {
 	URL       string `json:"url,omitempty"`
 	Category  string `json:"category,omitempty"`
 	Copy      string `json:"copy,omitempty"`
+	Level     string `json:"level,omitempty"`
 }
 
 type apiResponse struct {
@@ -3,7 +3,9 @@ package bark
 import (
 	"github.com/dockerutil/shoutrrr/internal/testutils"
 	"github.com/dockerutil/shoutrrr/pkg/format"
+	"github.com/dockerutil/shoutrrr/pkg/types"
 
+	"encoding/json"
 	"log"
 	"net/http"
 	"net/url"
@@ -99,6 +101,25 @@ var _ = Describe("the bark service", func() {
 
 			Expect(service.Send("Message", nil)).To(Succeed())
 		})
+		It("should send the service independent priority as the interruption level", func() {
+			serviceURL := testutils.URLMust("bark://:devicekey@hostname?priority=low")
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+			Expect(service.config.Level).To(Equal("passive"))
+
+			var levels []string
+			httpmock.RegisterResponder("POST", service.config.GetAPIURL("push"), func(req *http.Request) (*http.Response, error) {
+				payload := PushPayload{}
+				if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+					return nil, err
+				}
+				levels = append(levels, payload.Level)
+				return httpmock.NewJsonResponse(200, apiResponse{Code: http.StatusOK, Message: "OK"})
+			})
+
+			Expect(service.Send("Message", &types.Params{"priority": "urgent"})).To(Succeed())
+			Expect(service.Send("Message", nil)).To(Succeed())
+			Expect(levels).To(Equal([]string{"critical", "passive"}))
+		})
 		It("should not panic if a server error occurs", func() {
 			serviceURL := testutils.URLMust("bark://:devicekey@hostname")
 			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
@@ -137,8 +158,8 @@ var _ = Describe("the bark service", func() {
 
 				testutils.TestConfigSetDefaultValues(&Config{})
 
-				testutils.TestConfigGetEnumsCount(&Config{}, 0)
-				testutils.TestConfigGetFieldsCount(&Config{}, 9)
+				testutils.TestConfigGetEnumsCount(&Config{}, 1)
+				testutils.TestConfigGetFieldsCount(&Config{}, 12)
 			})
 		})
 		Describe("the service instance", func() {
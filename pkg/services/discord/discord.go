@@ -2,8 +2,11 @@ package discord
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 
@@ -11,6 +14,8 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 )
 
 // Service providing Discord as a notification service
@@ -30,20 +35,108 @@ const (
 	hookURL = "https://discord.com/api/webhooks"
 	// Only search this many runes for a good split position
 	maxSearchRunes = 100
+	// maxFiles is the max number of files that can be uploaded with a single webhook message
+	maxFiles = 10
+	// maxFileSize is the upload limit of servers without boosts
+	maxFileSize = 10 << 20
 )
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to discord
 func (service *Service) Send(message string, params *types.Params) error {
+	_, err := service.send(message, params, false)
+	return err
+}
+
+// SendRefs sends the message like Send, returning a reference with the ID of every message sent by the webhook
+func (service *Service) SendRefs(message string, params *types.Params) ([]types.MessageRef, error) {
+	return service.send(message, params, true)
+}
+
+// Update replaces the content of the referenced webhook message. The message must fit in a single webhook message.
+func (service *Service) Update(ref types.MessageRef, message string, params *types.Params) error {
+	payload := []byte(message)
+	if !service.config.JSON {
+		config := *service.config
+		if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+			return err
+		}
+		if config.Format == markup.Formats.Markdown {
+			message = markup.Discord(message)
+		}
+
+		batches := createItemsFromPlain(message, config.SplitLines, false)
+		if len(batches) > 1 {
+			return fmt.Errorf("failed to update discord message: %w", overflow.ErrMessageTooLong)
+		}
+		var items []types.MessageItem
+		if len(batches) > 0 {
+			items = batches[0]
+		}
+
+		var err error
+		if payload, err = createPayloadBytes(items, &config); err != nil {
+			return err
+		}
+	}
+
+	_, err := service.request(http.MethodPatch, messageURL(service.config, ref), "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to update discord message: %v", err)
+	}
+	return nil
+}
+
+// Delete removes the referenced webhook message
+func (service *Service) Delete(ref types.MessageRef) error {
+	if _, err := service.request(http.MethodDelete, messageURL(service.config, ref), "", nil); err != nil {
+		return fmt.Errorf("failed to delete discord message: %v", err)
+	}
+	return nil
+}
+
+// send sends the message, waiting for the webhook to return the IDs of the messages if wait is set
+func (service *Service) send(message string, params *types.Params, wait bool) ([]types.MessageRef, error) {
 	var firstErr error
+	var refs []types.MessageRef
 
 	if service.config.JSON {
-		postURL := CreateAPIURLFromConfig(service.config)
-		firstErr = doSend([]byte(message), postURL)
+		var id string
+		id, firstErr = service.doSend([]byte(message), webhookURL(service.config, wait))
+		refs = appendRef(refs, id)
 	} else {
-		batches := CreateItemsFromPlain(message, service.config.SplitLines)
+		config := *service.config
+		if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+			return nil, err
+		}
+		if config.Format == markup.Formats.Markdown {
+			message = markup.Discord(message)
+		}
+
+		var attachment *types.Attachment
+		if config.Overflow != overflow.Modes.Split {
+			messages, overflowAttachment, err := overflow.Apply(message, limits, config.Overflow)
+			if err != nil {
+				return nil, err
+			}
+			message, attachment = messages[0], overflowAttachment
+		}
+
+		batches := createItemsFromPlain(message, config.SplitLines, config.Markers)
+		if attachment != nil && len(batches) > 0 && len(batches[0]) > 0 {
+			batches[0][0].Attachments = append(batches[0][0].Attachments, *attachment)
+		}
 		for _, items := range batches {
-			if err := service.sendItems(items, params); err != nil {
-				service.Log(err)
+			batchRefs, err := service.sendItems(items, &config, wait)
+			refs = append(refs, batchRefs...)
+			if err != nil {
+				service.Error("Failed to send items", "error", err)
 				if firstErr == nil {
 					firstErr = err
 				}
@@ -52,56 +145,125 @@ func (service *Service) Send(message string, params *types.Params) error {
 	}
 
 	if firstErr != nil {
-		return fmt.Errorf("failed to send discord notification: %v", firstErr)
+		return refs, fmt.Errorf("failed to send discord notification: %v", firstErr)
 	}
-	return nil
+	return refs, nil
 }
 
-// SendItems sends items with additional meta data and richer appearance
-func (service *Service) SendItems(items []types.MessageItem, params *types.Params) error {
-	return service.sendItems(items, params)
+// MessageLimit returns the payload limits of the Discord webhook API
+func (service *Service) MessageLimit() types.MessageLimit {
+	return limits
 }
 
-func (service *Service) sendItems(items []types.MessageItem, params *types.Params) error {
-	var err error
+// MaxAttachmentSize returns the max size of files uploaded using the webhook
+func (service *Service) MaxAttachmentSize() int64 {
+	return maxFileSize
+}
 
+// SendItems sends items with additional meta data and richer appearance.
+// The attachments of the items are uploaded with the message, with any files exceeding the limit of a single
+// message being sent in additional messages.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
 	config := *service.config
-	if err = service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+	if err := service.pkr.UpdateConfigFromParams(&config, &params); err != nil {
 		return err
 	}
 
-	var payload WebhookPayload
-	payload, err = CreatePayloadFromItems(items, config.Title, config.LevelColors())
+	if config.Format == markup.Formats.Markdown {
+		converted := make([]types.MessageItem, len(items))
+		for i, item := range items {
+			item.Text = markup.Discord(item.Text)
+			converted[i] = item
+		}
+		items = converted
+	}
+
+	_, err := service.sendItems(items, &config, false)
+	return err
+}
+
+// sendItems sends the items, returning the references to the sent messages if wait is set
+func (service *Service) sendItems(items []types.MessageItem, config *Config, wait bool) ([]types.MessageRef, error) {
+	payloadBytes, err := createPayloadBytes(items, config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	payload.Username = config.Username
-	payload.AvatarURL = config.Avatar
+	var refs []types.MessageRef
+	var id string
+	postURL := webhookURL(config, wait)
+	attachments := types.ItemsAttachments(items)
+	if len(attachments) == 0 {
+		id, err = service.doSend(payloadBytes, postURL)
+		return appendRef(refs, id), err
+	}
+
+	for start := 0; start < len(attachments); start += maxFiles {
+		if start > 0 {
+			// The embeds have already been sent, only include the files in the following messages
+			payloadBytes, err = json.Marshal(WebhookPayload{
+				Username:  config.Username,
+				AvatarURL: config.Avatar,
+			})
+			if err != nil {
+				return refs, err
+			}
+		}
+		id, err = service.doSendFiles(payloadBytes, attachments[start:util.Min(start+maxFiles, len(attachments))], postURL)
+		refs = appendRef(refs, id)
+		if err != nil {
+			return refs, err
+		}
+	}
+
+	return refs, nil
+}
 
-	var payloadBytes []byte
-	payloadBytes, err = json.Marshal(payload)
+// createPayloadBytes returns the marshalled webhook payload of the items
+func createPayloadBytes(items []types.MessageItem, config *Config) ([]byte, error) {
+	payload, err := CreatePayloadFromItems(items, config.Title, config.LevelColors())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	postURL := CreateAPIURLFromConfig(&config)
-	return doSend(payloadBytes, postURL)
+	payload.Username = config.Username
+	payload.AvatarURL = config.Avatar
+
+	return json.Marshal(payload)
+}
+
+// appendRef appends a reference to the message with id to refs, unless id is empty
+func appendRef(refs []types.MessageRef, id string) []types.MessageRef {
+	if id == "" {
+		return refs
+	}
+	return append(refs, types.MessageRef{ID: id})
 }
 
 // CreateItemsFromPlain creates a set of MessageItems that is compatible with Discords webhook payload
 func CreateItemsFromPlain(plain string, splitLines bool) (batches [][]types.MessageItem) {
+	return createItemsFromPlain(plain, splitLines, false)
+}
+
+func createItemsFromPlain(plain string, splitLines bool, markers bool) (batches [][]types.MessageItem) {
+	var chunkBatches [][]string
 	if splitLines {
-		return util.MessageItemsFromLines(plain, limits)
+		partitioner := util.Partitioner{Limits: limits, Markers: markers}
+		chunkBatches = partitioner.Lines(plain)
+	} else {
+		// The last chunk of each batch is reserved for meta data
+		batchLimits := limits
+		batchLimits.ChunkCount--
+		partitioner := util.Partitioner{Limits: batchLimits, Distance: maxSearchRunes, Markers: markers}
+		chunkBatches = partitioner.Batches(plain)
 	}
 
-	for {
-		items, omitted := util.PartitionMessage(plain, limits, maxSearchRunes)
-		batches = append(batches, items)
-		if omitted == 0 {
-			break
+	for _, chunks := range chunkBatches {
+		items := make([]types.MessageItem, 0, len(chunks))
+		for _, chunk := range chunks {
+			items = append(items, types.MessageItem{Text: chunk})
 		}
-		plain = plain[len(plain)-omitted:]
+		batches = append(batches, items)
 	}
 
 	return
@@ -124,6 +286,24 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return nil
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
 // CreateAPIURLFromConfig takes a discord config object and creates a post url
 func CreateAPIURLFromConfig(config *Config) string {
 	return fmt.Sprintf(
@@ -133,16 +313,74 @@ func CreateAPIURLFromConfig(config *Config) string {
 		config.Token)
 }
 
-func doSend(payload []byte, postURL string) error {
-	res, err := http.Post(postURL, "application/json", bytes.NewBuffer(payload))
+// webhookURL returns the URL used to execute the webhook, asking it to return the sent message if wait is set
+func webhookURL(config *Config, wait bool) string {
+	if wait {
+		return CreateAPIURLFromConfig(config) + "?wait=true"
+	}
+	return CreateAPIURLFromConfig(config)
+}
+
+// messageURL returns the URL of the webhook message identified by ref
+func messageURL(config *Config, ref types.MessageRef) string {
+	return fmt.Sprintf("%s/messages/%s", CreateAPIURLFromConfig(config), url.PathEscape(ref.ID))
+}
+
+func (service *Service) doSend(payload []byte, postURL string) (string, error) {
+	return service.request(http.MethodPost, postURL, "application/json", bytes.NewBuffer(payload))
+}
+
+// doSendFiles sends the payload together with the files as a multipart form
+func (service *Service) doSendFiles(payload []byte, attachments []types.Attachment, postURL string) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if err := writer.WriteField("payload_json", string(payload)); err != nil {
+		return "", err
+	}
+
+	for i := range attachments {
+		if err := util.WriteFormFile(writer, fmt.Sprintf("files[%d]", i), &attachments[i]); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	return service.request(http.MethodPost, postURL, writer.FormDataContentType(), body)
+}
+
+// request sends the body to the webhook API, returning the ID of the message if the API responded with it
+func (service *Service) request(method string, requestURL string, contentType string, body io.Reader) (string, error) {
+	req, err := http.NewRequest(method, requestURL, body)
+	if err != nil {
+		return "", err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
 
+	res, err := service.HTTPClient().Do(req)
 	if res == nil && err == nil {
 		err = fmt.Errorf("unknown error")
 	}
-
-	if err == nil && res.StatusCode != http.StatusNoContent {
-		err = fmt.Errorf("response status code %s", res.Status)
+	if err != nil {
+		return "", err
 	}
+	defer res.Body.Close()
 
-	return err
+	switch res.StatusCode {
+	case http.StatusNoContent:
+		return "", nil
+	case http.StatusOK:
+		message := webhookMessage{}
+		if err := json.NewDecoder(res.Body).Decode(&message); err != nil {
+			return "", fmt.Errorf("failed to parse response: %v", err)
+		}
+		return message.ID, nil
+	default:
+		return "", fmt.Errorf("response status code %s", res.Status)
+	}
 }
@@ -3,14 +3,14 @@ package discord
 import (
 	"errors"
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 	"net/url"
 )
 
 // Config is the configuration needed to send discord notifications
 type Config struct {
-	standard.EnumlessConfig
 	WebhookID  string `url:"host"`
 	Token      string `url:"user"`
 	Title      string `key:"title"      default:""`
@@ -22,7 +22,11 @@ type Config struct {
 	ColorInfo  uint   `key:"colorInfo"  default:"0x2488ff" desc:"The color of the left border for info messages"    base:"16"`
 	ColorDebug uint   `key:"colorDebug" default:"0x7b00ab" desc:"The color of the left border for debug messages"   base:"16"`
 	SplitLines bool   `key:"splitLines" default:"Yes"      desc:"Whether to send each line as a separate embedded item"`
+	Markers    bool   `key:"markers"    default:"No"       desc:"Whether to append (1/3) style markers to the items when splitting long messages"`
 	JSON       bool   `key:"json"       default:"No"       desc:"Whether to send the whole message as the JSON payload instead of using it as the 'content' field"`
+
+	Format   markup.Format `key:"format"   default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+	Overflow overflow.Mode `key:"overflow" default:"Split" desc:"How to send messages longer than a single webhook message: Split, Truncate, Attach or Error"`
 }
 
 // LevelColors returns an array of colors with a MessageLevel index
@@ -36,6 +40,14 @@ func (config *Config) LevelColors() (colors [types.MessageLevelCount]uint) {
 	return colors
 }
 
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Format":   markup.Formats.Enum,
+		"Overflow": overflow.Modes.Enum,
+	}
+}
+
 // GetURL returns a URL representation of it's current field values
 func (config *Config) GetURL() *url.URL {
 	resolver := format.NewPropKeyResolver(config)
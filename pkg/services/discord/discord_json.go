@@ -2,19 +2,33 @@ package discord
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/dockerutil/shoutrrr/pkg/util"
 )
 
+const (
+	maxEmbedFields      = 25
+	maxFieldNameLength  = 256
+	maxFieldValueLength = 1024
+	// maxInlineValueLength is the max length of field values shown side by side
+	maxInlineValueLength = 40
+)
+
 // WebhookPayload is the webhook endpoint payload
 type WebhookPayload struct {
-	Embeds    []embedItem `json:"embeds"`
+	Embeds    []embedItem `json:"embeds,omitempty"`
 	Username  string      `json:"username,omitempty"`
 	AvatarURL string      `json:"avatar_url,omitempty"`
 }
 
+// webhookMessage is the message returned by the webhook API when waiting for the message to be sent
+type webhookMessage struct {
+	ID string `json:"id"`
+}
+
 // JSON is the actual notification payload
 type embedItem struct {
 	Title     string       `json:"title,omitempty"`
@@ -23,6 +37,13 @@ type embedItem struct {
 	Timestamp string       `json:"timestamp,omitempty"`
 	Color     uint         `json:"color,omitempty"`
 	Footer    *embedFooter `json:"footer,omitempty"`
+	Fields    []embedField `json:"fields,omitempty"`
+}
+
+type embedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
 }
 
 type embedFooter struct {
@@ -49,7 +70,7 @@ func CreatePayloadFromItems(items []types.MessageItem, title string, colors [typ
 		}
 
 		ei := embedItem{
-			Content: item.Text,
+			Content: appendActionLinks(item.Text, item.Actions),
 			Color:   color,
 		}
 
@@ -63,6 +84,8 @@ func CreatePayloadFromItems(items []types.MessageItem, title string, colors [typ
 			ei.Timestamp = item.Timestamp.UTC().Format(time.RFC3339)
 		}
 
+		ei.Fields = createEmbedFields(item.Fields)
+
 		embeds = append(embeds, ei)
 	}
 
@@ -75,3 +98,45 @@ func CreatePayloadFromItems(items []types.MessageItem, title string, colors [typ
 		Embeds: embeds,
 	}, nil
 }
+
+// appendActionLinks appends the actions that open a URL to text as markdown links, since webhooks cannot add buttons
+func appendActionLinks(text string, actions []types.Action) string {
+	builder := strings.Builder{}
+	builder.WriteString(text)
+	for _, action := range types.LinkActions(actions) {
+		builder.WriteString("\n[")
+		builder.WriteString(action.Label)
+		builder.WriteString("](")
+		builder.WriteString(action.URL)
+		builder.WriteString(")")
+	}
+	return builder.String()
+}
+
+// createEmbedFields creates the embed fields from the item fields, shown inline if the value is short
+func createEmbedFields(fields []types.Field) []embedField {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	embedFields := make([]embedField, 0, util.Min(len(fields), maxEmbedFields))
+	for _, field := range fields {
+		if len(embedFields) == maxEmbedFields {
+			break
+		}
+		embedFields = append(embedFields, embedField{
+			Name:   util.Ellipsis(nonEmpty(field.Key), maxFieldNameLength),
+			Value:  util.Ellipsis(nonEmpty(field.Value), maxFieldValueLength),
+			Inline: len(field.Value) <= maxInlineValueLength && !strings.Contains(field.Value, "\n"),
+		})
+	}
+	return embedFields
+}
+
+// nonEmpty returns a zero width space for empty strings, since discord does not accept empty field names or values
+func nonEmpty(text string) string {
+	if text == "" {
+		return "\u200b"
+	}
+	return text
+}
@@ -1,15 +1,20 @@
 package discord_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/dockerutil/shoutrrr/internal/testutils"
 	. "github.com/dockerutil/shoutrrr/pkg/services/discord"
+	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 	"github.com/jarcoal/httpmock"
 
+	"mime/multipart"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
@@ -166,6 +171,19 @@ var _ = Describe("the discord service", func() {
 
 				// Expect(meta.Footer.Text).To(ContainSubstring("200"))
 			})
+			It("should not corrupt multi-byte runes in the following batches", func() {
+				plain := strings.Repeat("å", 7000)
+				batches := CreateItemsFromPlain(plain, false)
+				Expect(batches).To(HaveLen(2))
+
+				builder := strings.Builder{}
+				for _, items := range batches {
+					for _, item := range items {
+						builder.WriteString(item.Text)
+					}
+				}
+				Expect(builder.String()).To(Equal(plain))
+			})
 			When("no title is supplied and content fits", func() {
 				It("should return a payload without a meta chunk", func() {
 
@@ -203,6 +221,20 @@ var _ = Describe("the discord service", func() {
 				Expect(item.Title).To(Equal("Title"))
 				Expect(item.Color).To(Equal(dummyColors[types.Warning]))
 			})
+			It("should add the item fields as embed fields", func() {
+				item := types.MessageItem{Text: "Deploy failed"}
+				item.WithField("host", "web-1").WithField("error", strings.Repeat("connection refused ", 3)).WithField("empty", "")
+				payload, err := CreatePayloadFromItems([]types.MessageItem{item}, "", dummyColors)
+				Expect(err).ToNot(HaveOccurred())
+
+				fields := payload.Embeds[0].Fields
+				Expect(fields).To(HaveLen(3))
+				Expect(fields[0].Name).To(Equal("host"))
+				Expect(fields[0].Value).To(Equal("web-1"))
+				Expect(fields[0].Inline).To(BeTrue())
+				Expect(fields[1].Inline).To(BeFalse())
+				Expect(fields[2].Value).To(Equal("\u200b"))
+			})
 		})
 	})
 
@@ -237,6 +269,127 @@ var _ = Describe("the discord service", func() {
 			Expect(service.Initialize(dummyConfig.GetURL(), logger)).To(Succeed())
 			Expect(service.Send("", nil)).NotTo(Succeed())
 		})
+		When("the service has been created from a config", func() {
+			It("should send using the config", func() {
+				setupResponder(&dummyConfig, 204, "")
+				configService, err := New(&dummyConfig, standard.WithLogger(logger))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(configService.Send("Message", nil)).To(Succeed())
+			})
+			It("should return an error if the config is nil", func() {
+				_, err := New(nil)
+				Expect(err).To(MatchError(standard.ErrUnexpectedConfig))
+			})
+		})
+		When("sending items with attachments", func() {
+			It("should upload the files in a multipart form with the payload", func() {
+				var forms []*multipart.Form
+				httpmock.RegisterResponder("POST", CreateAPIURLFromConfig(&dummyConfig), func(req *http.Request) (*http.Response, error) {
+					if err := req.ParseMultipartForm(1 << 20); err != nil {
+						return nil, err
+					}
+					forms = append(forms, req.MultipartForm)
+					return httpmock.NewStringResponse(204, ""), nil
+				})
+
+				item := types.MessageItem{Text: "Report"}
+				for i := 0; i < 11; i++ {
+					item.WithAttachment(types.Attachment{Name: fmt.Sprintf("report-%d.txt", i), Data: []byte("content")})
+				}
+				Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+
+				Expect(forms).To(HaveLen(2))
+				Expect(forms[0].Value["payload_json"][0]).To(ContainSubstring(`"description":"Report"`))
+				Expect(forms[0].File).To(HaveLen(10))
+				Expect(forms[0].File["files[0]"][0].Filename).To(Equal("report-0.txt"))
+				Expect(forms[0].File["files[0]"][0].Header.Get("Content-Type")).To(HavePrefix("text/plain"))
+				Expect(forms[1].Value["payload_json"][0]).NotTo(ContainSubstring("embeds"))
+				Expect(forms[1].File["files[0]"][0].Filename).To(Equal("report-10.txt"))
+			})
+		})
+		When("the message exceeds the max length of a webhook message", func() {
+			message := strings.Repeat("The build failed. ", 400)
+			It("should send the truncated message with the full text as a file when using the attach mode", func() {
+				var forms []*multipart.Form
+				httpmock.RegisterResponder("POST", CreateAPIURLFromConfig(&dummyConfig), func(req *http.Request) (*http.Response, error) {
+					if err := req.ParseMultipartForm(1 << 20); err != nil {
+						return nil, err
+					}
+					forms = append(forms, req.MultipartForm)
+					return httpmock.NewStringResponse(204, ""), nil
+				})
+
+				params := types.Params{"overflow": "attach", "splitLines": "no"}
+				Expect(service.Send(message, &params)).To(Succeed())
+
+				Expect(forms).To(HaveLen(1))
+				Expect(forms[0].Value["payload_json"][0]).To(ContainSubstring("more characters]"))
+				Expect(forms[0].File["files[0]"][0].Filename).To(Equal(overflow.AttachmentName))
+			})
+			It("should send every line once with markers when splitting lines", func() {
+				var payloads []WebhookPayload
+				httpmock.RegisterResponder("POST", CreateAPIURLFromConfig(&dummyConfig), func(req *http.Request) (*http.Response, error) {
+					var payload WebhookPayload
+					if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+						return nil, err
+					}
+					payloads = append(payloads, payload)
+					return httpmock.NewStringResponse(204, ""), nil
+				})
+
+				lines := make([]string, 12)
+				for i := range lines {
+					lines[i] = fmt.Sprintf("line %d", i+1)
+				}
+				params := types.Params{"markers": "yes", "splitLines": "yes"}
+				Expect(service.Send(strings.Join(lines, "\n"), &params)).To(Succeed())
+
+				Expect(payloads).To(HaveLen(2))
+				Expect(payloads[0].Embeds).To(HaveLen(10))
+				Expect(payloads[0].Embeds[0].Content).To(Equal("line 1 (1/12)"))
+				Expect(payloads[1].Embeds).To(HaveLen(2))
+				Expect(payloads[1].Embeds[1].Content).To(Equal("line 12 (12/12)"))
+			})
+			It("should return an error when using the error mode", func() {
+				setupResponder(&dummyConfig, 204, "")
+				params := types.Params{"overflow": "error"}
+				Expect(service.Send(message, &params)).To(MatchError(overflow.ErrMessageTooLong))
+				Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+			})
+		})
+		When("referencing the sent messages", func() {
+			It("should wait for the webhook to return the message ID", func() {
+				httpmock.RegisterResponder("POST", CreateAPIURLFromConfig(&dummyConfig)+"?wait=true",
+					httpmock.NewStringResponder(200, `{"id": "1234567890"}`))
+
+				refs, err := service.SendRefs("Deploy started", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(refs).To(Equal([]types.MessageRef{{ID: "1234567890"}}))
+			})
+			It("should update and delete the webhook message", func() {
+				var updated WebhookPayload
+				messageURL := CreateAPIURLFromConfig(&dummyConfig) + "/messages/1234567890"
+				httpmock.RegisterResponder("PATCH", messageURL, func(req *http.Request) (*http.Response, error) {
+					if err := json.NewDecoder(req.Body).Decode(&updated); err != nil {
+						return nil, err
+					}
+					return httpmock.NewStringResponse(200, `{"id": "1234567890"}`), nil
+				})
+				httpmock.RegisterResponder("DELETE", messageURL, httpmock.NewStringResponder(204, ""))
+
+				ref := types.MessageRef{ID: "1234567890"}
+				Expect(service.Update(ref, "Deploy finished", nil)).To(Succeed())
+				Expect(updated.Embeds).To(HaveLen(1))
+				Expect(updated.Embeds[0].Content).To(Equal("Deploy finished"))
+				Expect(service.Delete(ref)).To(Succeed())
+				Expect(httpmock.GetTotalCallCount()).To(Equal(2))
+			})
+			It("should not update a message with text that does not fit in a single message", func() {
+				params := types.Params{"splitLines": "no"}
+				err := service.Update(types.MessageRef{ID: "1"}, strings.Repeat("The build failed. ", 400), &params)
+				Expect(err).To(MatchError(overflow.ErrMessageTooLong))
+			})
+		})
 		When("using a custom json payload", func() {
 			It("should report an error if the server response is not OK", func() {
 				config := dummyConfig
@@ -5,8 +5,11 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -21,6 +24,13 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to a generic webhook endpoint
 func (service *Service) Send(message string, paramsPtr *types.Params) error {
 	config := *service.config
@@ -33,7 +43,11 @@ func (service *Service) Send(message string, paramsPtr *types.Params) error {
 	}
 
 	if err := service.pkr.UpdateConfigFromParams(&config, &params); err != nil {
-		service.Logf("Failed to update params: %v", err)
+		service.Warn("Failed to update params", "error", err)
+	}
+
+	if config.Format == markup.Formats.Markdown {
+		message = markup.Plain(message)
 	}
 
 	// Create a mutable copy of the passed params
@@ -56,6 +70,27 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return service.config.setURL(&service.pkr, configURL)
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	if serviceConfig.webhookURL == nil {
+		return errors.New("webhook URL missing from config, use ConfigFromWebhookURL to create it")
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
 // GetConfigURLFromCustom creates a regular service URL from one with a custom host
 func (*Service) GetConfigURLFromCustom(customURL *url.URL) (serviceURL *url.URL, err error) {
 	webhookURL := *customURL
@@ -84,11 +119,11 @@ func (service *Service) doSend(config *Config, params types.Params) error {
 			req.Header.Set(key, value)
 		}
 		var res *http.Response
-		res, err = http.DefaultClient.Do(req)
+		res, err = service.HTTPClient().Do(req)
 		if res != nil && res.Body != nil {
 			defer res.Body.Close()
 			if body, errRead := io.ReadAll(res.Body); errRead == nil {
-				service.Log("Server response: ", string(body))
+				service.Debug("Server response", "body", string(body))
 			}
 		}
 		if err == nil && res.StatusCode >= http.StatusMultipleChoices {
@@ -4,13 +4,12 @@ import (
 	"net/url"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Config for use within the generic service
 type Config struct {
-	standard.EnumlessConfig
 	webhookURL    *url.URL
 	headers       map[string]string
 	extraData     map[string]string
@@ -21,6 +20,8 @@ type Config struct {
 	TitleKey      string `key:"titlekey"    default:"title" desc:"The key that will be used for the title value"`
 	MessageKey    string `key:"messagekey"  default:"message" desc:"The key that will be used for the message value"`
 	RequestMethod string `key:"method"      default:"POST"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
 }
 
 // DefaultConfig creates a PropKeyResolver and uses it to populate the default values of a new Config, returning both
@@ -55,6 +56,13 @@ func (config *Config) WebhookURL() *url.URL {
 	return &webhookURL
 }
 
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]t.EnumFormatter {
+	return map[string]t.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
+}
+
 // GetURL returns a URL representation of its current field values
 func (config *Config) GetURL() *url.URL {
 	resolver := format.NewPropKeyResolver(config)
@@ -69,8 +77,11 @@ func (config *Config) SetURL(serviceURL *url.URL) error {
 
 func (config *Config) getURL(resolver t.ConfigQueryResolver) *url.URL {
 
-	serviceURL := *config.webhookURL
-	webhookQuery := config.webhookURL.Query()
+	serviceURL := url.URL{}
+	if config.webhookURL != nil {
+		serviceURL = *config.webhookURL
+	}
+	webhookQuery := serviceURL.Query()
 	serviceQuery := format.BuildQueryWithCustomFields(resolver, webhookQuery)
 	appendCustomQueryValues(serviceQuery, config.headers, config.extraData)
 	serviceURL.RawQuery = serviceQuery.Encode()
@@ -2,13 +2,15 @@ package googlechat
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Service providing Google Chat as a notification service.
@@ -27,21 +29,105 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return err
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Google Chat.
-func (service *Service) Send(message string, _ *types.Params) error {
-	config := service.config
+func (service *Service) Send(message string, params *types.Params) error {
+	if markup.FromParams(params, markup.Formats.Plain) == markup.Formats.Markdown {
+		message = markup.Slack(message)
+	}
 
-	jsonBody, err := json.Marshal(JSON{
+	return service.post(JSON{
 		Text: message,
 	})
+}
+
+// SendItems sends the items as a text message, with the fields of the items shown as key-values and the actions of
+// the items shown as buttons in a card
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	message := strings.TrimSuffix(types.ItemsToText(items), "\n")
+	if markup.FromParams(&params, markup.Formats.Plain) == markup.Formats.Markdown {
+		message = markup.Slack(message)
+	}
+
+	payload := JSON{Text: message}
+	fields, actions := types.ItemsFields(items), types.LinkActions(types.ItemsActions(items))
+	if len(fields) > 0 || len(actions) > 0 {
+		payload.CardsV2 = []card{createItemsCard(fields, actions)}
+	}
+
+	return service.post(payload)
+}
+
+// createItemsCard creates a card with a decorated text widget for each field, followed by a section with a button
+// for each action
+func createItemsCard(fields []types.Field, actions []types.Action) card {
+	var sections []cardSection
+
+	if len(fields) > 0 {
+		widgets := make([]widget, 0, len(fields))
+		for _, field := range fields {
+			widgets = append(widgets, widget{
+				DecoratedText: &decoratedText{
+					TopLabel: field.Key,
+					Text:     field.Value,
+				},
+			})
+		}
+		sections = append(sections, cardSection{Widgets: widgets})
+	}
+
+	if len(actions) > 0 {
+		buttons := make([]button, 0, len(actions))
+		for _, action := range actions {
+			buttons = append(buttons, button{
+				Text:    action.Label,
+				OnClick: onClick{OpenLink: openLink{URL: action.URL}},
+			})
+		}
+		sections = append(sections, cardSection{Widgets: []widget{{ButtonList: &buttonList{Buttons: buttons}}}})
+	}
+
+	return card{
+		CardID: "items",
+		Card: cardBody{
+			Sections: sections,
+		},
+	}
+}
+
+func (service *Service) post(payload JSON) error {
+	jsonBody, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	postURL := getAPIURL(config)
+	postURL := getAPIURL(service.config)
 
 	jsonBuffer := bytes.NewBuffer(jsonBody)
-	resp, err := http.Post(postURL.String(), "application/json", jsonBuffer)
+	resp, err := service.HTTPClient().Post(postURL.String(), "application/json", jsonBuffer)
 	if err != nil {
 		return fmt.Errorf("failed to send notification to Google Chat: %s", err)
 	}
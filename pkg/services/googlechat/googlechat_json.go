@@ -2,5 +2,46 @@ package googlechat
 
 // JSON is the actual payload being sent to the Google Chat API.
 type JSON struct {
-	Text string `json:"text"`
+	Text    string `json:"text"`
+	CardsV2 []card `json:"cardsV2,omitempty"`
+}
+
+type card struct {
+	CardID string   `json:"cardId"`
+	Card   cardBody `json:"card"`
+}
+
+type cardBody struct {
+	Sections []cardSection `json:"sections"`
+}
+
+type cardSection struct {
+	Widgets []widget `json:"widgets"`
+}
+
+type widget struct {
+	DecoratedText *decoratedText `json:"decoratedText,omitempty"`
+	ButtonList    *buttonList    `json:"buttonList,omitempty"`
+}
+
+type decoratedText struct {
+	TopLabel string `json:"topLabel,omitempty"`
+	Text     string `json:"text"`
+}
+
+type buttonList struct {
+	Buttons []button `json:"buttons"`
+}
+
+type button struct {
+	Text    string  `json:"text"`
+	OnClick onClick `json:"onClick"`
+}
+
+type onClick struct {
+	OpenLink openLink `json:"openLink"`
+}
+
+type openLink struct {
+	URL string `json:"url"`
 }
@@ -1,9 +1,12 @@
 package googlechat
 
 import (
+	"encoding/json"
+	"net/http"
 	"net/url"
 	"testing"
 
+	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/jarcoal/httpmock"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -68,6 +71,55 @@ var _ = Describe("Google Chat Service", func() {
 			err = service.Send("Message", nil)
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("should send the item fields as card key-values", func() {
+			serviceURL, _ := url.Parse("googlechat://chat.googleapis.com/v1/spaces/FOO/messages?key=bar&token=baz")
+			service := Service{}
+			Expect(service.Initialize(serviceURL, nil)).To(Succeed())
+
+			var sent JSON
+			httpmock.RegisterResponder("POST", "https://chat.googleapis.com/v1/spaces/FOO/messages?key=bar&token=baz", func(req *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+					return nil, err
+				}
+				return httpmock.NewStringResponse(200, ""), nil
+			})
+
+			item := types.MessageItem{Text: "Deploy failed"}
+			item.WithField("host", "web-1")
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+
+			Expect(sent.Text).To(Equal("Deploy failed"))
+			Expect(sent.CardsV2).To(HaveLen(1))
+			widgets := sent.CardsV2[0].Card.Sections[0].Widgets
+			Expect(widgets).To(HaveLen(1))
+			Expect(*widgets[0].DecoratedText).To(Equal(decoratedText{TopLabel: "host", Text: "web-1"}))
+		})
+		It("should send the item actions as card buttons", func() {
+			serviceURL, _ := url.Parse("googlechat://chat.googleapis.com/v1/spaces/FOO/messages?key=bar&token=baz")
+			service := Service{}
+			Expect(service.Initialize(serviceURL, nil)).To(Succeed())
+
+			var sent JSON
+			httpmock.RegisterResponder("POST", "https://chat.googleapis.com/v1/spaces/FOO/messages?key=bar&token=baz", func(req *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+					return nil, err
+				}
+				return httpmock.NewStringResponse(200, ""), nil
+			})
+
+			item := types.MessageItem{Text: "Deploy failed"}
+			item.WithAction(types.Action{Label: "Open runbook", URL: "https://example.com/runbook"})
+			item.WithAction(types.Action{Label: "Retry", URL: "https://ci.example.com/retry", Method: "POST"})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+
+			Expect(sent.CardsV2).To(HaveLen(1))
+			widgets := sent.CardsV2[0].Card.Sections[0].Widgets
+			Expect(widgets).To(HaveLen(1))
+			Expect(widgets[0].ButtonList.Buttons).To(Equal([]button{{
+				Text:    "Open runbook",
+				OnClick: onClick{OpenLink: openLink{URL: "https://example.com/runbook"}},
+			}}))
+		})
 
 	})
 })
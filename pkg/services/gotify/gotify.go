@@ -1,6 +1,7 @@
 package gotify
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
@@ -11,9 +12,24 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
 	"github.com/dockerutil/shoutrrr/pkg/util/jsonclient"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/netpolicy"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
+	"github.com/dockerutil/shoutrrr/pkg/util/tracing"
 )
 
+// priorities maps the service independent priorities to the gotify priorities.
+// A priority of 0 does not show a notification.
+var priorities = priority.Mapping{
+	priority.Priorities.Min:    "0",
+	priority.Priorities.Low:    "2",
+	priority.Priorities.Normal: "5",
+	priority.Priorities.High:   "8",
+	priority.Priorities.Urgent: "10",
+}
+
 // Service providing Gotify as a notification service
 type Service struct {
 	standard.Standard
@@ -25,28 +41,49 @@ type Service struct {
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
 func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) error {
-	service.Logger.SetLogger(logger)
-	service.config = &Config{
+	config := &Config{
 		Title: "Shoutrrr notification",
 	}
+	if err := config.SetURL(configURL); err != nil {
+		return err
+	}
+
+	return service.InitializeConfig(config, logger)
+}
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
 	service.pkr = format.NewPropKeyResolver(service.config)
-	err := service.config.SetURL(configURL)
 
 	service.httpClient = &http.Client{
-		Transport: &http.Transport{
+		Transport: tracing.Wrap(&http.Transport{
+			DialContext:     netpolicy.DialContext,
+			MaxConnsPerHost: httpclient.MaxConnsPerHost(),
 			TLSClientConfig: &tls.Config{
 				// If DisableTLS is specified, we might still need to disable TLS verification
 				// since the default configuration of Gotify redirects HTTP to HTTPS
 				// Note that this cannot be overridden using params, only using the config URL
 				InsecureSkipVerify: service.config.DisableTLS,
 			},
-		},
+		}),
 		// Set a reasonable timeout to prevent one bad transfer from block all subsequent ones
 		Timeout: 10 * time.Second,
 	}
 	service.client = jsonclient.NewWithHTTPClient(service.httpClient)
 
-	return err
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
 }
 
 const tokenChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.-_"
@@ -80,17 +117,31 @@ func buildURL(config *Config) (string, error) {
 	return fmt.Sprintf("%s://%s%s/message?token=%s", scheme, config.Host, config.Path, token), nil
 }
 
+// PriorityMapping returns the gotify priorities used for the service independent priorities
+func (service *Service) PriorityMapping() priority.Mapping {
+	return priorities
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	bound.client = jsonclient.NewWithHTTPClient(bound.ContextClient(service.httpClient))
+	return &bound
+}
+
 // Send a notification message to Gotify
 func (service *Service) Send(message string, params *types.Params) error {
 	if params == nil {
 		params = &types.Params{}
 	}
-	config := service.config
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
-		service.Logf("Failed to update params: %v", err)
+	params = priorities.Params(params)
+	config := *service.config
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+		service.Warn("Failed to update params", "error", err)
 	}
 
-	postURL, err := buildURL(config)
+	postURL, err := buildURL(&config)
 	if err != nil {
 		return err
 	}
@@ -100,6 +151,11 @@ func (service *Service) Send(message string, params *types.Params) error {
 		Title:    config.Title,
 		Priority: config.Priority,
 	}
+	if config.Format == markup.Formats.Markdown {
+		request.Extras = map[string]interface{}{
+			"client::display": map[string]string{"contentType": "text/markdown"},
+		}
+	}
 	response := &messageResponse{}
 	err = service.client.Post(postURL, request, response)
 	if err != nil {
@@ -6,19 +6,26 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
-
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Config for use within the gotify plugin
 type Config struct {
-	standard.EnumlessConfig
 	Token      string `url:"path2" desc:"Application token" required:""`
 	Host       string `url:"host,port" desc:"Server hostname (and optionally port)" required:""`
 	Path       string `optional:"" url:"path1" desc:"Server subpath"`
-	Priority   int    `key:"priority" default:"0"`
+	Priority   int    `key:"priority" default:"0" desc:"Message priority from 0 to 10. Also accepts the Min, Low, Normal, High and Urgent priorities"`
 	Title      string `key:"title" default:"Shoutrrr notification"`
 	DisableTLS bool   `key:"disabletls" default:"No"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
@@ -59,7 +66,7 @@ func (config *Config) setURL(resolver types.ConfigQueryResolver, url *url.URL) e
 	config.Host = url.Host
 	config.Token = path[tokenIndex:]
 
-	for key, vals := range url.Query() {
+	for key, vals := range priorities.Query(url.Query()) {
 		if err := resolver.Set(key, vals[0]); err != nil {
 			return err
 		}
@@ -4,9 +4,10 @@ import "fmt"
 
 // messageRequest is the actual payload being sent to the Gotify API
 type messageRequest struct {
-	Message  string `json:"message"`
-	Title    string `json:"title"`
-	Priority int    `json:"priority"`
+	Message  string                 `json:"message"`
+	Title    string                 `json:"title"`
+	Priority int                    `json:"priority"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
 }
 
 type messageResponse struct {
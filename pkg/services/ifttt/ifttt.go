@@ -2,14 +2,15 @@ package ifttt
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 const (
@@ -37,21 +38,50 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return nil
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to a IFTTT webhook
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
+	config := *service.config
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
 		return err
 	}
 
-	payload, err := createJSONToSend(config, message, params)
+	if config.Format == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
+
+	payload, err := createJSONToSend(&config, message, params)
 	fmt.Printf("%+v", payload)
 	if err != nil {
 		return err
 	}
 	for _, event := range config.Events {
 		apiURL := service.createAPIURLForEvent(event)
-		err := doSend(payload, apiURL)
+		err := service.doSend(payload, apiURL)
 		if err != nil {
 			return fmt.Errorf("failed to send IFTTT event \"%s\": %s", event, err)
 		}
@@ -68,8 +98,8 @@ func (service *Service) createAPIURLForEvent(event string) string {
 	)
 }
 
-func doSend(payload []byte, postURL string) error {
-	res, err := http.Post(postURL, "application/json", bytes.NewBuffer(payload))
+func (service *Service) doSend(payload []byte, postURL string) error {
+	res, err := service.HTTPClient().Post(postURL, "application/json", bytes.NewBuffer(payload))
 	if err != nil {
 		return err
 	}
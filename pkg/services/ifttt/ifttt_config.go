@@ -3,8 +3,8 @@ package ifttt
 import (
 	"errors"
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 	"net/url"
 )
 
@@ -15,7 +15,6 @@ const (
 
 // Config is the configuration needed to send IFTTT notifications
 type Config struct {
-	standard.EnumlessConfig
 	WebHookID         string   `url:"host" required:"true"`
 	Events            []string `key:"events" required:"true"`
 	Value1            string   `key:"value1" optional:""`
@@ -24,6 +23,15 @@ type Config struct {
 	UseMessageAsValue uint8    `key:"messagevalue" desc:"Sets the corresponding value field to the notification message" default:"2"`
 	UseTitleAsValue   uint8    `key:"titlevalue" desc:"Sets the corresponding value field to the notification title" default:"0"`
 	Title             string   `key:"title" default:"" desc:"Notification title, optionally set by the sender"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
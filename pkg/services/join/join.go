@@ -1,6 +1,7 @@
 package join
 
 import (
+	"context"
 	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 const (
@@ -23,6 +25,13 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Pushover
 func (service *Service) Send(message string, params *types.Params) error {
 	config := service.config
@@ -40,6 +49,10 @@ func (service *Service) Send(message string, params *types.Params) error {
 		icon = config.Icon
 	}
 
+	if markup.FromParams(params, config.Format) == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
+
 	devices := strings.Join(config.Devices, ",")
 
 	return service.sendToDevices(devices, message, title, icon)
@@ -68,7 +81,7 @@ func (service *Service) sendToDevices(devices string, message string, title stri
 
 	apiURL.RawQuery = data.Encode()
 
-	res, err := http.Post(
+	res, err := service.HTTPClient().Post(
 		apiURL.String(),
 		contentType,
 		nil)
@@ -95,3 +108,21 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 
 	return nil
 }
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
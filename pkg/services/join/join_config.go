@@ -4,6 +4,7 @@ import (
 	"errors"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 	"net/url"
 )
 
@@ -13,11 +14,15 @@ type Config struct {
 	Devices []string `key:"devices" desc:"Comma separated list of device IDs"`
 	Title   string   `key:"title" optional:"" desc:"If set creates a notification"`
 	Icon    string   `key:"icon" optional:"" desc:"Icon URL"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
 }
 
 // Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
 func (config *Config) Enums() map[string]types.EnumFormatter {
-	return map[string]types.EnumFormatter{}
+	return map[string]types.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
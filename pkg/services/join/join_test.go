@@ -107,9 +107,9 @@ var _ = Describe("the join config", func() {
 	})
 
 	When("listing the query fields", func() {
-		It("should return the keys \"devices\", \"icon\", \"title\" in alphabetical order", func() {
+		It("should return the keys \"devices\", \"format\", \"icon\", \"title\" in alphabetical order", func() {
 			fields := pkr.QueryFields()
-			Expect(fields).To(Equal([]string{"devices", "icon", "title"}))
+			Expect(fields).To(Equal([]string{"devices", "format", "icon", "title"}))
 		})
 	})
 
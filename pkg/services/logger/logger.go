@@ -7,6 +7,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Service is the Logger service struct
@@ -23,6 +24,9 @@ func (service *Service) Send(message string, params *types.Params) error {
 			data[key] = value
 		}
 	}
+	if markup.FromParams(params, markup.Formats.Plain) == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
 	data["message"] = message
 	return service.doSend(data)
 }
@@ -46,3 +50,20 @@ func (service *Service) Initialize(_ *url.URL, logger types.StdLogger) error {
 	service.config = &Config{}
 	return nil
 }
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
@@ -1,11 +1,14 @@
 package matrix
 
 import (
+	"context"
 	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 	"net/url"
+	"strings"
 )
 
 // Scheme is the identifying part of this service's configuration URL
@@ -21,14 +24,28 @@ type Service struct {
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
 func (s *Service) Initialize(configURL *url.URL, logger t.StdLogger) error {
-	s.SetLogger(logger)
-	s.config = &Config{}
+	config := &Config{}
 
-	s.pkr = format.NewPropKeyResolver(s.config)
-	if err := s.config.setURL(&s.pkr, configURL); err != nil {
+	pkr := format.NewPropKeyResolver(config)
+	if err := config.setURL(&pkr, configURL); err != nil {
 		return err
 	}
 
+	return s.InitializeConfig(config, logger)
+}
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service.
+// If the config contains a User, the service logs in using the Password.
+func (s *Service) InitializeConfig(config t.ServiceConfig, logger t.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	s.SetLogger(logger)
+	configCopy := *serviceConfig
+	s.config = &configCopy
+	s.pkr = format.NewPropKeyResolver(s.config)
+
 	s.client = newClient(s.config.Host, s.config.DisableTLS, logger)
 	if s.config.User != "" {
 		return s.client.login(s.config.User, s.config.Password)
@@ -38,21 +55,114 @@ func (s *Service) Initialize(configURL *url.URL, logger t.StdLogger) error {
 	return nil
 }
 
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (s *Service) WithContext(ctx context.Context) t.Service {
+	bound := *s
+	bound.SetContext(ctx)
+	if s.client != nil {
+		bound.client = s.client.withHTTPClient(bound.HTTPClient())
+	}
+	return &bound
+}
+
 // Send notification
 func (s *Service) Send(message string, params *t.Params) error {
+	_, err := s.send(message, nil, params)
+	return err
+}
+
+// SendRefs sends the message like Send, returning a reference with the room and event ID of the message in every room
+func (s *Service) SendRefs(message string, params *t.Params) ([]t.MessageRef, error) {
+	return s.send(message, nil, params)
+}
+
+// SendItems sends the items as a message, followed by a message sharing each of their attachments
+func (s *Service) SendItems(items []t.MessageItem, params t.Params) error {
+	message := strings.TrimSuffix(t.ItemsToPlain(items), "\n")
+	_, err := s.send(message, t.ItemsAttachments(items), &params)
+	return err
+}
+
+// Update edits the referenced message by sending a replacement event with the new content
+func (s *Service) Update(ref t.MessageRef, message string, params *t.Params) error {
 	config := *s.config
 	if err := s.pkr.UpdateConfigFromParams(&config, params); err != nil {
 		return err
 	}
 
-	errors := s.client.sendMessage(message, s.config.Rooms)
+	content := createTextRequest(message, &config)
+	// Clients that do not support edits show the fallback body, marked as an edit
+	request := content
+	request.Body = "* " + content.Body
+	if content.FormattedBody != "" {
+		request.FormattedBody = "* " + content.FormattedBody
+	}
+	request.NewContent = &content
+	request.RelatesTo = &relation{RelType: relTypeReplace, EventID: ref.ID}
+
+	if _, err := s.client.sendMessageToRoom(request, ref.Channel); err != nil {
+		return fmt.Errorf("failed to update message in room '%v': %w", ref.Channel, err)
+	}
+	return nil
+}
+
+// Delete redacts the referenced message
+func (s *Service) Delete(ref t.MessageRef) error {
+	if err := s.client.redactEvent(ref.Channel, ref.ID); err != nil {
+		return fmt.Errorf("failed to delete message in room '%v': %w", ref.Channel, err)
+	}
+	return nil
+}
+
+// MaxAttachmentSize returns 0, since the upload size is limited by the homeserver
+func (s *Service) MaxAttachmentSize() int64 {
+	return 0
+}
+
+func (s *Service) send(message string, attachments []t.Attachment, params *t.Params) ([]t.MessageRef, error) {
+	config := *s.config
+	if err := s.pkr.UpdateConfigFromParams(&config, params); err != nil {
+		return nil, err
+	}
+
+	var requests []apiReqSend
+	if message != "" || len(attachments) == 0 {
+		requests = append(requests, createTextRequest(message, &config))
+	}
+
+	// The files are uploaded once, and shared in all the rooms
+	for i := range attachments {
+		request, err := s.client.uploadFile(&attachments[i])
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	refs, errors := s.client.sendMessages(requests, config.Rooms)
 
 	if len(errors) > 0 {
 		for _, err := range errors {
-			s.Logf("error sending message: %w", err)
+			s.Error("Error sending message", "error", err)
 		}
-		return fmt.Errorf("%v error(s) sending message, with initial error: %v", len(errors), errors[0])
+		return refs, fmt.Errorf("%v error(s) sending message, with initial error: %v", len(errors), errors[0])
 	}
 
-	return nil
+	return refs, nil
+}
+
+// createTextRequest creates a text message, converting markdown to HTML if the format is Markdown
+func createTextRequest(message string, config *Config) apiReqSend {
+	request := apiReqSend{MsgType: msgTypeText, Body: message}
+	if config.Format == markup.Formats.Markdown {
+		request.Body = markup.Plain(message)
+		request.Format = formatHTML
+		request.FormattedBody = markup.HTML(message)
+	}
+	return request
 }
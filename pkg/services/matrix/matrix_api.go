@@ -8,13 +8,21 @@ const (
 	apiLogin       = "/_matrix/client/r0/login"
 	apiRoomJoin    = "/_matrix/client/r0/join/%s"
 	apiSendMessage = "/_matrix/client/r0/rooms/%s/send/m.room.message"
+	apiRedact      = "/_matrix/client/r0/rooms/%s/redact/%s/%s"
 	apiJoinedRooms = "/_matrix/client/r0/joined_rooms"
+	apiUpload      = "/_matrix/media/r0/upload"
 
 	contentType = "application/json"
 
 	accessTokenKey = "access_token"
 
 	msgTypeText       messageType    = "m.text"
+	msgTypeFile       messageType    = "m.file"
+	msgTypeImage      messageType    = "m.image"
+	msgTypeVideo      messageType    = "m.video"
+	msgTypeAudio      messageType    = "m.audio"
+	formatHTML                       = "org.matrix.custom.html"
+	relTypeReplace                   = "m.replace"
 	flowLoginPassword flowType       = "m.login.password"
 	idTypeUser        identifierType = "m.id.user"
 )
@@ -38,8 +46,34 @@ type apiResLogin struct {
 }
 
 type apiReqSend struct {
-	MsgType messageType `json:"msgtype"`
-	Body    string      `json:"body"`
+	MsgType       messageType `json:"msgtype"`
+	Body          string      `json:"body"`
+	Format        string      `json:"format,omitempty"`
+	FormattedBody string      `json:"formatted_body,omitempty"`
+	FileName      string      `json:"filename,omitempty"`
+	URL           string      `json:"url,omitempty"`
+	Info          *fileInfo   `json:"info,omitempty"`
+	NewContent    *apiReqSend `json:"m.new_content,omitempty"`
+	RelatesTo     *relation   `json:"m.relates_to,omitempty"`
+}
+
+// relation relates an event to another event, like an edit replacing the content of the original message
+type relation struct {
+	RelType string `json:"rel_type"`
+	EventID string `json:"event_id"`
+}
+
+type apiReqRedact struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+type fileInfo struct {
+	MimeType string `json:"mimetype"`
+	Size     int    `json:"size"`
+}
+
+type apiResUpload struct {
+	ContentURI string `json:"content_uri"`
 }
 
 type apiResRoom struct {
@@ -7,48 +7,61 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
+	"github.com/dockerutil/shoutrrr/pkg/util/logging"
 )
 
+// client is safe for concurrent use once logged in, since apiURL is never modified after it has been created
 type client struct {
 	apiURL      url.URL
 	accessToken string
-	logger      types.StdLogger
+	logger      types.Logger
+	httpClient  *http.Client
 }
 
 func newClient(host string, disableTLS bool, logger types.StdLogger) (c *client) {
+	if logger == nil {
+		logger = util.DiscardLogger
+	}
+
 	c = &client{
-		logger: logger,
+		logger:     logging.Leveled(logger),
+		httpClient: httpclient.Client,
 		apiURL: url.URL{
 			Host:   host,
 			Scheme: "https",
 		},
 	}
 
-	if c.logger == nil {
-		c.logger = util.DiscardLogger
-	}
-
 	if disableTLS {
 		c.apiURL.Scheme = c.apiURL.Scheme[:4]
 	}
 
-	c.logger.Printf("Using server: %v\n", c.apiURL.String())
+	c.logger.Debug("Using server", "url", c.apiURL.String())
 
 	return c
 }
 
+// withHTTPClient returns a copy of the client that makes its requests using httpClient
+func (c *client) withHTTPClient(httpClient *http.Client) *client {
+	copied := *c
+	copied.httpClient = httpClient
+	return &copied
+}
+
 func (c *client) useToken(token string) {
 	c.accessToken = token
-	c.updateAccessToken()
 }
 
 func (c *client) login(user string, password string) error {
-	c.apiURL.RawQuery = ""
-	defer c.updateAccessToken()
+	c.accessToken = ""
 
 	resLogin := apiResLoginFlows{}
 	if err := c.apiGet(apiLogin, &resLogin); err != nil {
@@ -59,7 +72,7 @@ func (c *client) login(user string, password string) error {
 	for _, flow := range resLogin.Flows {
 		flows = append(flows, string(flow.Type))
 		if flow.Type == flowLoginPassword {
-			c.logf("Using login flow '%v'", flow.Type)
+			c.logger.Debug("Using login flow", "flow", flow.Type)
 			return c.loginPassword(user, password)
 		}
 	}
@@ -83,25 +96,28 @@ func (c *client) loginPassword(user string, password string) error {
 		tokenHint = response.AccessToken[:3]
 	}
 
-	c.logf("AccessToken: %v...\n", tokenHint)
-	c.logf("HomeServer: %v\n", response.HomeServer)
-	c.logf("User: %v\n", response.UserID)
+	c.logger.Debug("Logged in", "accessToken", tokenHint+"...", "homeServer", response.HomeServer, "user", response.UserID)
 
 	return nil
 }
 
-func (c *client) sendMessage(message string, rooms []string) (errors []error) {
+// txnCounter makes the transaction IDs unique when several requests are sent within the same nanosecond
+var txnCounter atomic.Uint64
+
+// sendMessages sends the messages to each of the rooms, or to all joined rooms if no rooms are specified, returning
+// a reference with the room and event ID of every sent message
+func (c *client) sendMessages(messages []apiReqSend, rooms []string) (refs []types.MessageRef, errors []error) {
 	if len(rooms) > 0 {
-		return c.sendToExplicitRooms(rooms, message)
+		return c.sendToExplicitRooms(rooms, messages)
 	}
-	return c.sendToJoinedRooms(message)
+	return c.sendToJoinedRooms(messages)
 }
 
-func (c *client) sendToExplicitRooms(rooms []string, message string) (errors []error) {
+func (c *client) sendToExplicitRooms(rooms []string, messages []apiReqSend) (refs []types.MessageRef, errors []error) {
 	var err error
 
 	for _, room := range rooms {
-		c.logf("Sending message to '%v'...\n", room)
+		c.logger.Debug("Sending message", "room", room)
 
 		var roomID string
 		if roomID, err = c.joinRoom(room); err != nil {
@@ -110,32 +126,44 @@ func (c *client) sendToExplicitRooms(rooms []string, message string) (errors []e
 		}
 
 		if room != roomID {
-			c.logf("Resolved room alias '%v' to ID '%v'", room, roomID)
+			c.logger.Debug("Resolved room alias", "alias", room, "id", roomID)
 		}
 
-		if err := c.sendMessageToRoom(message, roomID); err != nil {
-			errors = append(errors, fmt.Errorf("failed to send message to room '%v': %w", roomID, err))
-		}
+		roomRefs, roomErrors := c.sendMessagesToRoom(messages, roomID)
+		refs = append(refs, roomRefs...)
+		errors = append(errors, roomErrors...)
 	}
 
-	return errors
+	return refs, errors
 }
 
-func (c *client) sendToJoinedRooms(message string) (errors []error) {
+func (c *client) sendToJoinedRooms(messages []apiReqSend) (refs []types.MessageRef, errors []error) {
 	joinedRooms, err := c.getJoinedRooms()
 	if err != nil {
-		return append(errors, fmt.Errorf("failed to get joined rooms: %w", err))
+		return nil, append(errors, fmt.Errorf("failed to get joined rooms: %w", err))
 	}
 
 	// Send to all rooms that are joined
 	for _, roomID := range joinedRooms {
-		c.logf("Sending message to '%v'...\n", roomID)
-		if err := c.sendMessageToRoom(message, roomID); err != nil {
+		c.logger.Debug("Sending message", "room", roomID)
+		roomRefs, roomErrors := c.sendMessagesToRoom(messages, roomID)
+		refs = append(refs, roomRefs...)
+		errors = append(errors, roomErrors...)
+	}
+
+	return refs, errors
+}
+
+func (c *client) sendMessagesToRoom(messages []apiReqSend, roomID string) (refs []types.MessageRef, errors []error) {
+	for _, message := range messages {
+		eventID, err := c.sendMessageToRoom(message, roomID)
+		if err != nil {
 			errors = append(errors, fmt.Errorf("failed to send message to room '%v': %w", roomID, err))
+			continue
 		}
+		refs = append(refs, types.MessageRef{Channel: roomID, ID: eventID})
 	}
-
-	return errors
+	return refs, errors
 }
 
 func (c *client) joinRoom(room string) (roomID string, err error) {
@@ -146,49 +174,71 @@ func (c *client) joinRoom(room string) (roomID string, err error) {
 	return resRoom.RoomID, nil
 }
 
-func (c *client) sendMessageToRoom(message string, roomID string) error {
+func (c *client) sendMessageToRoom(message apiReqSend, roomID string) (eventID string, err error) {
 	resEvent := apiResEvent{}
-	return c.apiPost(fmt.Sprintf(apiSendMessage, roomID), apiReqSend{
-		MsgType: msgTypeText,
-		Body:    message,
-	}, &resEvent)
+	if err = c.apiPost(fmt.Sprintf(apiSendMessage, roomID), message, &resEvent); err != nil {
+		return "", err
+	}
+	return resEvent.EventID, nil
 }
 
-func (c *client) apiGet(path string, response interface{}) error {
-	c.apiURL.Path = path
+// redactEvent removes the content of the event in the room
+func (c *client) redactEvent(roomID string, eventID string) error {
+	txnID := strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatUint(txnCounter.Add(1), 36)
+	resEvent := apiResEvent{}
+	return c.apiPut(fmt.Sprintf(apiRedact, roomID, eventID, txnID), apiReqRedact{}, &resEvent)
+}
 
-	var err error
-	var res *http.Response
-	res, err = http.Get(c.apiURL.String())
+// uploadFile uploads the attachment to the media repository, returning a message that shares it
+func (c *client) uploadFile(attachment *types.Attachment) (apiReqSend, error) {
+	contentType := attachment.ContentType()
+	uploadURL := c.buildURLWithQuery(apiUpload, url.Values{"filename": []string{attachment.Name}})
+
+	res, err := c.httpClient.Post(uploadURL, contentType, bytes.NewReader(attachment.Data))
 	if err != nil {
-		return err
+		return apiReqSend{}, err
 	}
 
-	var body []byte
-	defer res.Body.Close()
-	body, err = ioutil.ReadAll(res.Body)
+	response := apiResUpload{}
+	if err = parseResponse(res, &response); err != nil {
+		return apiReqSend{}, fmt.Errorf("failed to upload %q: %w", attachment.Name, err)
+	}
 
-	if res.StatusCode >= 400 {
-		resError := &apiResError{}
-		if err == nil {
-			if err = json.Unmarshal(body, resError); err == nil {
-				return resError
-			}
-		}
+	return apiReqSend{
+		MsgType:  fileMessageType(contentType),
+		Body:     attachment.Name,
+		FileName: attachment.Name,
+		URL:      response.ContentURI,
+		Info: &fileInfo{
+			MimeType: contentType,
+			Size:     len(attachment.Data),
+		},
+	}, nil
+}
 
-		return fmt.Errorf("got HTTP %v", res.Status)
+// fileMessageType returns the message type used to share files of contentType
+func fileMessageType(contentType string) messageType {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return msgTypeImage
+	case strings.HasPrefix(contentType, "video/"):
+		return msgTypeVideo
+	case strings.HasPrefix(contentType, "audio/"):
+		return msgTypeAudio
 	}
+	return msgTypeFile
+}
 
+func (c *client) apiGet(path string, response interface{}) error {
+	res, err := c.httpClient.Get(c.buildURL(path))
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(body, response)
+	return parseResponse(res, response)
 }
 
 func (c *client) apiPost(path string, request interface{}, response interface{}) error {
-	c.apiURL.Path = path
-
 	var err error
 	var body []byte
 
@@ -198,13 +248,38 @@ func (c *client) apiPost(path string, request interface{}, response interface{})
 	}
 
 	var res *http.Response
-	res, err = http.Post(c.apiURL.String(), contentType, bytes.NewReader(body))
+	res, err = c.httpClient.Post(c.buildURL(path), contentType, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 
+	return parseResponse(res, response)
+}
+
+func (c *client) apiPut(path string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.buildURL(path), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+
+	return parseResponse(res, response)
+}
+
+// parseResponse reads the API response into response, returning the API error if the request failed
+func parseResponse(res *http.Response, response interface{}) error {
 	defer res.Body.Close()
-	body, err = ioutil.ReadAll(res.Body)
+	body, err := ioutil.ReadAll(res.Body)
 
 	if res.StatusCode >= 400 {
 		resError := &apiResError{}
@@ -224,14 +299,20 @@ func (c *client) apiPost(path string, request interface{}, response interface{})
 	return json.Unmarshal(body, response)
 }
 
-func (c *client) updateAccessToken() {
-	query := c.apiURL.Query()
-	query.Set(accessTokenKey, c.accessToken)
-	c.apiURL.RawQuery = query.Encode()
+// buildURL returns the API URL for path, using a copy of apiURL to allow concurrent requests
+func (c *client) buildURL(path string) string {
+	return c.buildURLWithQuery(path, url.Values{})
 }
 
-func (c *client) logf(format string, v ...interface{}) {
-	c.logger.Printf(format, v...)
+// buildURLWithQuery returns the API URL for path with the query, adding the access token if set
+func (c *client) buildURLWithQuery(path string, query url.Values) string {
+	apiURL := c.apiURL
+	apiURL.Path = path
+	if c.accessToken != "" {
+		query.Set(accessTokenKey, c.accessToken)
+	}
+	apiURL.RawQuery = query.Encode()
+	return apiURL.String()
 }
 
 func (c *client) getJoinedRooms() ([]string, error) {
@@ -4,20 +4,27 @@ import (
 	"net/url"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Config is the configuration for the matrix service
 type Config struct {
-	standard.EnumlessConfig
-
 	User       string   `optional:"" url:"user" desc:"Username or empty when using access token"`
 	Password   string   `url:"password" desc:"Password or access token"`
 	DisableTLS bool     `key:"disableTLS" default:"No"`
 	Host       string   `url:"host"`
 	Rooms      []string `key:"rooms,room" optional:"" desc:"Room aliases, or with ! prefix, room IDs"`
 	Title      string   `key:"title" default:""`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]t.EnumFormatter {
+	return map[string]t.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
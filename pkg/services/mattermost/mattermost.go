@@ -2,6 +2,7 @@ package mattermost
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,6 +11,7 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Service sends notifications to a pre-configured channel or user
@@ -27,16 +29,41 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return service.config.setURL(&service.pkr, configURL)
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Mattermost
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
-	apiURL := buildURL(config)
+	config := *service.config
+	apiURL := buildURL(&config)
 
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
+	if err := service.pkr.UpdateConfigFromParams(&config, markup.WithoutParam(params)); err != nil {
 		return err
 	}
-	json, _ := CreateJSONPayload(config, message, params)
-	res, err := http.Post(apiURL, "application/json", bytes.NewReader(json))
+	json, _ := CreateJSONPayload(&config, message, params)
+	res, err := service.HTTPClient().Post(apiURL, "application/json", bytes.NewReader(json))
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,8 @@ package mattermost
 
 import (
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
 	"testing"
@@ -265,6 +267,21 @@ var _ = Describe("the mattermost service", func() {
 			err = service.Send("Message", nil)
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("should accept the format param and send the markdown unchanged", func() {
+			service := Service{}
+			err = service.Initialize(testutils.URLMust("mattermost://mattermost.host/token"), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			httpmock.RegisterResponder("POST", "https://mattermost.host/hooks/token", func(req *http.Request) (*http.Response, error) {
+				body, err := io.ReadAll(req.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(body)).To(ContainSubstring(`"text":"**bold**"`))
+				return httpmock.NewStringResponse(200, ``), nil
+			})
+
+			err = service.Send("**bold**", &types.Params{"format": "markdown"})
+			Expect(err).NotTo(HaveOccurred())
+		})
 	})
 
 	Describe("the basic service API", func() {
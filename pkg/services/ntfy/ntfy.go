@@ -2,7 +2,9 @@
 package ntfy
 
 import (
+	"context"
 	"fmt"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,6 +15,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Service sends notifications Ntfy
@@ -22,15 +25,63 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Ntfy
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
+	return service.send(message, nil, nil, params)
+}
+
+// SendItems sends the items as a plain text message. Since ntfy only supports a single attachment per message, each
+// attachment is uploaded in a separate message, with the first one containing the text.
+// The actions of the items are added as action buttons, with the ones exceeding the max count appended as links.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	message := strings.TrimSuffix(types.ItemsToPlain(types.ItemsWithoutActions(items)), "\n")
+	return service.send(message, types.ItemsAttachments(items), types.ItemsActions(items), &params)
+}
+
+// MaxAttachmentSize returns 0, since the attachment size limit is set by the server (15 MB for ntfy.sh)
+func (service *Service) MaxAttachmentSize() int64 {
+	return 0
+}
 
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
+func (service *Service) send(message string, attachments []types.Attachment, actions []types.Action, params *types.Params) error {
+	config := *service.config
+	params = priorities.Params(params)
+
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
 		return err
 	}
 
-	if err := service.sendAPI(config, message); err != nil {
+	if len(actions) > 0 {
+		var links []types.Action
+		var err error
+		if config.Actions, links, err = appendActions(config.Actions, actions); err != nil {
+			return fmt.Errorf("failed to send ntfy notification: %w", err)
+		}
+		if plain := types.ActionsToPlain(links); plain != "" {
+			message += "\n" + plain
+		}
+	}
+
+	var err error
+	if len(attachments) == 0 {
+		err = service.sendAPI(&config, message, nil)
+	}
+	for i := range attachments {
+		if err = service.sendAPI(&config, message, &attachments[i]); err != nil {
+			break
+		}
+		// Only the first message contains the text
+		message = ""
+	}
+
+	if err != nil {
 		return fmt.Errorf("failed to send ntfy notification: %w", err)
 	}
 
@@ -49,10 +100,29 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 
 }
 
-func (service *Service) sendAPI(config *Config, message string) error {
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// sendAPI publishes the message, or uploads the attachment with the message in the Message header if not nil
+func (service *Service) sendAPI(config *Config, message string, attachment *types.Attachment) error {
 	response := apiResponse{}
 	request := message
-	jsonClient := jsonclient.NewClient()
+	jsonClient := jsonclient.NewWithHTTPClient(service.HTTPClient())
 
 	headers := jsonClient.Headers()
 	headers.Del("Content-Type")
@@ -63,17 +133,28 @@ func (service *Service) sendAPI(config *Config, message string) error {
 	addHeaderIfNotEmpty(&headers, "Delay", config.Delay)
 	addHeaderIfNotEmpty(&headers, "Actions", strings.Join(config.Actions, ";"))
 	addHeaderIfNotEmpty(&headers, "Click", config.Click)
-	addHeaderIfNotEmpty(&headers, "Attach", config.Attach)
 	addHeaderIfNotEmpty(&headers, "X-Icon", config.Icon)
-	addHeaderIfNotEmpty(&headers, "Filename", config.Filename)
 	addHeaderIfNotEmpty(&headers, "Email", config.Email)
 
+	if attachment != nil {
+		// The request body is the file, so the message is sent using a header, which cannot contain newlines
+		request = string(attachment.Data)
+		addHeaderIfNotEmpty(&headers, "Filename", attachment.Name)
+		addHeaderIfNotEmpty(&headers, "Message", mime.BEncoding.Encode("utf-8", strings.ReplaceAll(message, "\n", `\n`)))
+	} else {
+		addHeaderIfNotEmpty(&headers, "Attach", config.Attach)
+		addHeaderIfNotEmpty(&headers, "Filename", config.Filename)
+	}
+
 	if !config.Cache {
 		headers.Add("Cache", "no")
 	}
 	if !config.Firebase {
 		headers.Add("Firebase", "no")
 	}
+	if config.Format == markup.Formats.Markdown {
+		headers.Add("Markdown", "yes")
+	}
 
 	if err := jsonClient.Post(config.GetAPIURL(), request, &response); err != nil {
 		if jsonClient.ErrorResponse(err, &response) {
@@ -0,0 +1,83 @@
+package ntfy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// maxActions is the max number of action buttons of a single notification
+const maxActions = 3
+
+// labelLineBreaks replaces the line breaks of action labels, which can not be sent in a header
+var labelLineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// appendActions returns the configured actions with the item actions appended in the ntfy short format, together
+// with the item actions that did not fit and have to be sent as links instead
+func appendActions(configured []string, actions []types.Action) (combined []string, rest []types.Action, err error) {
+	// Copy the configured actions, since the slice is shared with the service config
+	combined = append(make([]string, 0, maxActions), configured...)
+	for i, action := range actions {
+		if len(combined) >= maxActions {
+			return combined, actions[i:], nil
+		}
+		formatted, err := formatAction(&action)
+		if err != nil {
+			return nil, nil, err
+		}
+		combined = append(combined, formatted)
+	}
+	return combined, nil, nil
+}
+
+// formatAction returns the action in the ntfy short format, see https://docs.ntfy.sh/publish/#action-buttons
+func formatAction(action *types.Action) (string, error) {
+	values := []string{labelLineBreaks.Replace(action.Label), action.URL, action.Method, action.Body}
+	for _, value := range action.Headers {
+		values = append(values, value)
+	}
+	for _, value := range values {
+		if strings.Contains(value, "'") && strings.Contains(value, `"`) {
+			return "", fmt.Errorf("the action %q has a value with both single and double quotes, which ntfy can not parse", action.Label)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("the action %q has a value with a line break, which can not be sent in a header", action.Label)
+		}
+	}
+
+	label := quoteActionValue(labelLineBreaks.Replace(action.Label))
+	if !action.IsHTTP() {
+		return strings.Join([]string{"view", label, quoteActionValue(action.URL)}, ", "), nil
+	}
+
+	parts := []string{"http", label, quoteActionValue(action.URL)}
+	parts = append(parts, "method="+quoteActionValue(action.Method))
+
+	headers := make([]string, 0, len(action.Headers))
+	for header := range action.Headers {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		parts = append(parts, "headers."+header+"="+quoteActionValue(action.Headers[header]))
+	}
+
+	if action.Body != "" {
+		parts = append(parts, "body="+quoteActionValue(action.Body))
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// quoteActionValue quotes the value if it contains any of the separators used by the short format.
+// Values containing both single and double quotes can not be quoted, and are rejected by formatAction.
+func quoteActionValue(value string) string {
+	if !strings.ContainsAny(value, ",;=\"'") {
+		return value
+	}
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	return `"` + value + `"`
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Config for use within the ntfy service
@@ -17,7 +18,7 @@ type Config struct {
 	Username string   `url:"user"        optional:""         desc:"Auth username"`
 	Scheme   string   `key:"scheme"      default:"https"     desc:"Server protocol, http or https"`
 	Tags     []string `key:"tags"        optional:""         desc:"List of tags that may or not map to emojis"`
-	Priority priority `key:"priority"    default:"default"   desc:"Message priority with 1=min, 3=default and 5=max"`
+	Priority priority `key:"priority"    default:"default"   desc:"Message priority with 1=min, 3=default and 5=max. Also accepts the Normal and Urgent priorities"`
 	Actions  []string `key:"actions"     optional:"" sep:";" desc:"Custom user action buttons for notifications, see https://docs.ntfy.sh/publish/#action-buttons"`
 	Click    string   `key:"click"       optional:""         desc:"Website opened when notification is clicked"`
 	Attach   string   `key:"attach"      optional:""         desc:"URL of an attachment, see attach via URL"`
@@ -27,12 +28,15 @@ type Config struct {
 	Icon     string   `key:"icon"        optional:""         desc:"URL to use as notification icon"`
 	Cache    bool     `key:"cache"       default:"yes"       desc:"Cache messages"`
 	Firebase bool     `key:"firebase"    default:"yes"       desc:"Send to firebase"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
 }
 
 // Enums implements types.ServiceConfig
 func (*Config) Enums() map[string]types.EnumFormatter {
 	return map[string]types.EnumFormatter{
 		"Priority": Priority.Enum,
+		"Format":   markup.Formats.Enum,
 	}
 }
 
@@ -93,7 +97,7 @@ func (config *Config) setURL(resolver types.ConfigQueryResolver, url *url.URL) e
 	// Escape raw `;` in queries
 	url.RawQuery = strings.ReplaceAll(url.RawQuery, ";", "%3b")
 
-	for key, vals := range url.Query() {
+	for key, vals := range priorities.Query(url.Query()) {
 		if err := resolver.Set(key, vals[0]); err != nil {
 			return err
 		}
@@ -3,6 +3,7 @@ package ntfy
 import (
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	unified "github.com/dockerutil/shoutrrr/pkg/util/priority"
 )
 
 type priority int
@@ -44,3 +45,17 @@ var Priority = &priorityVals{
 func (p priority) String() string {
 	return Priority.Enum.Print(int(p))
 }
+
+// priorities maps the service independent priorities to the ntfy priorities
+var priorities = unified.Mapping{
+	unified.Priorities.Min:    "min",
+	unified.Priorities.Low:    "low",
+	unified.Priorities.Normal: "default",
+	unified.Priorities.High:   "high",
+	unified.Priorities.Urgent: "max",
+}
+
+// PriorityMapping returns the ntfy priorities used for the service independent priorities
+func (service *Service) PriorityMapping() unified.Mapping {
+	return priorities
+}
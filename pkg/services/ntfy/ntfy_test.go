@@ -3,7 +3,9 @@ package ntfy
 import (
 	"github.com/dockerutil/shoutrrr/internal/testutils"
 	"github.com/dockerutil/shoutrrr/pkg/format"
+	"github.com/dockerutil/shoutrrr/pkg/types"
 
+	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -115,6 +117,92 @@ var _ = Describe("the ntfy service", func() {
 
 			Expect(service.Send("Message", nil)).To(HaveOccurred())
 		})
+		It("should not keep the params in the service config", func() {
+			serviceURL := testutils.URLMust("ntfy://:devicekey@hostname?title=Default")
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+
+			titles := []string{}
+			httpmock.RegisterResponder("POST", service.config.GetAPIURL(), func(req *http.Request) (*http.Response, error) {
+				titles = append(titles, req.Header.Get("Title"))
+				return httpmock.NewJsonResponse(200, apiResponse{Code: http.StatusOK, Message: "OK"})
+			})
+
+			Expect(service.Send("Message", &types.Params{"title": "Custom"})).To(Succeed())
+			Expect(service.Send("Message", nil)).To(Succeed())
+			Expect(titles).To(Equal([]string{"Custom", "Default"}))
+			Expect(service.config.Title).To(Equal("Default"))
+		})
+		It("should upload each attachment as the body of a message", func() {
+			serviceURL := testutils.URLMust("ntfy://:devicekey@hostname?attach=https://example.com/file.txt")
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+
+			var uploads []http.Header
+			var bodies []string
+			httpmock.RegisterResponder("POST", service.config.GetAPIURL(), func(req *http.Request) (*http.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				uploads = append(uploads, req.Header)
+				bodies = append(bodies, string(body))
+				return httpmock.NewJsonResponse(200, apiResponse{Code: http.StatusOK, Message: "OK"})
+			})
+
+			item := types.MessageItem{Text: "Backup done\nsee the log"}
+			item.WithAttachment(types.Attachment{Name: "backup.log", Data: []byte("log content")})
+			item.WithAttachment(types.Attachment{Name: "db.log", Data: []byte("db content")})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+
+			Expect(bodies).To(Equal([]string{"log content", "db content"}))
+			Expect(uploads[0].Get("Message")).To(Equal(`Backup done\nsee the log`))
+			Expect(uploads[0].Get("Filename")).To(Equal("backup.log"))
+			Expect(uploads[0].Get("Attach")).To(BeEmpty())
+			Expect(uploads[1].Get("Message")).To(BeEmpty())
+			Expect(uploads[1].Get("Filename")).To(Equal("db.log"))
+		})
+		It("should add the item actions as action buttons", func() {
+			serviceURL := testutils.URLMust("ntfy://:devicekey@hostname?actions=view,Docs,https://example.com/docs")
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+
+			var actions, body string
+			httpmock.RegisterResponder("POST", service.config.GetAPIURL(), func(req *http.Request) (*http.Response, error) {
+				content, _ := io.ReadAll(req.Body)
+				actions, body = req.Header.Get("Actions"), string(content)
+				return httpmock.NewJsonResponse(200, apiResponse{Code: http.StatusOK, Message: "OK"})
+			})
+
+			item := types.MessageItem{Text: "Deploy failed"}
+			item.WithAction(types.Action{Label: "Open runbook", URL: "https://example.com/runbook?step=1"})
+			item.WithAction(types.Action{Label: "Retry", URL: "https://ci.example.com/retry", Method: "POST", Body: `{"job":1}`})
+			item.WithAction(types.Action{Label: "Dashboard", URL: "https://example.com/dashboard"})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+
+			Expect(actions).To(Equal("view,Docs,https://example.com/docs;" +
+				"view, Open runbook, 'https://example.com/runbook?step=1';" +
+				`http, Retry, https://ci.example.com/retry, method=POST, body='{"job":1}'`))
+			Expect(body).To(Equal("Deploy failed\nDashboard: https://example.com/dashboard"))
+			Expect(service.config.Actions).To(HaveLen(1))
+		})
+		It("should keep the quotes of action values and the label on a single line", func() {
+			formatted, err := formatAction(&types.Action{
+				Label: "Retry\r\nnow", URL: "https://ci.example.com/retry", Method: "POST", Body: `{"job":"it's"}`,
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(formatted).To(BeEmpty())
+
+			formatted, err = formatAction(&types.Action{Label: "Retry\r\nnow", URL: "https://example.com/?q='x'"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(formatted).To(Equal(`view, Retry now, "https://example.com/?q='x'"`))
+
+			_, err = formatAction(&types.Action{Label: "Retry", URL: "https://ci.example.com/retry", Body: "a\nb", Method: "POST"})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should not send items with actions that can not be formatted", func() {
+			serviceURL := testutils.URLMust("ntfy://:devicekey@hostname")
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+
+			item := types.MessageItem{Text: "Deploy failed"}
+			item.WithAction(types.Action{Label: "Retry", URL: "https://ci.example.com/retry", Method: "POST", Body: `{"msg":"it's"}`})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).NotTo(Succeed())
+			Expect(httpmock.GetTotalCallCount()).To(Equal(0))
+		})
 		It("should not panic if a communication error occurs", func() {
 			httpmock.DeactivateAndReset()
 			serviceURL := testutils.URLMust("ntfy://:devicekey@nonresolvablehostname")
@@ -131,8 +219,8 @@ var _ = Describe("the ntfy service", func() {
 
 				testutils.TestConfigSetDefaultValues(&Config{})
 
-				testutils.TestConfigGetEnumsCount(&Config{}, 1)
-				testutils.TestConfigGetFieldsCount(&Config{}, 15)
+				testutils.TestConfigGetEnumsCount(&Config{}, 2)
+				testutils.TestConfigGetFieldsCount(&Config{}, 16)
 			})
 		})
 		Describe("the service instance", func() {
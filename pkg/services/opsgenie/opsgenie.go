@@ -2,21 +2,34 @@ package opsgenie
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
 )
 
 const (
 	alertEndpointTemplate = "https://%s:%d/v2/alerts"
 )
 
+// priorities maps the service independent priorities to the OpsGenie priorities
+var priorities = priority.Mapping{
+	priority.Priorities.Min:    "P5",
+	priority.Priorities.Low:    "P4",
+	priority.Priorities.Normal: "P3",
+	priority.Priorities.High:   "P2",
+	priority.Priorities.Urgent: "P1",
+}
+
 // Service providing OpsGenie as a notification service
 type Service struct {
 	standard.Standard
@@ -38,7 +51,7 @@ func (service *Service) sendAlert(url string, apiKey string, payload AlertPayloa
 	}
 	req.Header.Add("Authorization", "GenieKey "+apiKey)
 	req.Header.Add("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := service.HTTPClient().Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to send notification to OpsGenie: %s", err)
 	}
@@ -63,6 +76,31 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return service.config.setURL(&service.pkr, configURL)
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to OpsGenie
 // See: https://docs.opsgenie.com/docs/alert-api#create-alert
 func (service *Service) Send(message string, params *types.Params) error {
@@ -75,10 +113,45 @@ func (service *Service) Send(message string, params *types.Params) error {
 	return service.sendAlert(endpointURL, config.APIKey, payload)
 }
 
+// SendItems sends the items as an alert, with the fields of the items added to the alert details and the actions of
+// the items appended as links
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	config := service.config
+	endpointURL := fmt.Sprintf(alertEndpointTemplate, config.Host, config.Port)
+	message := strings.TrimSuffix(types.ItemsToText(items), "\n")
+	if links := types.ActionsToPlain(types.ItemsActions(items)); links != "" {
+		message += "\n" + links
+	}
+	payload, err := service.newAlertPayload(message, &params)
+	if err != nil {
+		return err
+	}
+
+	if fields := types.ItemsFields(items); len(fields) > 0 {
+		// Copy the details to prevent the fields from being added to the config
+		details := make(map[string]string, len(payload.Details)+len(fields))
+		for key, value := range payload.Details {
+			details[key] = value
+		}
+		for _, field := range fields {
+			details[field.Key] = field.Value
+		}
+		payload.Details = details
+	}
+
+	return service.sendAlert(endpointURL, config.APIKey, payload)
+}
+
+// PriorityMapping returns the OpsGenie priorities used for the service independent priorities
+func (service *Service) PriorityMapping() priority.Mapping {
+	return priorities
+}
+
 func (service *Service) newAlertPayload(message string, params *types.Params) (AlertPayload, error) {
 	if params == nil {
 		params = &types.Params{}
 	}
+	params = priorities.Params(params)
 
 	// Defensive copy
 	payloadFields := *service.config
@@ -87,6 +160,10 @@ func (service *Service) newAlertPayload(message string, params *types.Params) (A
 		return AlertPayload{}, err
 	}
 
+	if payloadFields.Format == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
+
 	// Use `Message` for the title if available, or if the message is too long
 	// Use `Description` for the message in these scenarios
 	title := payloadFields.Title
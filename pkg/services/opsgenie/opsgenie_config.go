@@ -7,6 +7,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 const defaultPort = 443
@@ -25,15 +26,19 @@ type Config struct {
 	Details     map[string]string `key:"details" desc:"Map of key-value pairs to use as custom properties of the alert" optional:"true"`
 	Entity      string            `key:"entity" desc:"Entity field of the alert that is generally used to specify which domain the Source field of the alert" optional:"true"`
 	Source      string            `key:"source" desc:"Source field of the alert" optional:"true"`
-	Priority    string            `key:"priority" desc:"Priority level of the alert. Possible values are P1, P2, P3, P4 and P5" optional:"true"`
+	Priority    string            `key:"priority" desc:"Priority level of the alert. Possible values are P1, P2, P3, P4 and P5, or the Min, Low, Normal, High and Urgent priorities" optional:"true"`
 	Note        string            `key:"note" desc:"Additional note that will be added while creating the alert" optional:"true"`
 	User        string            `key:"user" desc:"Display name of the request owner" optional:"true"`
 	Title       string            `key:"title" default:"" desc:"notification title, optionally set by the sender"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
 }
 
 // Enums returns an empty map because the OpsGenie service doesn't use Enums
 func (config Config) Enums() map[string]types.EnumFormatter {
-	return map[string]types.EnumFormatter{}
+	return map[string]types.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL is the public version of getURL that creates a new PropKeyResolver when accessed from outside the package
@@ -82,7 +87,7 @@ func (config *Config) setURL(resolver types.ConfigQueryResolver, url *url.URL) e
 		config.Port = 443
 	}
 
-	for key, vals := range url.Query() {
+	for key, vals := range priorities.Query(url.Query()) {
 		if err := resolver.Set(key, vals[0]); err != nil {
 			return err
 		}
@@ -212,6 +212,20 @@ var _ = Describe("the OpsGenie service", func() {
 			})
 		})
 
+		When("sending items with fields", func() {
+			It("should add the fields to the details from the query parameters", func() {
+				checkRequest = func(body string, header http.Header) {
+					Expect(body).To(ContainSubstring(`"details":{"host":"web-1","queryKey1":"queryValue1","queryKey2":"overridden"}`))
+				}
+
+				item := types.MessageItem{Text: "An example alert message"}
+				item.WithField("host", "web-1").WithField("queryKey2", "overridden")
+				err := service.SendItems([]types.MessageItem{item}, types.Params{})
+				Expect(err).To(BeNil())
+				Expect(service.config.Details).To(HaveKeyWithValue("queryKey2", "queryValue2"))
+			})
+		})
+
 		When("sending two alerts", func() {
 			It("should not mix-up the runtime parameters and the query parameters", func() {
 				// Internally the opsgenie service copies runtime parameters into the config struct
@@ -1,11 +1,15 @@
 package pushbullet
 
 import (
+	"context"
 	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
 	"github.com/dockerutil/shoutrrr/pkg/util/jsonclient"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"net/http"
 	"net/url"
 )
 
@@ -23,20 +27,51 @@ type Service struct {
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
 func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) error {
-	service.Logger.SetLogger(logger)
-
-	service.config = &Config{}
-	service.pkr = format.NewPropKeyResolver(service.config)
-	if err := service.config.setURL(&service.pkr, configURL); err != nil {
+	config := &Config{}
+	pkr := format.NewPropKeyResolver(config)
+	if err := config.setURL(&pkr, configURL); err != nil {
 		return err
 	}
 
-	service.client = jsonclient.NewClient()
-	service.client.Headers().Set("Access-Token", service.config.Token)
+	return service.InitializeConfig(config, logger)
+}
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+
+	service.client = service.newClient(httpclient.Client)
 
 	return nil
 }
 
+// newClient returns a JSON client authorized using the config Token, making the requests using httpClient
+func (service *Service) newClient(httpClient *http.Client) jsonclient.Client {
+	client := jsonclient.NewWithHTTPClient(httpClient)
+	client.Headers().Set("Access-Token", service.config.Token)
+	return client
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	bound.client = bound.newClient(bound.HTTPClient())
+	return &bound
+}
+
 // Send a push notification via Pushbullet
 func (service *Service) Send(message string, params *types.Params) error {
 	config := *service.config
@@ -44,6 +79,10 @@ func (service *Service) Send(message string, params *types.Params) error {
 		return err
 	}
 
+	if config.Format == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
+
 	for _, target := range config.Targets {
 		if err := doSend(&config, target, message, service.client); err != nil {
 			return err
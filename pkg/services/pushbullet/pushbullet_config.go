@@ -5,18 +5,25 @@ import (
 	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 	"net/url"
 	"strings"
-
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 )
 
 // Config ...
 type Config struct {
-	standard.EnumlessConfig
 	Targets []string `url:"path"`
 	Token   string   `url:"host"`
 	Title   string   `key:"title" default:"Shoutrrr notification"`
+
+	Format markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Format": markup.Formats.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
@@ -1,8 +1,11 @@
 package pushover
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -10,13 +13,31 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
 )
 
 const (
 	hookURL     = "https://api.pushover.net/1/messages.json"
 	contentType = "application/x-www-form-urlencoded"
+	// maxImageSize is the max size of the image attached to a message
+	maxImageSize = 5 << 20
+	// maxLength is the max number of characters in a message
+	maxLength = 1024
 )
 
+// priorities maps the service independent priorities to the pushover priorities.
+// The emergency priority (2) is not used, since it requires the retry and expire params.
+var priorities = priority.Mapping{
+	priority.Priorities.Min:    "-2",
+	priority.Priorities.Low:    "-1",
+	priority.Priorities.Normal: "0",
+	priority.Priorities.High:   "1",
+	priority.Priorities.Urgent: "1",
+}
+
 // Service providing the notification service Pushover
 type Service struct {
 	standard.Standard
@@ -24,22 +45,85 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Pushover
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
+	return service.send(message, nil, params)
+}
+
+// SendItems sends the items as a plain text message. A single image can be attached to the message.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	attachments := types.ItemsAttachments(items)
+	if len(attachments) > 1 {
+		return fmt.Errorf("%w: pushover messages can only have a single attachment", types.ErrAttachmentsNotSupported)
+	}
+
+	var image *types.Attachment
+	if len(attachments) == 1 {
+		if image = &attachments[0]; !image.IsImage() {
+			return fmt.Errorf("%w: pushover only supports images, %q is %v", types.ErrAttachmentsNotSupported, image.Name, image.ContentType())
+		}
+	}
+
+	message := strings.TrimSuffix(types.ItemsToPlain(items), "\n")
+	return service.send(message, image, &params)
+}
+
+// PriorityMapping returns the pushover priorities used for the service independent priorities
+func (service *Service) PriorityMapping() priority.Mapping {
+	return priorities
+}
+
+// MaxAttachmentSize returns the max size of the image attached to a message
+func (service *Service) MaxAttachmentSize() int64 {
+	return maxImageSize
+}
+
+// MessageLimit returns the max length of a Pushover message
+func (service *Service) MessageLimit() types.MessageLimit {
+	return types.MessageLimit{ChunkSize: maxLength, TotalChunkSize: maxLength, ChunkCount: 1, Unit: types.RuneLength}
+}
+
+func (service *Service) send(message string, image *types.Attachment, params *types.Params) error {
+	config := *service.config
+	params = priorities.Params(params)
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+		return err
+	}
+
+	if config.Format == markup.Formats.Markdown {
+		message = markup.Plain(message)
+	}
+
+	messages, attachment, err := overflow.Apply(message, service.MessageLimit(), config.Overflow)
+	if err != nil {
 		return err
 	}
+	if attachment != nil {
+		return fmt.Errorf("%w: the %v overflow mode cannot be used", types.ErrAttachmentsNotSupported, config.Overflow)
+	}
 
 	device := strings.Join(config.Devices, ",")
-	if err := service.sendToDevice(device, message, config); err != nil {
-		return fmt.Errorf("failed to send notifications to pushover devices: %w", err)
+	for i, part := range messages {
+		// The image is only attached to the first part
+		if i > 0 {
+			image = nil
+		}
+		if err := service.sendToDevice(device, part, image, &config); err != nil {
+			return fmt.Errorf("failed to send notifications to pushover devices: %w", err)
+		}
 	}
 
 	return nil
 }
 
-func (service *Service) sendToDevice(device string, message string, config *Config) error {
+func (service *Service) sendToDevice(device string, message string, image *types.Attachment, config *Config) error {
 
 	data := url.Values{}
 	data.Set("device", device)
@@ -55,10 +139,16 @@ func (service *Service) sendToDevice(device string, message string, config *Conf
 		data.Set("priority", strconv.FormatInt(int64(config.Priority), 10))
 	}
 
-	res, err := http.Post(
-		hookURL,
-		contentType,
-		strings.NewReader(data.Encode()))
+	var res *http.Response
+	var err error
+	if image != nil {
+		res, err = service.postWithImage(data, image)
+	} else {
+		res, err = service.HTTPClient().Post(
+			hookURL,
+			contentType,
+			strings.NewReader(data.Encode()))
+	}
 
 	if err != nil {
 		return err
@@ -71,6 +161,25 @@ func (service *Service) sendToDevice(device string, message string, config *Conf
 	return nil
 }
 
+// postWithImage posts the data as a multipart form, since it's required to upload the image attachment
+func (service *Service) postWithImage(data url.Values, image *types.Attachment) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for key := range data {
+		if err := writer.WriteField(key, data.Get(key)); err != nil {
+			return nil, err
+		}
+	}
+	if err := util.WriteFormFile(writer, "attachment", image); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return service.HTTPClient().Post(hookURL, writer.FormDataContentType(), body)
+}
+
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
 func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) error {
 	service.Logger.SetLogger(logger)
@@ -82,3 +191,21 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 
 	return nil
 }
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
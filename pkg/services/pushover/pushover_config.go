@@ -4,6 +4,8 @@ import (
 	"errors"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 	"net/url"
 )
 
@@ -12,13 +14,19 @@ type Config struct {
 	Token    string   `url:"pass" desc:"API Token/Key"`
 	User     string   `url:"host" desc:"User Key"`
 	Devices  []string `key:"devices" optional:""`
-	Priority int8     `key:"priority" default:"0"`
+	Priority int8     `key:"priority" default:"0" desc:"Message priority from -2 to 1. Also accepts the Min, Low, Normal, High and Urgent priorities"`
 	Title    string   `key:"title" optional:""`
+
+	Format   markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+	Overflow overflow.Mode `key:"overflow" default:"Split" desc:"How to send messages longer than 1024 characters: Split, Truncate or Error"`
 }
 
 // Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
 func (config *Config) Enums() map[string]types.EnumFormatter {
-	return map[string]types.EnumFormatter{}
+	return map[string]types.EnumFormatter{
+		"Format":   markup.Formats.Enum,
+		"Overflow": overflow.Modes.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
@@ -45,7 +53,7 @@ func (config *Config) setURL(resolver types.ConfigQueryResolver, url *url.URL) e
 	config.User = url.Host
 	config.Token = password
 
-	for key, vals := range url.Query() {
+	for key, vals := range priorities.Query(url.Query()) {
 		if err := resolver.Set(key, vals[0]); err != nil {
 			return err
 		}
@@ -3,13 +3,18 @@ package pushover_test
 import (
 	"errors"
 	"log"
+	"mime/multipart"
+	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/dockerutil/shoutrrr/internal/testutils"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/pushover"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 	"github.com/jarcoal/httpmock"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -136,9 +141,9 @@ var _ = Describe("the pushover config", func() {
 	})
 
 	When("listing the query fields", func() {
-		It("should return the keys \"devices\",\"priority\",\"title\"", func() {
+		It("should return the keys \"devices\",\"format\",\"overflow\",\"priority\",\"title\"", func() {
 			fields := keyResolver.QueryFields()
-			Expect(fields).To(Equal([]string{"devices", "priority", "title"}))
+			Expect(fields).To(Equal([]string{"devices", "format", "overflow", "priority", "title"}))
 		})
 	})
 
@@ -173,6 +178,56 @@ var _ = Describe("the pushover config", func() {
 			err = service.Send("Message", nil)
 			Expect(err).To(HaveOccurred())
 		})
+		It("should upload an image attachment with the message", func() {
+			Expect(service.Initialize(testutils.URLMust("pushover://:apptoken@usertoken"), logger)).To(Succeed())
+
+			var form *multipart.Form
+			httpmock.RegisterResponder("POST", hookURL, func(req *http.Request) (*http.Response, error) {
+				if err := req.ParseMultipartForm(1 << 20); err != nil {
+					return nil, err
+				}
+				form = req.MultipartForm
+				return httpmock.NewStringResponse(200, ""), nil
+			})
+
+			item := types.MessageItem{Text: "Motion detected"}
+			item.WithAttachment(types.Attachment{Name: "camera.jpg", Data: []byte{0xff, 0xd8, 0xff}})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+			Expect(form.Value["message"]).To(Equal([]string{"Motion detected"}))
+			Expect(form.File["attachment"][0].Filename).To(Equal("camera.jpg"))
+		})
+		It("should split messages longer than 1024 characters", func() {
+			Expect(service.Initialize(testutils.URLMust("pushover://:apptoken@usertoken"), logger)).To(Succeed())
+
+			var messages []string
+			httpmock.RegisterResponder("POST", hookURL, func(req *http.Request) (*http.Response, error) {
+				if err := req.ParseForm(); err != nil {
+					return nil, err
+				}
+				messages = append(messages, req.PostForm.Get("message"))
+				return httpmock.NewStringResponse(200, ""), nil
+			})
+
+			message := strings.Repeat("The backup failed. ", 100)
+			Expect(service.Send(message, nil)).To(Succeed())
+			Expect(messages).To(HaveLen(2))
+			Expect(messages[0]).To(HaveSuffix("(1/2)"))
+			Expect(len([]rune(messages[0]))).To(BeNumerically("<=", 1024))
+
+			params := types.Params{"overflow": "error"}
+			Expect(service.Send(message, &params)).To(MatchError(overflow.ErrMessageTooLong))
+			Expect(messages).To(HaveLen(2))
+		})
+		It("should report that attachments other than a single image are not supported", func() {
+			Expect(service.Initialize(testutils.URLMust("pushover://:apptoken@usertoken"), logger)).To(Succeed())
+
+			item := types.MessageItem{Text: "Report"}
+			item.WithAttachment(types.Attachment{Name: "report.pdf", Data: []byte("%PDF")})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(MatchError(types.ErrAttachmentsNotSupported))
+
+			item.WithAttachment(types.Attachment{Name: "camera.jpg", Data: []byte{0xff, 0xd8, 0xff}})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(MatchError(types.ErrAttachmentsNotSupported))
+		})
 	})
 })
 
@@ -2,6 +2,7 @@ package rocketchat
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Service sends notifications to a pre-configured channel or user
@@ -28,14 +30,41 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return nil
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Rocket.chat
 func (service *Service) Send(message string, params *types.Params) error {
 	var res *http.Response
 	var err error
 	config := service.config
 	apiURL := buildURL(config)
+	if markup.FromParams(params, markup.Formats.Plain) == markup.Formats.Markdown {
+		message = markup.Slack(message)
+	}
 	json, _ := CreateJSONPayload(config, message, params)
-	res, err = http.Post(apiURL, "application/json", bytes.NewReader(json))
+	res, err = service.HTTPClient().Post(apiURL, "application/json", bytes.NewReader(json))
 	if err != nil {
 		return fmt.Errorf("Error while posting to URL: %w\nHOST: %s\nPORT: %s", err, config.Host, config.Port)
 	}
@@ -1,8 +1,10 @@
 package services_test
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"testing"
 
 	"github.com/dockerutil/shoutrrr/internal/testutils"
@@ -103,4 +105,54 @@ var _ = Describe("services", func() {
 		}
 	})
 
+	When("sending concurrently with different params", func() {
+
+		AfterEach(func() {
+			httpmock.DeactivateAndReset()
+		})
+
+		for key, configURL := range serviceURLs {
+
+			key := key
+			configURL := configURL
+
+			It("should not share state between the sends for "+key, func() {
+
+				if key == "smtp" {
+					Skip("smtp does not use HTTP and needs a specific test")
+				}
+				if key == "xmpp" {
+					Skip("not supported")
+				}
+
+				httpmock.Activate()
+				respStatus := http.StatusOK
+				if key == "discord" || key == "ifttt" {
+					respStatus = http.StatusNoContent
+				}
+				httpmock.RegisterNoResponder(httpmock.NewStringResponder(respStatus, serviceResponses[key]))
+
+				serviceRouter, _ := router.New(logger)
+				service, err := serviceRouter.Locate(configURL)
+				Expect(err).NotTo(HaveOccurred())
+
+				if mockService, ok := service.(testutils.MockClientService); ok {
+					httpmock.ActivateNonDefault(mockService.GetHTTPClient())
+				}
+
+				wg := sync.WaitGroup{}
+				for i := 0; i < 8; i++ {
+					wg.Add(1)
+					go func(title string) {
+						defer GinkgoRecover()
+						defer wg.Done()
+						Expect(service.Send("test", &types.Params{"title": title})).To(Succeed())
+					}(fmt.Sprintf("title %d", i))
+				}
+				wg.Wait()
+			})
+
+		}
+	})
+
 })
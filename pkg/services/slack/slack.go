@@ -2,13 +2,16 @@ package slack
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/util/jsonclient"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
@@ -22,31 +25,136 @@ type Service struct {
 }
 
 const (
-	apiPostMessage = "https://slack.com/api/chat.postMessage"
+	apiPostMessage   = "https://slack.com/api/chat.postMessage"
+	apiUpdateMessage = "https://slack.com/api/chat.update"
+	apiDeleteMessage = "https://slack.com/api/chat.delete"
+	// maxLength is the number of characters after which Slack truncates the text of a message
+	maxLength = 40000
 )
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Slack
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
+	_, err := service.send(message, nil, nil, nil, params)
+	return err
+}
+
+// SendRefs sends the message like Send, returning a reference with the channel and ts of the message.
+// Messages sent using a webhook return no references, since the webhook response does not identify the message.
+func (service *Service) SendRefs(message string, params *types.Params) ([]types.MessageRef, error) {
+	return service.send(message, nil, nil, nil, params)
+}
+
+// SendItems sends the items as a plain text message, with their fields as attachment fields and their actions as
+// buttons, uploading their attachments to the channel of the message. Uploading files requires an API token.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	message := strings.TrimSuffix(types.ItemsToText(items), "\n")
+	_, err := service.send(message, types.ItemsFields(items), types.ItemsActions(items), types.ItemsAttachments(items), &params)
+	return err
+}
 
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
+// Update replaces the text of the referenced message using chat.update, which requires an API token
+func (service *Service) Update(ref types.MessageRef, message string, params *types.Params) error {
+	config := *service.config
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
 		return err
 	}
+	if !config.Token.IsAPIToken() {
+		return fmt.Errorf("failed to update slack message: %w using a webhook, an API token is required", types.ErrUpdateNotSupported)
+	}
+	if overflow.Exceeds(message, service.MessageLimit()) {
+		return fmt.Errorf("failed to update slack message: %w", overflow.ErrMessageTooLong)
+	}
+
+	payload := createPayload(&config, message, nil, nil)
+	payload.Channel = ref.Channel
+	payload.TS = ref.ID
+	payload.ThreadTS = ""
+	if _, err := service.sendAPI(&config, apiUpdateMessage, payload); err != nil {
+		return fmt.Errorf("failed to update slack message: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the referenced message using chat.delete, which requires an API token
+func (service *Service) Delete(ref types.MessageRef) error {
+	if !service.config.Token.IsAPIToken() {
+		return fmt.Errorf("failed to delete slack message: %w using a webhook, an API token is required", types.ErrUpdateNotSupported)
+	}
 
-	payload := CreateJSONPayload(config, message)
+	payload := messageRefPayload{Channel: ref.Channel, TS: ref.ID}
+	if _, err := service.sendAPI(service.config, apiDeleteMessage, payload); err != nil {
+		return fmt.Errorf("failed to delete slack message: %w", err)
+	}
+	return nil
+}
+
+// MaxAttachmentSize returns the max size of files uploaded using the API
+func (service *Service) MaxAttachmentSize() int64 {
+	return maxFileSize
+}
 
-	var err error
-	if config.Token.IsAPIToken() {
-		err = service.sendAPI(config, payload)
-	} else {
-		err = service.sendWebhook(config, payload)
+func (service *Service) send(message string, fields []types.Field, actions []types.Action, attachments []types.Attachment, params *types.Params) ([]types.MessageRef, error) {
+	config := *service.config
+
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+		return nil, err
 	}
 
+	messages, attachment, err := overflow.Apply(message, service.MessageLimit(), config.Overflow)
 	if err != nil {
-		return fmt.Errorf("failed to send slack notification: %w", err)
+		return nil, fmt.Errorf("failed to send slack notification: %w", err)
+	}
+	if attachment != nil {
+		attachments = append([]types.Attachment{*attachment}, attachments...)
 	}
 
-	return nil
+	if len(attachments) > 0 && !config.Token.IsAPIToken() {
+		return nil, fmt.Errorf("failed to send slack notification: %w using a webhook, an API token is required", types.ErrAttachmentsNotSupported)
+	}
+
+	var refs []types.MessageRef
+	for i, part := range messages {
+		if i < len(messages)-1 {
+			// The fields, buttons and files are only added to the last part
+			refs, err = service.sendPayload(&config, createPayload(&config, part, nil, nil), nil, refs)
+		} else {
+			refs, err = service.sendPayload(&config, createPayload(&config, part, fields, actions), attachments, refs)
+		}
+		if err != nil {
+			return refs, fmt.Errorf("failed to send slack notification: %w", err)
+		}
+	}
+
+	return refs, nil
+}
+
+// sendPayload posts the payload, followed by the attachments, appending the reference of the message to refs
+func (service *Service) sendPayload(config *Config, payload MessagePayload, attachments []types.Attachment, refs []types.MessageRef) ([]types.MessageRef, error) {
+	if !config.Token.IsAPIToken() {
+		return refs, service.sendWebhook(config, payload)
+	}
+
+	response, err := service.sendAPI(config, apiPostMessage, payload)
+	if err != nil {
+		return refs, err
+	}
+	refs = append(refs, types.MessageRef{Channel: response.Channel, ID: response.TS})
+	if len(attachments) > 0 {
+		err = service.uploadFiles(config, attachments, response.Channel)
+	}
+	return refs, err
+}
+
+// MessageLimit returns the max length of a Slack message
+func (service *Service) MessageLimit() types.MessageLimit {
+	return types.MessageLimit{ChunkSize: maxLength, TotalChunkSize: maxLength, ChunkCount: 1, Unit: types.RuneLength}
 }
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
@@ -59,15 +167,42 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 
 }
 
-func (service *Service) sendAPI(config *Config, payload interface{}) error {
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+func (service *Service) sendAPI(config *Config, apiURL string, payload interface{}) (*APIResponse, error) {
 	response := APIResponse{}
-	jsonClient := jsonclient.NewClient()
+	jsonClient := jsonclient.NewWithHTTPClient(service.HTTPClient())
 	jsonClient.Headers().Set("Authorization", config.Token.Authorization())
 
-	if err := jsonClient.Post(apiPostMessage, payload, &response); err != nil {
-		return err
+	if err := jsonClient.Post(apiURL, payload, &response); err != nil {
+		return nil, err
+	}
+
+	if err := service.checkAPIResponse(&response); err != nil {
+		return nil, err
 	}
 
+	return &response, nil
+}
+
+// checkAPIResponse returns an error if the API call failed, and logs any warnings
+func (service *Service) checkAPIResponse(response *APIResponse) error {
 	if !response.Ok {
 		if response.Error != "" {
 			return fmt.Errorf("api response: %v", response.Error)
@@ -76,7 +211,7 @@ func (service *Service) sendAPI(config *Config, payload interface{}) error {
 	}
 
 	if response.Warning != "" {
-		service.Logger.Logf("Slack API warning: %q", response.Warning)
+		service.Warn("Slack API warning", "warning", response.Warning)
 	}
 
 	return nil
@@ -87,7 +222,7 @@ func (service *Service) sendWebhook(config *Config, payload interface{}) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
-	res, err := http.Post(config.Token.WebhookURL(), jsonclient.ContentType, bytes.NewBuffer(payloadBytes))
+	res, err := service.HTTPClient().Post(config.Token.WebhookURL(), jsonclient.ContentType, bytes.NewBuffer(payloadBytes))
 	if err != nil {
 		return fmt.Errorf("failed to invoke webhook: %w", err)
 	}
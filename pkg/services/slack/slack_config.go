@@ -4,13 +4,13 @@ import (
 	"net/url"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 )
 
 // Config for the slack service
 type Config struct {
-	standard.EnumlessConfig
 	BotName string `optional:"uses bot default" key:"botname,username" desc:"Bot name"`
 	Icon    string `key:"icon,icon_emoji,icon_url" default:"" optional:"" desc:"Use emoji or URL as icon (based on presence of http(s):// prefix)"`
 	Token   Token  `desc:"API Bot token" url:"user,pass"`
@@ -18,6 +18,17 @@ type Config struct {
 	Title   string `key:"title" optional:"omitted" desc:"Prepended text above the message"`
 	Channel string `url:"host" desc:"Channel to send messages to in Cxxxxxxxxxx format"`
 	ThreadTS string   `key:"thread_ts" optional:"" desc:"ts value of the parent message (to send message as reply in thread)"`
+
+	Format   markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+	Overflow overflow.Mode `key:"overflow" default:"Split" desc:"How to send messages longer than 40000 characters: Split, Truncate, Attach or Error"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Format":   markup.Formats.Enum,
+		"Overflow": overflow.Modes.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
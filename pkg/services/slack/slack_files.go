@@ -0,0 +1,89 @@
+package slack
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/jsonclient"
+)
+
+const (
+	apiGetUploadURL   = "https://slack.com/api/files.getUploadURLExternal"
+	apiCompleteUpload = "https://slack.com/api/files.completeUploadExternal"
+	// maxFileSize is the max size of files uploaded to Slack
+	maxFileSize = 1 << 30
+)
+
+type uploadURLResponse struct {
+	APIResponse
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+type completeUploadRequest struct {
+	Files     []uploadedFile `json:"files"`
+	ChannelID string         `json:"channel_id"`
+	ThreadTS  string         `json:"thread_ts,omitempty"`
+}
+
+type uploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+}
+
+// uploadFiles uploads the attachments and shares them in the channel identified by channelID, which has to be a
+// channel ID and not a name
+func (service *Service) uploadFiles(config *Config, attachments []types.Attachment, channelID string) error {
+	jsonClient := jsonclient.NewWithHTTPClient(service.HTTPClient())
+	jsonClient.Headers().Set("Authorization", config.Token.Authorization())
+
+	request := completeUploadRequest{
+		ChannelID: channelID,
+		ThreadTS:  config.ThreadTS,
+	}
+
+	for i := range attachments {
+		attachment := &attachments[i]
+		query := url.Values{
+			"filename": []string{attachment.Name},
+			"length":   []string{strconv.Itoa(len(attachment.Data))},
+		}
+
+		response := uploadURLResponse{}
+		if err := jsonClient.Get(apiGetUploadURL+"?"+query.Encode(), &response); err != nil {
+			return fmt.Errorf("failed to get upload URL for %q: %w", attachment.Name, err)
+		}
+		if err := service.checkAPIResponse(&response.APIResponse); err != nil {
+			return fmt.Errorf("failed to get upload URL for %q: %w", attachment.Name, err)
+		}
+
+		if err := service.uploadFile(response.UploadURL, attachment); err != nil {
+			return fmt.Errorf("failed to upload %q: %w", attachment.Name, err)
+		}
+
+		request.Files = append(request.Files, uploadedFile{ID: response.FileID, Title: attachment.Name})
+	}
+
+	response := APIResponse{}
+	if err := jsonClient.Post(apiCompleteUpload, request, &response); err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+	return service.checkAPIResponse(&response)
+}
+
+func (service *Service) uploadFile(uploadURL string, attachment *types.Attachment) error {
+	res, err := service.HTTPClient().Post(uploadURL, attachment.ContentType(), bytes.NewReader(attachment.Data))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("upload status: %v", res.Status)
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package slack
 import (
 	"regexp"
 	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // MessagePayload used within the Slack service
@@ -12,11 +15,18 @@ type MessagePayload struct {
 	Blocks      []block      `json:"blocks,omitempty"`
 	Attachments []attachment `json:"attachments,omitempty"`
 	ThreadTS    string       `json:"thread_ts,omitempty"`
+	TS          string       `json:"ts,omitempty"`
 	Channel     string       `json:"channel,omitempty"`
 	IconEmoji   string       `json:"icon_emoji,omitempty"`
 	IconURL     string       `json:"icon_url,omitempty"`
 }
 
+// messageRefPayload identifies a sent message in the API calls that only need the reference
+type messageRefPayload struct {
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
 var iconURLPattern = regexp.MustCompile(`https?://`)
 
 // SetIcon sets the appropriate icon field in the payload based on whether the input is a URL or not
@@ -44,26 +54,39 @@ type blockText struct {
 }
 
 type attachment struct {
-	Title    string        `json:"title,omitempty"`
-	Fallback string        `json:"fallback,omitempty"`
-	Text     string        `json:"text"`
-	Color    string        `json:"color,omitempty"`
-	Fields   []legacyField `json:"fields,omitempty"`
-	Footer   string        `json:"footer,omitempty"`
-	Time     int           `json:"ts,omitempty"`
+	Title    string         `json:"title,omitempty"`
+	Fallback string         `json:"fallback,omitempty"`
+	Text     string         `json:"text"`
+	Color    string         `json:"color,omitempty"`
+	Fields   []legacyField  `json:"fields,omitempty"`
+	Actions  []legacyButton `json:"actions,omitempty"`
+	Footer   string         `json:"footer,omitempty"`
+	Time     int            `json:"ts,omitempty"`
 }
 
+// maxShortFieldLength is the max length of the field values shown side by side
+const maxShortFieldLength = 40
+
 type legacyField struct {
 	Title string `json:"title"`
 	Value string `json:"value"`
 	Short bool   `json:"short,omitempty"`
 }
 
+// legacyButton is an attachment action that opens the URL
+type legacyButton struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
 // APIResponse is the default generic response message sent from the API
 type APIResponse struct {
 	Ok       bool   `json:"ok"`
 	Error    string `json:"error"`
 	Warning  string `json:"warning"`
+	Channel  string `json:"channel,omitempty"`
+	TS       string `json:"ts,omitempty"`
 	MetaData struct {
 		Warnings []string `json:"warnings"`
 	} `json:"response_metadata"`
@@ -71,24 +94,31 @@ type APIResponse struct {
 
 // CreateJSONPayload compatible with the slack post message API
 func CreateJSONPayload(config *Config, message string) interface{} {
+	return createPayload(config, message, nil, nil)
+}
+
+// createPayload creates the message payload, with the fields and the buttons of the actions rendered in an additional
+// attachment
+func createPayload(config *Config, message string, fields []types.Field, actions []types.Action) MessagePayload {
 
 	var atts []attachment
-	for i, line := range strings.Split(message, "\n") {
-		// When 100 attachments have been reached, append the remaining line to the last
-		// attachment to prevent reaching the slack API limit
-		if i >= 100 {
-			atts[len(atts)-1].Text += "\n" + line
-			continue
-		}
-		atts = append(atts, attachment{
-			Text:  line,
+	if config.Format == markup.Formats.Markdown {
+		// Converted markdown is sent as a single attachment, since code blocks and lists can span multiple lines
+		atts = []attachment{{
+			Text:  markup.Slack(message),
 			Color: config.Color,
-		})
+		}}
+	} else {
+		atts = createLineAttachments(config, message)
 	}
 
-	// Remove last attachment if empty
-	if atts[len(atts)-1].Text == "" {
-		atts = atts[:len(atts)-1]
+	buttons := createLegacyButtons(actions)
+	if len(fields) > 0 || len(buttons) > 0 {
+		atts = append(atts, attachment{
+			Color:   config.Color,
+			Fields:  createLegacyFields(fields),
+			Actions: buttons,
+		})
 	}
 
 	payload := MessagePayload{
@@ -106,3 +136,49 @@ func CreateJSONPayload(config *Config, message string) interface{} {
 
 	return payload
 }
+
+// createLegacyFields converts the fields to attachment fields, showing the short values side by side
+func createLegacyFields(fields []types.Field) []legacyField {
+	legacyFields := make([]legacyField, 0, len(fields))
+	for _, field := range fields {
+		legacyFields = append(legacyFields, legacyField{
+			Title: field.Key,
+			Value: field.Value,
+			Short: len(field.Value) <= maxShortFieldLength && !strings.Contains(field.Value, "\n"),
+		})
+	}
+	return legacyFields
+}
+
+// createLegacyButtons converts the actions that open a URL to attachment buttons
+func createLegacyButtons(actions []types.Action) []legacyButton {
+	var buttons []legacyButton
+	for _, action := range types.LinkActions(actions) {
+		buttons = append(buttons, legacyButton{Type: "button", Text: action.Label, URL: action.URL})
+	}
+	return buttons
+}
+
+// createLineAttachments creates an attachment for each line of message
+func createLineAttachments(config *Config, message string) []attachment {
+	var atts []attachment
+	for i, line := range strings.Split(message, "\n") {
+		// When 100 attachments have been reached, append the remaining line to the last
+		// attachment to prevent reaching the slack API limit
+		if i >= 100 {
+			atts[len(atts)-1].Text += "\n" + line
+			continue
+		}
+		atts = append(atts, attachment{
+			Text:  line,
+			Color: config.Color,
+		})
+	}
+
+	// Remove last attachment if empty
+	if atts[len(atts)-1].Text == "" {
+		atts = atts[:len(atts)-1]
+	}
+
+	return atts
+}
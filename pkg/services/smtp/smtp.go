@@ -1,40 +1,49 @@
 package smtp
 
 import (
+	"context"
 	"crypto/tls"
+	"encoding/base64"
 	"fmt"
+	"html"
 	"io"
 	"math/rand"
+	"mime"
 	"net"
 	"net/smtp"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/netpolicy"
 )
 
 // Service sends notifications to a given e-mail addresses via SMTP
 type Service struct {
 	standard.Standard
 	standard.Templater
-	config            *Config
-	multipartBoundary string
-	propKeyResolver   format.PropKeyResolver
+	config          *Config
+	propKeyResolver format.PropKeyResolver
 }
 
 const (
 	contentHTML      = "text/html; charset=\"UTF-8\""
 	contentPlain     = "text/plain; charset=\"UTF-8\""
 	contentMultipart = "multipart/alternative; boundary=%s"
+	contentMixed     = "multipart/mixed; boundary=%s"
+	// base64LineLength is the max length of the lines of base64 encoded attachments
+	base64LineLength = 76
 )
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
 func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) error {
-	service.Logger.SetLogger(logger)
-	service.config = &Config{
+	config := &Config{
 		Port:        25,
 		ToAddresses: nil,
 		Subject:     "",
@@ -45,12 +54,26 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 		ClientHost:  "localhost",
 	}
 
-	pkr := format.NewPropKeyResolver(service.config)
+	pkr := format.NewPropKeyResolver(config)
 
-	if err := service.config.setURL(&pkr, configURL); err != nil {
+	if err := config.setURL(&pkr, configURL); err != nil {
 		return err
 	}
 
+	return service.InitializeConfig(config, logger)
+}
+
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service.
+// If Auth is Unknown, Plain is used if a Username is set, and None otherwise.
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := serviceConfig.Clone()
+	service.config = &configCopy
+
 	if service.config.Auth == AuthTypes.Unknown {
 		if service.config.Username != "" {
 			service.config.Auth = AuthTypes.Plain
@@ -59,43 +82,75 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 		}
 	}
 
-	service.propKeyResolver = pkr
+	service.propKeyResolver = format.NewPropKeyResolver(service.config)
 
 	return nil
 }
 
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to e-mail recipients
 func (service *Service) Send(message string, params *types.Params) error {
+	return service.send(message, nil, nil, params)
+}
+
+// SendItems sends the items as a text message, with their attachments as MIME parts. The fields of the items are
+// rendered as a table when using HTML, and as "key: value" lines otherwise. The actions are appended as links.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	message := strings.TrimSuffix(types.ItemsToText(items), "\n")
+	if links := types.ActionsToPlain(types.ItemsActions(items)); links != "" {
+		message += "\n" + links
+	}
+	return service.send(message, types.ItemsFields(items), types.ItemsAttachments(items), &params)
+}
+
+// MaxAttachmentSize returns 0, since the size of mails is limited by the server
+func (service *Service) MaxAttachmentSize() int64 {
+	return 0
+}
+
+func (service *Service) send(message string, fields []types.Field, attachments []types.Attachment, params *types.Params) error {
 	config := service.config.Clone()
 	if err := service.propKeyResolver.UpdateConfigFromParams(&config, params); err != nil {
 		return fail(FailApplySendParams, err)
 	}
 
-	client, err := getClientConnection(service.config)
+	client, err := getClientConnection(service.Context(), &config)
 	if err != nil {
 		return fail(FailGetSMTPClient, err)
 	}
 
-	return service.doSend(client, message, &config)
+	return service.doSend(client, message, fields, attachments, &config)
 }
 
-func getClientConnection(config *Config) (*smtp.Client, error) {
+func getClientConnection(ctx context.Context, config *Config) (*smtp.Client, error) {
 
-	var conn net.Conn
-	var err error
+	addr := net.JoinHostPort(config.Host, strconv.FormatUint(uint64(config.Port), 10))
 
-	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
+	conn, err := netpolicy.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fail(FailConnectToServer, err)
+	}
 
 	if useImplicitTLS(config.Encryption, config.Port) {
-		conn, err = tls.Dial("tcp", addr, &tls.Config{
+		tlsConn := tls.Client(conn, &tls.Config{
 			ServerName: config.Host,
 		})
-	} else {
-		conn, err = net.Dial("tcp", addr)
-	}
-
-	if err != nil {
-		return nil, fail(FailConnectToServer, err)
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return nil, fail(FailConnectToServer, err)
+		}
+		conn = tlsConn
 	}
 
 	client, err := smtp.NewClient(conn, config.Host)
@@ -106,7 +161,7 @@ func getClientConnection(config *Config) (*smtp.Client, error) {
 	return client, nil
 }
 
-func (service *Service) doSend(client *smtp.Client, message string, config *Config) failure {
+func (service *Service) doSend(client *smtp.Client, message string, fields []types.Field, attachments []types.Attachment, config *Config) failure {
 
 	config.FixEmailTags()
 
@@ -116,13 +171,20 @@ func (service *Service) doSend(client *smtp.Client, message string, config *Conf
 		return fail(FailHandshake, err)
 	}
 
-	if config.UseHTML {
-		service.multipartBoundary = fmt.Sprintf("%x", rand.Int63())
+	// Markdown messages are sent using both a plain text and a HTML part
+	if config.Format == markup.Formats.Markdown {
+		config.UseHTML = true
+	}
+
+	// The boundary is kept per send, since the service can be used for concurrent sends
+	boundary := ""
+	if config.UseHTML || len(attachments) > 0 {
+		boundary = fmt.Sprintf("%x", rand.Int63())
 	}
 
 	if config.UseStartTLS && !useImplicitTLS(config.Encryption, config.Port) {
 		if supported, _ := client.Extension("StartTLS"); !supported {
-			service.Logf("Warning: StartTLS enabled, but server did not report support for it. Connection is NOT encrypted")
+			service.Warn("StartTLS enabled, but server did not report support for it. Connection is NOT encrypted")
 		} else {
 			if err := client.StartTLS(&tls.Config{
 				ServerName: config.Host,
@@ -142,12 +204,12 @@ func (service *Service) doSend(client *smtp.Client, message string, config *Conf
 
 	for _, toAddress := range config.ToAddresses {
 
-		err := service.sendToRecipient(client, toAddress, config, message)
+		err := service.sendToRecipient(client, toAddress, config, message, fields, attachments, boundary)
 		if err != nil {
 			return fail(FailSendRecipient, err)
 		}
 
-		service.Logf("Mail successfully sent to \"%s\"!\n", toAddress)
+		service.Info("Mail successfully sent", "to", toAddress)
 	}
 
 	// Send the QUIT command and close the connection.
@@ -166,7 +228,7 @@ func (service *Service) resolveClientHost(config *Config) string {
 
 	hostname, err := os.Hostname()
 	if err != nil {
-		service.Logf("Failed to get hostname, falling back to localhost: %v", err)
+		service.Warn("Failed to get hostname, falling back to localhost", "error", err)
 		return "localhost"
 	}
 
@@ -190,7 +252,7 @@ func (service *Service) getAuth(config *Config) (smtp.Auth, failure) {
 
 }
 
-func (service *Service) sendToRecipient(client *smtp.Client, toAddress string, config *Config, message string) failure {
+func (service *Service) sendToRecipient(client *smtp.Client, toAddress string, config *Config, message string, fields []types.Field, attachments []types.Attachment, boundary string) failure {
 
 	// Set the sender and recipient first
 	if err := client.Mail(config.FromAddress); err != nil {
@@ -206,21 +268,44 @@ func (service *Service) sendToRecipient(client *smtp.Client, toAddress string, c
 		return fail(FailOpenDataStream, err)
 	}
 
-	if err := writeHeaders(wc, service.getHeaders(toAddress, config.Subject)); err != nil {
+	headers := getHeaders(config, toAddress, boundary)
+	mixedBoundary := "mixed-" + boundary
+	if len(attachments) > 0 {
+		// The message body becomes the first part of the mixed content, followed by the attachments
+		bodyContentType := headers["Content-Type"]
+		headers["Content-Type"] = fmt.Sprintf(contentMixed, mixedBoundary)
+		if err := writeHeaders(wc, headers); err != nil {
+			return err
+		}
+		if err := writeMultipartHeader(wc, mixedBoundary, bodyContentType); err != nil {
+			return fail(FailWriteHeaders, err)
+		}
+	} else if err := writeHeaders(wc, headers); err != nil {
 		return err
 	}
 
 	var ferr failure
 	if config.UseHTML {
-		ferr = service.writeMultipartMessage(wc, message)
+		ferr = service.writeMultipartMessage(wc, message, fields, boundary, config.Format)
 	} else {
-		ferr = service.writeMessagePart(wc, message, "plain")
+		ferr = service.writeMessagePart(wc, appendPlainFields(message, fields), "plain")
 	}
 
 	if ferr != nil {
 		return ferr
 	}
 
+	if len(attachments) > 0 {
+		for i := range attachments {
+			if err := writeAttachment(wc, mixedBoundary, &attachments[i]); err != nil {
+				return fail(FailAttachment, err, attachments[i].Name)
+			}
+		}
+		if err := writeMultipartHeader(wc, mixedBoundary, ""); err != nil {
+			return fail(FailMultiEndHeader, err)
+		}
+	}
+
 	if err = wc.Close(); err != nil {
 		return fail(FailCloseDataStream, err)
 	}
@@ -228,18 +313,16 @@ func (service *Service) sendToRecipient(client *smtp.Client, toAddress string, c
 	return nil
 }
 
-func (service *Service) getHeaders(toAddress string, subject string) map[string]string {
-	conf := service.config
-
+func getHeaders(conf *Config, toAddress string, boundary string) map[string]string {
 	var contentType string
 	if conf.UseHTML {
-		contentType = fmt.Sprintf(contentMultipart, service.multipartBoundary)
+		contentType = fmt.Sprintf(contentMultipart, boundary)
 	} else {
 		contentType = contentPlain
 	}
 
 	return map[string]string{
-		"Subject":      subject,
+		"Subject":      conf.Subject,
 		"Date":         time.Now().Format(time.RFC1123Z),
 		"To":           toAddress,
 		"From":         fmt.Sprintf("%s <%s>", conf.FromName, conf.FromAddress),
@@ -248,23 +331,29 @@ func (service *Service) getHeaders(toAddress string, subject string) map[string]
 	}
 }
 
-func (service *Service) writeMultipartMessage(wc io.WriteCloser, message string) failure {
+func (service *Service) writeMultipartMessage(wc io.WriteCloser, message string, fields []types.Field, boundary string, format markup.Format) failure {
+	plainMessage, htmlMessage := message, message
+	if format == markup.Formats.Markdown {
+		plainMessage, htmlMessage = markup.Plain(message), markup.HTML(message)
+	}
+	plainMessage = appendPlainFields(plainMessage, fields)
+	htmlMessage = appendHTMLFields(htmlMessage, fields)
 
-	if err := writeMultipartHeader(wc, service.multipartBoundary, contentPlain); err != nil {
+	if err := writeMultipartHeader(wc, boundary, contentPlain); err != nil {
 		return fail(FailPlainHeader, err)
 	}
-	if err := service.writeMessagePart(wc, message, "plain"); err != nil {
+	if err := service.writeMessagePart(wc, plainMessage, "plain"); err != nil {
 		return err
 	}
 
-	if err := writeMultipartHeader(wc, service.multipartBoundary, contentHTML); err != nil {
+	if err := writeMultipartHeader(wc, boundary, contentHTML); err != nil {
 		return fail(FailHTMLHeader, err)
 	}
-	if err := service.writeMessagePart(wc, message, "HTML"); err != nil {
+	if err := service.writeMessagePart(wc, htmlMessage, "HTML"); err != nil {
 		return err
 	}
 
-	if err := writeMultipartHeader(wc, service.multipartBoundary, ""); err != nil {
+	if err := writeMultipartHeader(wc, boundary, ""); err != nil {
 		return fail(FailMultiEndHeader, err)
 
 	}
@@ -272,6 +361,30 @@ func (service *Service) writeMultipartMessage(wc io.WriteCloser, message string)
 	return nil
 }
 
+// appendPlainFields appends the fields to message as "key: value" lines
+func appendPlainFields(message string, fields []types.Field) string {
+	if len(fields) == 0 {
+		return message
+	}
+	return message + "\n" + types.FieldsToPlain(fields)
+}
+
+// appendHTMLFields appends the fields to message as a table with a row for each field
+func appendHTMLFields(message string, fields []types.Field) string {
+	if len(fields) == 0 {
+		return message
+	}
+
+	builder := strings.Builder{}
+	builder.WriteString(message)
+	builder.WriteString("\n<table>\n")
+	for _, field := range fields {
+		fmt.Fprintf(&builder, "<tr><th align=\"left\">%s</th><td>%s</td></tr>\n", html.EscapeString(field.Key), html.EscapeString(field.Value))
+	}
+	builder.WriteString("</table>")
+	return builder.String()
+}
+
 func (service *Service) writeMessagePart(wc io.WriteCloser, message string, template string) failure {
 	if tpl, found := service.GetTemplate(template); found {
 		data := make(map[string]string)
@@ -306,6 +419,28 @@ func writeMultipartHeader(wc io.WriteCloser, boundary string, contentType string
 	return nil
 }
 
+// writeAttachment writes the attachment as a base64 encoded part of the mixed content
+func writeAttachment(wc io.WriteCloser, boundary string, attachment *types.Attachment) error {
+	name := mime.QEncoding.Encode("utf-8", attachment.Name)
+	name = strings.ReplaceAll(strings.ReplaceAll(name, `\`, `\\`), `"`, `\"`)
+	if _, err := fmt.Fprintf(wc, "\n\n--%s\n", boundary); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(wc, "Content-Type: %s; name=\"%s\"\nContent-Disposition: attachment; filename=\"%s\"\n"+
+		"Content-Transfer-Encoding: base64\n\n", attachment.ContentType(), name, name); err != nil {
+		return err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for start := 0; start < len(encoded); start += base64LineLength {
+		end := min(start+base64LineLength, len(encoded))
+		if _, err := fmt.Fprintf(wc, "%s\n", encoded[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func writeHeaders(wc io.WriteCloser, headers map[string]string) failure {
 	for key, val := range headers {
 		if _, err := fmt.Fprintf(wc, "%s: %s\n", key, val); err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Config is the configuration needed to send e-mail notifications over SMTP
@@ -27,6 +28,8 @@ type Config struct {
 	UseStartTLS bool      `desc:"Whether to use StartTLS encryption" default:"Yes" key:"usestarttls,starttls"`
 	UseHTML     bool      `desc:"Whether the message being sent is in HTML" default:"No" key:"usehtml"`
 	ClientHost  string    `desc:"The client host name sent to the SMTP server during HELLO phase. If set to \"auto\" it will use the OS hostname" key:"clienthost" default:"localhost"`
+
+	Format markup.Format `desc:"The markup used by the message, converted to HTML if Markdown" default:"Plain" key:"format"`
 }
 
 // GetURL returns a URL representation of it's current field values
@@ -104,6 +107,7 @@ func (config Config) Enums() map[string]types.EnumFormatter {
 	return map[string]types.EnumFormatter{
 		"Auth":       AuthTypes.Enum,
 		"Encryption": EncMethods.Enum,
+		"Format":     markup.Formats.Enum,
 	}
 }
 
@@ -1,11 +1,18 @@
 package smtp
 
 import (
+	"bytes"
+	"encoding/base64"
+	"io"
 	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
 	"net/smtp"
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"unsafe"
 
@@ -14,6 +21,7 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 
 	gt "github.com/onsi/gomega/types"
 
@@ -61,6 +69,26 @@ var _ = Describe("the SMTP service", func() {
 			Expect(outputURL.String()).To(Equal(urlWithAllProps))
 
 		})
+		When("initializing the service using a config", func() {
+			It("should keep the addresses unchanged", func() {
+				config := &Config{
+					Host:        "mail.example.com",
+					Port:        587,
+					FromAddress: "alerts+shoutrrr@example.com",
+					ToAddresses: []string{"ops+alerts@example.com"},
+					Username:    "user",
+					Auth:        AuthTypes.Unknown,
+				}
+				service, err := New(config)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(service.config.FromAddress).To(Equal("alerts+shoutrrr@example.com"))
+				Expect(service.config.ToAddresses).To(Equal([]string{"ops+alerts@example.com"}))
+				Expect(service.config.Auth).To(Equal(AuthTypes.Plain))
+
+				config.ToAddresses[0] = "changed@example.com"
+				Expect(service.config.ToAddresses).To(Equal([]string{"ops+alerts@example.com"}))
+			})
+		})
 		When("resolving client host", func() {
 			When("clienthost is set to auto", func() {
 				It("should return the os hostname", func() {
@@ -102,8 +130,8 @@ var _ = Describe("the SMTP service", func() {
 		})
 
 		It("should have the expected number of fields and enums", func() {
-			testutils.TestConfigGetEnumsCount(config, 2)
-			testutils.TestConfigGetFieldsCount(config, 13)
+			testutils.TestConfigGetEnumsCount(config, 3)
+			testutils.TestConfigGetFieldsCount(config, 14)
 		})
 	})
 	When("cloning a config", func() {
@@ -142,35 +170,35 @@ var _ = Describe("the SMTP service", func() {
 		})
 		It("should fail when writing multipart plain header", func() {
 			writer := testutils.CreateFailWriter(1)
-			err := service.writeMultipartMessage(writer, message)
+			err := service.writeMultipartMessage(writer, message, nil, "boundary", markup.Formats.Plain)
 			Expect(err).To(HaveOccurred())
 			Expect(err.ID()).To(Equal(FailPlainHeader))
 		})
 
 		It("should fail when writing multipart plain message", func() {
 			writer := testutils.CreateFailWriter(2)
-			err := service.writeMultipartMessage(writer, message)
+			err := service.writeMultipartMessage(writer, message, nil, "boundary", markup.Formats.Plain)
 			Expect(err).To(HaveOccurred())
 			Expect(err.ID()).To(Equal(FailMessageRaw))
 		})
 
 		It("should fail when writing multipart HTML header", func() {
 			writer := testutils.CreateFailWriter(4)
-			err := service.writeMultipartMessage(writer, message)
+			err := service.writeMultipartMessage(writer, message, nil, "boundary", markup.Formats.Plain)
 			Expect(err).To(HaveOccurred())
 			Expect(err.ID()).To(Equal(FailHTMLHeader))
 		})
 
 		It("should fail when writing multipart HTML message", func() {
 			writer := testutils.CreateFailWriter(5)
-			err := service.writeMultipartMessage(writer, message)
+			err := service.writeMultipartMessage(writer, message, nil, "boundary", markup.Formats.Plain)
 			Expect(err).To(HaveOccurred())
 			Expect(err.ID()).To(Equal(FailMessageRaw))
 		})
 
 		It("should fail when writing multipart end header", func() {
 			writer := testutils.CreateFailWriter(6)
-			err := service.writeMultipartMessage(writer, message)
+			err := service.writeMultipartMessage(writer, message, nil, "boundary", markup.Formats.Plain)
 			Expect(err).To(HaveOccurred())
 			Expect(err.ID()).To(Equal(FailMultiEndHeader))
 		})
@@ -187,6 +215,72 @@ var _ = Describe("the SMTP service", func() {
 
 	})
 
+	When("writing a markdown message", func() {
+		It("should write both a plain text and a HTML part", func() {
+			service := Service{}
+			writer := &bufferWriteCloser{}
+			err := service.writeMultipartMessage(writer, "**Deploy** of `api` done", nil, "boundary", markup.Formats.Markdown)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.String()).To(ContainSubstring("\n\nDeploy of api done\n\n--boundary"))
+			Expect(writer.String()).To(ContainSubstring("<p><strong>Deploy</strong> of <code>api</code> done</p>"))
+		})
+	})
+
+	When("writing a message with fields", func() {
+		It("should write the fields as lines in the plain part and as a table in the HTML part", func() {
+			service := Service{}
+			writer := &bufferWriteCloser{}
+			fields := []types.Field{{Key: "host", Value: "web-1"}, {Key: "error", Value: "a < b"}}
+			err := service.writeMultipartMessage(writer, "Deploy failed", fields, "boundary", markup.Formats.Plain)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.String()).To(ContainSubstring("Deploy failed\nhost: web-1\nerror: a < b\n\n--boundary"))
+			Expect(writer.String()).To(ContainSubstring(`<tr><th align="left">error</th><td>a &lt; b</td></tr>`))
+		})
+	})
+
+	When("sending a message with attachments", func() {
+		It("should send the body and the attachments as parts of mixed content", func() {
+			textCon, tcfaker := testutils.CreateTextConFaker([]string{
+				"250 mx.google.com at your service",
+				"250 Sender OK",
+				"250 Receiver OK",
+				"354 Go ahead",
+				"250 Data OK",
+			}, "\r\n")
+			client := &smtp.Client{Text: textCon}
+
+			config := &Config{FromAddress: "sender@example.com", UseHTML: true}
+			attachments := []types.Attachment{{Name: "report.csv", Data: []byte("a,b\n1,2")}}
+			service := Service{}
+			Expect(service.sendToRecipient(client, "r@example.com", config, "message body", nil, attachments, "b1")).To(Succeed())
+
+			input := strings.Join(tcfaker.GetClientSentences(), "\r\n")
+			data := input[strings.Index(input, "DATA\r\n")+6 : strings.LastIndex(input, "\r\n.\r\n")]
+			msg, err := mail.ReadMessage(strings.NewReader(data))
+			Expect(err).NotTo(HaveOccurred())
+
+			mediaType, mediaParams, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mediaType).To(Equal("multipart/mixed"))
+
+			reader := multipart.NewReader(msg.Body, mediaParams["boundary"])
+			body, err := reader.NextPart()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body.Header.Get("Content-Type")).To(Equal("multipart/alternative; boundary=b1"))
+
+			attachment, err := reader.NextPart()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attachment.FileName()).To(Equal("report.csv"))
+			Expect(attachment.Header.Get("Content-Type")).To(HavePrefix("text/csv"))
+			content, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, attachment))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("a,b\n1,2"))
+
+			_, err = reader.NextPart()
+			Expect(err).To(Equal(io.EOF))
+		})
+	})
+
 	When("running E2E tests", func() {
 
 		It("should work without errors", func() {
@@ -500,7 +594,7 @@ func testSendRecipient(testURL string, responses []string) failures.Failure {
 	config := &Config{}
 	message := "message body"
 
-	ferr := service.sendToRecipient(client, "r@example.com", config, message)
+	ferr := service.sendToRecipient(client, "r@example.com", config, message, nil, nil, "")
 
 	logger.Printf("\n%s", tcfaker.GetConversation(false))
 	if ferr != nil {
@@ -541,7 +635,7 @@ func testIntegration(testURL string, responses []string, htmlTemplate string, pl
 
 	fakeTLSEnabled(client, serviceURL.Hostname())
 
-	ferr := service.doSend(client, "Test message", service.config)
+	ferr := service.doSend(client, "Test message", nil, nil, service.config)
 
 	recieved := tcfaker.GetClientSentences()
 	for _, expected := range expectRec {
@@ -572,6 +666,15 @@ func fakeTLSEnabled(client *smtp.Client, hostname string) {
 	cr.SetString(hostname)
 }
 
+// bufferWriteCloser is a bytes.Buffer that can be used as the data stream of a mail
+type bufferWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*bufferWriteCloser) Close() error {
+	return nil
+}
+
 // matchFailure is a simple wrapper around `fail` and `gomega.MatchError`` to make it easier to use in tests
 func matchFailure(id failures.FailureID) gt.GomegaMatcher {
 	return MatchError(fail(id, nil))
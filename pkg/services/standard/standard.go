@@ -1,7 +1,9 @@
 package standard
 
-// Standard implements the Logger and Templater parts of the Service interface
+// Standard implements the Logger and Templater parts of the Service interface, and holds the context used by copies
+// of the service created by WithContext
 type Standard struct {
 	Logger
 	Templater
+	Contexter
 }
@@ -0,0 +1,40 @@
+package standard
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
+)
+
+// Contexter holds the context that the requests of a service are made using, set on the copies of the service
+// returned by types.ContextService.WithContext
+type Contexter struct {
+	ctx context.Context
+}
+
+// SetContext sets the context that the requests of the service are made using
+func (c *Contexter) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// Context returns the context that the requests of the service should be made using
+func (c *Contexter) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// HTTPClient returns the shared httpclient.Client, making the requests using the context of the service
+func (c *Contexter) HTTPClient() *http.Client {
+	return c.ContextClient(httpclient.Client)
+}
+
+// ContextClient returns client, making the requests using the context of the service
+func (c *Contexter) ContextClient(client *http.Client) *http.Client {
+	if c.ctx == nil {
+		return client
+	}
+	return httpclient.WithContext(c.ctx, client)
+}
@@ -14,13 +14,16 @@ const (
 	FailServiceInit f.FailureID = -3
 	// FailUnknown is the default FailureID
 	FailUnknown f.FailureID = iota
+	// FailCircuitOpen is the FailureID used to represent a send being rejected since the circuit breaker is open
+	FailCircuitOpen f.FailureID = -4
 )
 
 // Failure creates a Failure instance corresponding to the provided failureID, wrapping the provided error
 func Failure(failureID f.FailureID, err error, v ...interface{}) f.Failure {
 	messages := map[int]string{
-		int(FailParseURL): "error parsing Service URL",
-		int(FailUnknown):  "an unknown error occurred",
+		int(FailParseURL):    "error parsing Service URL",
+		int(FailUnknown):     "an unknown error occurred",
+		int(FailCircuitOpen): "circuit breaker is open",
 	}
 
 	msg := messages[int(failureID)]
@@ -3,11 +3,14 @@ package standard
 import (
 	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/logging"
 )
 
-// Logger provides the utility methods Log* that maps to Logger.Print*
+// Logger provides the utility methods Log* that maps to Logger.Print*, and the leveled methods Debug, Info, Warn and
+// Error that use the logger as a types.Logger if it implements it, and Print otherwise
 type Logger struct {
-	logger types.StdLogger
+	logger  types.StdLogger
+	leveled types.Logger
 }
 
 // Logf maps to the service loggers Logger.Printf function
@@ -20,6 +23,26 @@ func (sl *Logger) Log(v ...interface{}) {
 	sl.logger.Print(v...)
 }
 
+// Debug logs msg and the key-value pairs at the debug level
+func (sl *Logger) Debug(msg string, keyvals ...interface{}) {
+	sl.leveled.Debug(msg, keyvals...)
+}
+
+// Info logs msg and the key-value pairs at the info level
+func (sl *Logger) Info(msg string, keyvals ...interface{}) {
+	sl.leveled.Info(msg, keyvals...)
+}
+
+// Warn logs msg and the key-value pairs at the warning level
+func (sl *Logger) Warn(msg string, keyvals ...interface{}) {
+	sl.leveled.Warn(msg, keyvals...)
+}
+
+// Error logs msg and the key-value pairs at the error level
+func (sl *Logger) Error(msg string, keyvals ...interface{}) {
+	sl.leveled.Error(msg, keyvals...)
+}
+
 // SetLogger maps the specified logger to the Log* helper methods
 func (sl *Logger) SetLogger(logger types.StdLogger) {
 	if logger == nil {
@@ -27,4 +50,5 @@ func (sl *Logger) SetLogger(logger types.StdLogger) {
 	} else {
 		sl.logger = logger
 	}
+	sl.leveled = logging.Leveled(sl.logger)
 }
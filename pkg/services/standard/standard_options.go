@@ -0,0 +1,54 @@
+package standard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// ErrUnexpectedConfig is returned (wrapped) when a service is initialized using the config of another service
+var ErrUnexpectedConfig = errors.New("unexpected config type")
+
+// Option configures a service created using the New function of its package
+type Option func(service types.Service) error
+
+// WithLogger makes the service write progress logs to logger
+func WithLogger(logger types.StdLogger) Option {
+	return func(service types.Service) error {
+		service.SetLogger(logger)
+		return nil
+	}
+}
+
+// WithTemplate sets the template identified by id for the service, using body as the template source
+func WithTemplate(id string, body string) Option {
+	return func(service types.Service) error {
+		return service.SetTemplateString(id, body)
+	}
+}
+
+// ConfigService is a Service that can be initialized using a ServiceConfig
+type ConfigService interface {
+	types.Service
+	types.ConfigInitializer
+}
+
+// New initializes service using config and applies the options. It is used to implement the New function of the
+// service packages.
+func New[S ConfigService](service S, config types.ServiceConfig, options []Option) (S, error) {
+	if err := service.InitializeConfig(config, nil); err != nil {
+		return service, err
+	}
+	for _, option := range options {
+		if err := option(service); err != nil {
+			return service, err
+		}
+	}
+	return service, nil
+}
+
+// UnexpectedConfigError returns an error for a config that is not of the type used by the service
+func UnexpectedConfigError(config types.ServiceConfig) error {
+	return fmt.Errorf("%w: %T", ErrUnexpectedConfig, config)
+}
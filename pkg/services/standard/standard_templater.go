@@ -8,6 +8,7 @@ import (
 // Templater is the standard implementation of ApplyTemplate using the "text/template" library
 type Templater struct {
 	templates map[string]*template.Template
+	funcs     template.FuncMap
 }
 
 // GetTemplate attempts to retrieve the template identified with id
@@ -18,7 +19,7 @@ func (templater *Templater) GetTemplate(id string) (template *template.Template,
 
 // SetTemplateString creates a new template from the body and assigning it the id
 func (templater *Templater) SetTemplateString(id string, body string) error {
-	tpl, err := template.New("").Parse(body)
+	tpl, err := template.New("").Funcs(templater.funcs).Parse(body)
 	if err != nil {
 		return err
 	}
@@ -38,3 +39,8 @@ func (templater *Templater) SetTemplateFile(id string, file string) error {
 	}
 	return templater.SetTemplateString(id, string(bytes))
 }
+
+// SetTemplateFuncs sets the functions that are made available to templates that are set after this call
+func (templater *Templater) SetTemplateFuncs(funcs template.FuncMap) {
+	templater.funcs = funcs
+}
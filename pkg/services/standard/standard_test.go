@@ -5,9 +5,13 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
+	"text/template"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -60,6 +64,16 @@ var _ = Describe("the standard logging implementation", func() {
 				Expect(builder.String()).To(Equal("foo 7\n"))
 			})
 		})
+		When("when the leveled methods are called", func() {
+			It("should print the messages with the key-value pairs", func() {
+
+				logger.SetLogger(stringLogger)
+				logger.Warn("foo", "count", 7)
+				logger.Debug("bar")
+
+				Expect(builder.String()).To(Equal("foo count=7\nbar\n"))
+			})
+		})
 	})
 })
 
@@ -111,6 +125,19 @@ var _ = Describe("the standard template implementation", func() {
 			Expect(found).To(BeTrue())
 		})
 	})
+	When("a template is using custom functions", func() {
+		It("should make the functions available to the template", func() {
+			templater := &Templater{}
+			templater.SetTemplateFuncs(template.FuncMap{"shout": strings.ToUpper})
+			err := templater.SetTemplateString("bar", "{{ shout . }}")
+			Expect(err).NotTo(HaveOccurred())
+
+			tpl, _ := templater.GetTemplate("bar")
+			sb := &strings.Builder{}
+			Expect(tpl.Execute(sb, "body")).To(Succeed())
+			Expect(sb.String()).To(Equal("BODY"))
+		})
+	})
 	When("a template is being retrieved with an invalid ID", func() {
 		It("should return an error", func() {
 			templater := &Templater{}
@@ -131,3 +158,52 @@ var _ = Describe("the standard enumless config implementation", func() {
 		})
 	})
 })
+
+var _ = Describe("the standard service options", func() {
+	It("should initialize the service and apply the options", func() {
+		output := &strings.Builder{}
+		service, err := New(&optionsService{}, &optionsConfig{}, []Option{
+			WithLogger(log.New(output, "", 0)),
+			WithTemplate("message", "{{ . }}"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(service.initialized).To(BeTrue())
+
+		service.Log("logged")
+		Expect(output.String()).To(Equal("logged\n"))
+		_, found := service.GetTemplate("message")
+		Expect(found).To(BeTrue())
+	})
+	It("should return the error of an option", func() {
+		_, err := New(&optionsService{}, &optionsConfig{}, []Option{WithTemplate("message", "{{ ")})
+		Expect(err).To(HaveOccurred())
+	})
+	It("should wrap ErrUnexpectedConfig for configs of other services", func() {
+		err := UnexpectedConfigError(&optionsConfig{})
+		Expect(err).To(MatchError(ErrUnexpectedConfig))
+		Expect(err.Error()).To(ContainSubstring("*standard.optionsConfig"))
+	})
+})
+
+type optionsService struct {
+	Standard
+	initialized bool
+}
+
+func (service *optionsService) Send(string, *types.Params) error { return nil }
+
+func (service *optionsService) Initialize(*url.URL, types.StdLogger) error { return nil }
+
+func (service *optionsService) InitializeConfig(_ types.ServiceConfig, logger types.StdLogger) error {
+	service.SetLogger(logger)
+	service.initialized = true
+	return nil
+}
+
+type optionsConfig struct {
+	EnumlessConfig
+}
+
+func (config *optionsConfig) GetURL() *url.URL { return &url.URL{Scheme: "options"} }
+
+func (config *optionsConfig) SetURL(*url.URL) error { return nil }
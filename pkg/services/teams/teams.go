@@ -2,6 +2,7 @@ package teams
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // Service providing teams as a notification service
@@ -21,15 +23,50 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Microsoft Teams
 func (service *Service) Send(message string, params *types.Params) error {
-	config := service.config
+	config := *service.config
+
+	if err := service.pkr.UpdateConfigFromParams(&config, markup.WithoutParam(params)); err != nil {
+		service.Warn("Failed to update params", "error", err)
+	}
+
+	return service.doSend(&config, createLineSections(message))
+}
 
-	if err := service.pkr.UpdateConfigFromParams(config, params); err != nil {
-		service.Logf("Failed to update params: %v", err)
+// SendItems sends the items as message card sections, with the fields of each item as the section facts and the
+// actions of each item as the section buttons
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	config := *service.config
+
+	if err := service.pkr.UpdateConfigFromParams(&config, markup.WithoutParam(&params)); err != nil {
+		service.Warn("Failed to update params", "error", err)
+	}
+
+	sections := make([]section, 0, len(items))
+	for _, item := range items {
+		sec := section{Text: item.Text}
+		for _, field := range item.Fields {
+			sec.Facts = append(sec.Facts, fact{Key: field.Key, Value: field.Value})
+		}
+		for _, action := range types.LinkActions(item.Actions) {
+			sec.PotentialAction = append(sec.PotentialAction, openURIAction{
+				Type:    "OpenUri",
+				Name:    action.Label,
+				Targets: []uriTarget{{OS: "default", URI: action.URL}},
+			})
+		}
+		sections = append(sections, sec)
 	}
 
-	return service.doSend(config, message)
+	return service.doSend(&config, sections)
 }
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
@@ -44,6 +81,24 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return service.config.setURL(&service.pkr, configURL)
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
 // GetConfigURLFromCustom creates a regular service URL from one with a custom host
 func (*Service) GetConfigURLFromCustom(customURL *url.URL) (serviceURL *url.URL, err error) {
 	config, err := ConfigFromWebhookURL(*customURL)
@@ -61,7 +116,8 @@ func (*Service) GetConfigURLFromCustom(customURL *url.URL) (serviceURL *url.URL,
 	return config.getURL(&resolver), nil
 }
 
-func (service *Service) doSend(config *Config, message string) error {
+// createLineSections creates a section for each line of message
+func createLineSections(message string) []section {
 	var sections []section
 
 	for _, line := range strings.Split(message, "\n") {
@@ -70,6 +126,10 @@ func (service *Service) doSend(config *Config, message string) error {
 		})
 	}
 
+	return sections
+}
+
+func (service *Service) doSend(config *Config, sections []section) error {
 	// Teams need a summary for the webhook, use title or first (truncated) row
 	summary := config.Title
 	if summary == "" && len(sections) > 0 {
@@ -97,11 +157,11 @@ func (service *Service) doSend(config *Config, message string) error {
 		host = LegacyHost
 		// Emit a warning to the log for now.
 		// TODO(v0.6): Remove legacy support as it should be fully deprecated now
-		service.Logf(`Warning: No host specified, update your Teams URL: %s`, util.DocsURL(`services/teams`))
+		service.Warn("No host specified, update your Teams URL", "docs", util.DocsURL(`services/teams`))
 	}
 	postURL := buildWebhookURL(host, config.Group, config.Tenant, config.AltID, config.GroupOwner)
 
-	res, err := http.Post(postURL, "application/json", bytes.NewBuffer(payload))
+	res, err := service.HTTPClient().Post(postURL, "application/json", bytes.NewBuffer(payload))
 	if err == nil && res.StatusCode != http.StatusOK {
 		return fmt.Errorf("failed to send notification to teams, response status code %s", res.Status)
 	}
@@ -13,13 +13,26 @@ type payload struct {
 }
 
 type section struct {
-	Text         string `json:"text,omitempty"`
-	ActivityText string `json:"activityText,omitempty"`
-	StartGroup   bool   `json:"startGroup"`
-	Facts        []fact `json:"facts,omitempty"`
+	Text            string          `json:"text,omitempty"`
+	ActivityText    string          `json:"activityText,omitempty"`
+	StartGroup      bool            `json:"startGroup"`
+	Facts           []fact          `json:"facts,omitempty"`
+	PotentialAction []openURIAction `json:"potentialAction,omitempty"`
 }
 
 type fact struct {
 	Key   string `json:"key"`
 	Value string `json:"value"`
 }
+
+// openURIAction is a button that opens the URI of the target
+type openURIAction struct {
+	Type    string      `json:"@type"`
+	Name    string      `json:"name"`
+	Targets []uriTarget `json:"targets"`
+}
+
+type uriTarget struct {
+	OS  string `json:"os"`
+	URI string `json:"uri"`
+}
@@ -1,11 +1,14 @@
 package teams
 
 import (
+	"encoding/json"
 	"errors"
 	"log"
+	"net/http"
 	"net/url"
 	"testing"
 
+	"github.com/dockerutil/shoutrrr/pkg/types"
 	"github.com/jarcoal/httpmock"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -149,6 +152,50 @@ var _ = Describe("the teams service", func() {
 			err = service.Send("Message", nil)
 			Expect(err).To(HaveOccurred())
 		})
+		It("should send the item fields as section facts", func() {
+			serviceURL, _ := url.Parse(scopedURLBase)
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+
+			var sent payload
+			httpmock.RegisterResponder("POST", scopedWebhookURL, func(req *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+					return nil, err
+				}
+				return httpmock.NewStringResponse(200, ""), nil
+			})
+
+			item := types.MessageItem{Text: "Deploy failed"}
+			item.WithField("host", "web-1")
+			Expect(service.SendItems([]types.MessageItem{item, {Text: "Rolled back"}}, nil)).To(Succeed())
+
+			Expect(sent.Sections).To(HaveLen(2))
+			Expect(sent.Sections[0].Text).To(Equal("Deploy failed"))
+			Expect(sent.Sections[0].Facts).To(Equal([]fact{{Key: "host", Value: "web-1"}}))
+			Expect(sent.Sections[1].Facts).To(BeEmpty())
+		})
+		It("should send the item actions as section buttons", func() {
+			serviceURL, _ := url.Parse(scopedURLBase)
+			Expect(service.Initialize(serviceURL, logger)).To(Succeed())
+
+			var sent payload
+			httpmock.RegisterResponder("POST", scopedWebhookURL, func(req *http.Request) (*http.Response, error) {
+				if err := json.NewDecoder(req.Body).Decode(&sent); err != nil {
+					return nil, err
+				}
+				return httpmock.NewStringResponse(200, ""), nil
+			})
+
+			item := types.MessageItem{Text: "Deploy failed"}
+			item.WithAction(types.Action{Label: "Open runbook", URL: "https://example.com/runbook"})
+			Expect(service.SendItems([]types.MessageItem{item}, nil)).To(Succeed())
+
+			Expect(sent.Sections).To(HaveLen(1))
+			Expect(sent.Sections[0].PotentialAction).To(Equal([]openURIAction{{
+				Type:    "OpenUri",
+				Name:    "Open runbook",
+				Targets: []uriTarget{{OS: "default", URI: "https://example.com/runbook"}},
+			}}))
+		})
 
 	})
 
@@ -1,17 +1,25 @@
 package telegram
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 )
 
 const (
 	apiFormat = "https://api.telegram.org/bot%s/%s"
 	maxlength = 4096
+	// maxFileSize is the max size of files sent by bots
+	maxFileSize = 50 << 20
+	// maxPhotoSize is the max size of files sent as photos, larger images are sent as documents
+	maxPhotoSize = 10 << 20
 )
 
 // Service sends notifications to a given telegram chat
@@ -21,18 +29,140 @@ type Service struct {
 	pkr    format.PropKeyResolver
 }
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send notification to Telegram
 func (service *Service) Send(message string, params *types.Params) error {
-	if len(message) > maxlength {
-		return errors.New("Message exceeds the max length")
+	_, err := service.SendRefs(message, params)
+	return err
+}
+
+// SendRefs sends the message like Send, returning a reference with the chat and message_id of every text message
+func (service *Service) SendRefs(message string, params *types.Params) ([]types.MessageRef, error) {
+	config := *service.config
+	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
+		return nil, err
 	}
 
+	refs, attachment, err := service.sendText(message, nil, &config)
+	if err != nil || attachment == nil {
+		return refs, err
+	}
+	return refs, service.sendFilesForChatIDs([]types.Attachment{*attachment}, &config)
+}
+
+// newClient returns a Client using token, making the requests using the context of the service
+func (service *Service) newClient(token string) *Client {
+	return &Client{token: token, httpClient: service.HTTPClient()}
+}
+
+// Update replaces the text of the referenced message using editMessageText
+func (service *Service) Update(ref types.MessageRef, message string, params *types.Params) error {
 	config := *service.config
 	if err := service.pkr.UpdateConfigFromParams(&config, params); err != nil {
 		return err
 	}
+	messageID, err := parseMessageID(ref)
+	if err != nil {
+		return err
+	}
+
+	client := service.newClient(config.Token)
+	payload := createSendMessagePayload(message, ref.Channel, &config)
+	payload.MessageThreadID = nil
+	payload.MessageID = messageID
+	_, err = client.EditMessageText(&payload)
+	return err
+}
+
+// Delete removes the referenced message
+func (service *Service) Delete(ref types.MessageRef) error {
+	messageID, err := parseMessageID(ref)
+	if err != nil {
+		return err
+	}
+
+	client := service.newClient(service.config.Token)
+	return client.DeleteMessage(&messageRefPayload{ChatID: ref.Channel, MessageID: messageID})
+}
+
+func parseMessageID(ref types.MessageRef) (int64, error) {
+	messageID, err := strconv.ParseInt(ref.ID, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid telegram message ID %q: %w", ref.ID, err)
+	}
+	return messageID, nil
+}
+
+// SendItems sends the items as a plain text message, followed by their attachments.
+// The actions of the items are added as inline keyboard buttons.
+func (service *Service) SendItems(items []types.MessageItem, params types.Params) error {
+	message := strings.TrimSuffix(types.ItemsToPlain(types.ItemsWithoutActions(items)), "\n")
+	keyboard := createInlineKeyboard(types.ItemsActions(items))
+
+	config := *service.config
+	if err := service.pkr.UpdateConfigFromParams(&config, &params); err != nil {
+		return err
+	}
+
+	attachments := types.ItemsAttachments(items)
+	if message != "" {
+		_, attachment, err := service.sendText(message, keyboard, &config)
+		if err != nil {
+			return err
+		}
+		if attachment != nil {
+			attachments = append([]types.Attachment{*attachment}, attachments...)
+		}
+	}
+
+	return service.sendFilesForChatIDs(attachments, &config)
+}
+
+// sendText sends the message to the chats, applying the overflow mode if it exceeds the max length.
+// The keyboard, if not nil, is added to the last message.
+// When using the Attach mode, the returned attachment with the full message should be sent after the message.
+func (service *Service) sendText(message string, keyboard *replyMarkup, config *Config) ([]types.MessageRef, *types.Attachment, error) {
+	limit := service.MessageLimit()
+	if config.Title != "" {
+		// Leave room for the title, which is prepended to the message
+		limit.ChunkSize -= types.UTF16Length.Length(config.Title) + 1
+		limit.TotalChunkSize = limit.ChunkSize
+	}
+
+	messages, attachment, err := overflow.Apply(message, limit, config.Overflow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var refs []types.MessageRef
+	for i, part := range messages {
+		var partKeyboard *replyMarkup
+		if i == len(messages)-1 {
+			partKeyboard = keyboard
+		}
+		partRefs, err := service.sendMessageForChatIDs(part, partKeyboard, config)
+		refs = append(refs, partRefs...)
+		if err != nil {
+			return refs, nil, err
+		}
+	}
+	return refs, attachment, nil
+}
 
-	return service.sendMessageForChatIDs(message, &config)
+// MaxAttachmentSize returns the max size of files sent by bots
+func (service *Service) MaxAttachmentSize() int64 {
+	return maxFileSize
+}
+
+// MessageLimit returns the max length of a Telegram message
+func (service *Service) MessageLimit() types.MessageLimit {
+	return types.MessageLimit{ChunkSize: maxlength, TotalChunkSize: maxlength, ChunkCount: 1, Unit: types.UTF16Length}
 }
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
@@ -50,13 +180,36 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return nil
 }
 
-func (service *Service) sendMessageForChatIDs(message string, config *Config) error {
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	service.pkr = format.NewPropKeyResolver(service.config)
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
+func (service *Service) sendMessageForChatIDs(message string, keyboard *replyMarkup, config *Config) ([]types.MessageRef, error) {
+	refs := make([]types.MessageRef, 0, len(service.config.Chats))
 	for _, chat := range service.config.Chats {
-		if err := sendMessageToAPI(message, chat, config); err != nil {
-			return err
+		ref, err := service.sendMessageToAPI(message, keyboard, chat, config)
+		if err != nil {
+			return refs, err
+		}
+		if ref != nil {
+			refs = append(refs, *ref)
 		}
 	}
-	return nil
+	return refs, nil
 }
 
 // GetConfig returns the Config for the service
@@ -64,9 +217,37 @@ func (service *Service) GetConfig() *Config {
 	return service.config
 }
 
-func sendMessageToAPI(message string, chat string, config *Config) error {
-	client := &Client{token: config.Token}
+func (service *Service) sendFilesForChatIDs(attachments []types.Attachment, config *Config) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+	for _, chat := range config.Chats {
+		if err := service.sendFilesToAPI(attachments, chat, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendMessageToAPI sends the message to the chat, returning a reference to the message if the API returned it
+func (service *Service) sendMessageToAPI(message string, keyboard *replyMarkup, chat string, config *Config) (*types.MessageRef, error) {
+	client := service.newClient(config.Token)
 	payload := createSendMessagePayload(message, chat, config)
-	_, err := client.SendMessage(&payload)
-	return err
+	payload.ReplyMarkup = keyboard
+	sent, err := client.SendMessage(&payload)
+	if err != nil || sent == nil {
+		return nil, err
+	}
+	return &types.MessageRef{Channel: payload.ID, ID: strconv.FormatInt(sent.MessageID, 10)}, nil
+}
+
+func (service *Service) sendFilesToAPI(attachments []types.Attachment, chat string, config *Config) error {
+	client := service.newClient(config.Token)
+	payload := createSendFilePayload(chat, config)
+	for i := range attachments {
+		if _, err := client.SendFile(&payload, &attachments[i]); err != nil {
+			return err
+		}
+	}
+	return nil
 }
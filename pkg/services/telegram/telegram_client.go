@@ -1,14 +1,31 @@
 package telegram
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
 	"github.com/dockerutil/shoutrrr/pkg/util/jsonclient"
 )
 
 // Client for Telegram API
 type Client struct {
 	token string
+	// httpClient is used for the API requests, or httpclient.Client if nil
+	httpClient *http.Client
+}
+
+func (c *Client) client() *http.Client {
+	if c.httpClient == nil {
+		return httpclient.Client
+	}
+	return c.httpClient
 }
 
 func (c *Client) apiURL(endpoint string) string {
@@ -18,7 +35,7 @@ func (c *Client) apiURL(endpoint string) string {
 // GetBotInfo returns the bot User info
 func (c *Client) GetBotInfo() (*User, error) {
 	response := &userResponse{}
-	err := jsonclient.Get(c.apiURL("getMe"), response)
+	err := jsonclient.NewWithHTTPClient(c.client()).Get(c.apiURL("getMe"), response)
 
 	if !response.OK {
 		return nil, GetErrorResponse(jsonclient.ErrorBody(err))
@@ -37,7 +54,7 @@ func (c *Client) GetUpdates(offset int, limit int, timeout int, allowedUpdates [
 		AllowedUpdates: allowedUpdates,
 	}
 	response := &updatesResponse{}
-	err := jsonclient.Post(c.apiURL("getUpdates"), request, response)
+	err := jsonclient.NewWithHTTPClient(c.client()).Post(c.apiURL("getUpdates"), request, response)
 
 	if !response.OK {
 		return nil, GetErrorResponse(jsonclient.ErrorBody(err))
@@ -50,7 +67,7 @@ func (c *Client) GetUpdates(offset int, limit int, timeout int, allowedUpdates [
 func (c *Client) SendMessage(message *SendMessagePayload) (*Message, error) {
 
 	response := &messageResponse{}
-	err := jsonclient.Post(c.apiURL("sendMessage"), message, response)
+	err := jsonclient.NewWithHTTPClient(c.client()).Post(c.apiURL("sendMessage"), message, response)
 
 	if !response.OK {
 		return nil, GetErrorResponse(jsonclient.ErrorBody(err))
@@ -59,6 +76,74 @@ func (c *Client) SendMessage(message *SendMessagePayload) (*Message, error) {
 	return response.Result, nil
 }
 
+// EditMessageText replaces the text of the message identified by the chat and MessageID of the payload
+func (c *Client) EditMessageText(message *SendMessagePayload) (*Message, error) {
+
+	response := &messageResponse{}
+	err := jsonclient.NewWithHTTPClient(c.client()).Post(c.apiURL("editMessageText"), message, response)
+
+	if !response.OK {
+		return nil, GetErrorResponse(jsonclient.ErrorBody(err))
+	}
+
+	return response.Result, nil
+}
+
+// DeleteMessage deletes the message identified by the payload
+func (c *Client) DeleteMessage(message *messageRefPayload) error {
+
+	response := &boolResponse{}
+	err := jsonclient.NewWithHTTPClient(c.client()).Post(c.apiURL("deleteMessage"), message, response)
+
+	if !response.OK {
+		return GetErrorResponse(jsonclient.ErrorBody(err))
+	}
+
+	return nil
+}
+
+// SendFile uploads the attachment to the chat of the payload, using sendPhoto for photos and sendDocument for any
+// other files
+func (c *Client) SendFile(payload *sendFilePayload, attachment *types.Attachment) (*Message, error) {
+	method, field := "sendDocument", "document"
+	if isPhoto(attachment) {
+		method, field = "sendPhoto", "photo"
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := payload.write(writer); err != nil {
+		return nil, err
+	}
+	if err := util.WriteFormFile(writer, field, attachment); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	res, err := c.client().Post(c.apiURL(method), writer.FormDataContentType(), body)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &messageResponse{}
+	if err := json.Unmarshal(resBody, response); err != nil || !response.OK {
+		if errRes := GetErrorResponse(string(resBody)); errRes != nil {
+			return nil, errRes
+		}
+		return nil, fmt.Errorf("failed to send file: %v", res.Status)
+	}
+
+	return response.Result, nil
+}
+
 // GetErrorResponse retrieves the error message from a failed request
 func GetErrorResponse(body string) error {
 	response := &errorResponse{}
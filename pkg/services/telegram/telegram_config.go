@@ -8,6 +8,8 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/format"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 )
 
 // Config for use within the telegram plugin
@@ -18,12 +20,17 @@ type Config struct {
 	ParseMode    parseMode `key:"parsemode" default:"None" desc:"How the text Message should be parsed"`
 	Chats        []string  `key:"chats,channels" desc:"Chat IDs or Channel names (using @channel-name)"`
 	Title        string    `key:"title" default:"" desc:"Notification title, optionally set by the sender"`
+
+	Format   markup.Format `key:"format" default:"Plain" desc:"The markup used by the message, converted to the service markup if Markdown"`
+	Overflow overflow.Mode `key:"overflow" default:"Split" desc:"How to send messages longer than 4096 characters: Split, Truncate, Attach or Error"`
 }
 
 // Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
 func (config *Config) Enums() map[string]types.EnumFormatter {
 	return map[string]types.EnumFormatter{
 		"ParseMode": ParseModes.Enum,
+		"Format":    markup.Formats.Enum,
+		"Overflow":  overflow.Modes.Enum,
 	}
 }
 
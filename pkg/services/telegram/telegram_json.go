@@ -3,8 +3,12 @@ package telegram
 import (
 	"fmt"
 	"html"
+	"mime/multipart"
 	"strconv"
 	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/markup"
 )
 
 // SendMessagePayload is the notification payload for the telegram notification service
@@ -21,6 +25,46 @@ type SendMessagePayload struct {
 	MessageID           int64        `json:"message_id,omitempty"`
 }
 
+// sendFilePayload is the form sent together with a file to the sendDocument and sendPhoto endpoints
+type sendFilePayload struct {
+	ID                  string
+	MessageThreadID     *int
+	DisableNotification bool
+}
+
+func (payload *sendFilePayload) write(writer *multipart.Writer) error {
+	if err := writer.WriteField("chat_id", payload.ID); err != nil {
+		return err
+	}
+	if payload.MessageThreadID != nil {
+		if err := writer.WriteField("message_thread_id", strconv.Itoa(*payload.MessageThreadID)); err != nil {
+			return err
+		}
+	}
+	return writer.WriteField("disable_notification", strconv.FormatBool(payload.DisableNotification))
+}
+
+func createSendFilePayload(channel string, config *Config) sendFilePayload {
+	chatID, threadID := parseChannel(channel)
+	return sendFilePayload{
+		ID:                  chatID,
+		MessageThreadID:     threadID,
+		DisableNotification: !config.Notification,
+	}
+}
+
+// isPhoto returns whether the attachment can be sent as a photo, which are compressed and shown inline
+func isPhoto(attachment *types.Attachment) bool {
+	if len(attachment.Data) > maxPhotoSize {
+		return false
+	}
+	switch attachment.ContentType() {
+	case "image/jpeg", "image/png", "image/webp":
+		return true
+	}
+	return false
+}
+
 // Message represents one chat message
 type Message struct {
 	MessageID int64  `json:"message_id"`
@@ -34,14 +78,19 @@ type messageResponse struct {
 	Result *Message `json:"result"`
 }
 
+// messageRefPayload identifies a sent message in the API calls that only need the reference
+type messageRefPayload struct {
+	ChatID    string `json:"chat_id"`
+	MessageID int64  `json:"message_id"`
+}
+
+type boolResponse struct {
+	OK     bool `json:"ok"`
+	Result bool `json:"result"`
+}
+
 func createSendMessagePayload(message string, channel string, config *Config) SendMessagePayload {
-	var threadID *int = nil
-	chatID, thread, ok := strings.Cut(channel, ":")
-	if ok {
-		if parsed, err := strconv.Atoi(thread); err == nil {
-			threadID = &parsed
-		}
-	}
+	chatID, threadID := parseChannel(channel)
 	payload := SendMessagePayload{
 		Text:                message,
 		ID:                  chatID,
@@ -51,7 +100,12 @@ func createSendMessagePayload(message string, channel string, config *Config) Se
 	}
 
 	parseMode := config.ParseMode
-	if config.ParseMode == ParseModes.None && config.Title != "" {
+	if config.Format == markup.Formats.Markdown {
+		// markdown is converted to the HTML subset supported by telegram, overriding the parse mode
+		parseMode = ParseModes.HTML
+		message = markup.TelegramHTML(message)
+		payload.Text = message
+	} else if config.ParseMode == ParseModes.None && config.Title != "" {
 		parseMode = ParseModes.HTML
 		// no parse mode has been provided, treat message as unescaped HTML
 		message = html.EscapeString(message)
@@ -62,13 +116,24 @@ func createSendMessagePayload(message string, channel string, config *Config) Se
 	}
 
 	// only HTML parse mode is supported for titles
-	if parseMode == ParseModes.HTML {
+	if parseMode == ParseModes.HTML && config.Title != "" {
 		payload.Text = fmt.Sprintf("<b>%v</b>\n%v", html.EscapeString(config.Title), message)
 	}
 
 	return payload
 }
 
+// parseChannel returns the chat ID and the optional message thread ID of a channel in the form chat[:thread]
+func parseChannel(channel string) (chatID string, threadID *int) {
+	chatID, thread, ok := strings.Cut(channel, ":")
+	if ok {
+		if parsed, err := strconv.Atoi(thread); err == nil {
+			threadID = &parsed
+		}
+	}
+	return chatID, threadID
+}
+
 type errorResponse struct {
 	OK          bool   `json:"ok"`
 	ErrorCode   int    `json:"error_code"`
@@ -182,17 +247,31 @@ func (c *Chat) Name() string {
 
 type inlineKey struct {
 	Text                     string `json:"text"`
-	URL                      string `json:"url"`
-	LoginURL                 string `json:"login_url"`
-	CallbackData             string `json:"callback_data"`
-	SwitchInlineQuery        string `json:"switch_inline_query"`
-	SwitchInlineQueryCurrent string `json:"switch_inline_query_current_chat"`
+	URL                      string `json:"url,omitempty"`
+	LoginURL                 string `json:"login_url,omitempty"`
+	CallbackData             string `json:"callback_data,omitempty"`
+	SwitchInlineQuery        string `json:"switch_inline_query,omitempty"`
+	SwitchInlineQueryCurrent string `json:"switch_inline_query_current_chat,omitempty"`
 }
 
 type replyMarkup struct {
 	InlineKeyboard [][]inlineKey `json:"inline_keyboard,omitempty"`
 }
 
+// createInlineKeyboard returns a keyboard with a button on its own row for each of the actions that open a URL, or nil
+// if there are none
+func createInlineKeyboard(actions []types.Action) *replyMarkup {
+	links := types.LinkActions(actions)
+	if len(links) == 0 {
+		return nil
+	}
+	keyboard := &replyMarkup{}
+	for _, action := range links {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []inlineKey{{Text: action.Label, URL: action.URL}})
+	}
+	return keyboard
+}
+
 type entity struct {
 	Type   string `json:"type"`
 	Offset int    `json:"offset"`
@@ -1,6 +1,7 @@
 package zulip
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 )
 
 // Service sends notifications to a pre-configured channel or user
@@ -21,6 +23,13 @@ const (
 	topicMaxLength = 60    // characters
 )
 
+// WithContext returns a copy of the service that makes its requests using ctx
+func (service *Service) WithContext(ctx context.Context) types.Service {
+	bound := *service
+	bound.SetContext(ctx)
+	return &bound
+}
+
 // Send a notification message to Zulip
 func (service *Service) Send(message string, params *types.Params) error {
 	// Clone the config because we might modify stream and/or
@@ -44,13 +53,27 @@ func (service *Service) Send(message string, params *types.Params) error {
 		return fmt.Errorf(string(TopicTooLong), topicMaxLength, topicLength)
 	}
 
-	messageSize := len(message)
+	mode := overflow.FromParams(params, config.Overflow)
+	messages, attachment, err := overflow.Apply(message, service.MessageLimit(), mode)
+	if err != nil {
+		return err
+	}
+	if attachment != nil {
+		return fmt.Errorf("%w: the %v overflow mode cannot be used", types.ErrAttachmentsNotSupported, mode)
+	}
 
-	if messageSize > contentMaxSize {
-		return fmt.Errorf("message exceeds max size (%d bytes): was %d bytes", contentMaxSize, messageSize)
+	for _, message := range messages {
+		if err := service.doSend(config, message); err != nil {
+			return err
+		}
 	}
 
-	return service.doSend(config, message)
+	return nil
+}
+
+// MessageLimit returns the max size of a Zulip message
+func (service *Service) MessageLimit() types.MessageLimit {
+	return types.MessageLimit{ChunkSize: contentMaxSize, TotalChunkSize: contentMaxSize, ChunkCount: 1, Unit: types.ByteLength}
 }
 
 // Initialize loads ServiceConfig from configURL and sets logger for this Service
@@ -65,10 +88,27 @@ func (service *Service) Initialize(configURL *url.URL, logger types.StdLogger) e
 	return nil
 }
 
+// InitializeConfig initializes the service using config instead of a URL, and sets the logger for this Service
+func (service *Service) InitializeConfig(config types.ServiceConfig, logger types.StdLogger) error {
+	serviceConfig, ok := config.(*Config)
+	if !ok || serviceConfig == nil {
+		return standard.UnexpectedConfigError(config)
+	}
+	service.Logger.SetLogger(logger)
+	configCopy := *serviceConfig
+	service.config = &configCopy
+	return nil
+}
+
+// New returns a new Service using config, without parsing a service URL
+func New(config *Config, options ...standard.Option) (*Service, error) {
+	return standard.New(&Service{}, config, options)
+}
+
 func (service *Service) doSend(config *Config, message string) error {
 	apiURL := service.getAPIURL(config)
 	payload := CreatePayload(config, message)
-	res, err := http.Post(apiURL, "application/x-www-form-urlencoded", strings.NewReader(payload.Encode()))
+	res, err := service.HTTPClient().Post(apiURL, "application/x-www-form-urlencoded", strings.NewReader(payload.Encode()))
 
 	if err == nil && res.StatusCode != http.StatusOK {
 		err = fmt.Errorf("response status code %s", res.Status)
@@ -3,19 +3,27 @@ package zulip
 import (
 	"errors"
 	"github.com/dockerutil/shoutrrr/pkg/format"
-	"github.com/dockerutil/shoutrrr/pkg/services/standard"
 	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/overflow"
 	"net/url"
 )
 
 // Config for the zulip service
 type Config struct {
-	standard.EnumlessConfig
 	BotMail string `url:"user" desc:"Bot e-mail address"`
 	BotKey  string `url:"pass" desc:"API Key"`
 	Host    string `url:"host,port" desc:"API server hostname"`
 	Stream  string `key:"stream" optional:"" description:"Target stream name"`
 	Topic   string `key:"topic,title" default:""`
+
+	Overflow overflow.Mode `key:"overflow" default:"Split" desc:"How to send messages longer than 10000 bytes: Split, Truncate or Error"`
+}
+
+// Enums returns the fields that should use a corresponding EnumFormatter to Print/Parse their values
+func (config *Config) Enums() map[string]types.EnumFormatter {
+	return map[string]types.EnumFormatter{
+		"Overflow": overflow.Modes.Enum,
+	}
 }
 
 // GetURL returns a URL representation of it's current field values
@@ -41,6 +49,10 @@ func (config *Config) getURL(_ types.ConfigQueryResolver) *url.URL {
 		query.Set("topic", config.Topic)
 	}
 
+	if config.Overflow != overflow.Modes.Split {
+		query.Set("overflow", config.Overflow.String())
+	}
+
 	return &url.URL{
 		User:     url.UserPassword(config.BotMail, config.BotKey),
 		Host:     config.Host,
@@ -74,17 +86,27 @@ func (config *Config) setURL(_ types.ConfigQueryResolver, serviceURL *url.URL) e
 	config.Stream = serviceURL.Query().Get("stream")
 	config.Topic = serviceURL.Query().Get("topic")
 
+	config.Overflow = overflow.Modes.Split
+	if mode := serviceURL.Query().Get("overflow"); mode != "" {
+		parsed := overflow.Modes.Enum.Parse(mode)
+		if parsed == format.EnumInvalid {
+			return errors.New(string(InvalidOverflow))
+		}
+		config.Overflow = overflow.Mode(parsed)
+	}
+
 	return nil
 }
 
 // Clone the config to a new Config struct
 func (config *Config) Clone() *Config {
 	return &Config{
-		BotMail: config.BotMail,
-		BotKey:  config.BotKey,
-		Host:    config.Host,
-		Stream:  config.Stream,
-		Topic:   config.Topic,
+		BotMail:  config.BotMail,
+		BotKey:   config.BotKey,
+		Host:     config.Host,
+		Stream:   config.Stream,
+		Topic:    config.Topic,
+		Overflow: config.Overflow,
 	}
 }
 
@@ -10,6 +10,8 @@ const (
 	MissingHost ErrorMessage = "missing Zulip host"
 	// MissingBotMail from the service URL
 	MissingBotMail ErrorMessage = "missing Bot mail address"
+	// InvalidOverflow if the overflow mode is not one of the supported modes
+	InvalidOverflow ErrorMessage = "invalid overflow mode"
 	// TopicTooLong if topic is more than 60 characters
 	TopicTooLong ErrorMessage = "topic exceeds max length (%d characters): was %d characters"
 )
@@ -0,0 +1,65 @@
+package types
+
+import "strings"
+
+// Action is a button or link that lets the recipients act on a notification, like opening a runbook
+type Action struct {
+	Label string
+	URL   string
+	// Method makes the action send a HTTP request to the URL instead of opening it. HTTP actions are only supported
+	// by services that can send the request (ntfy), and are left out by the others.
+	Method  string
+	Headers map[string]string
+	Body    string
+}
+
+// IsHTTP returns whether the action sends a HTTP request instead of opening the URL
+func (action *Action) IsHTTP() bool {
+	return action.Method != ""
+}
+
+// ItemsActions returns the actions of all the items
+func ItemsActions(items []MessageItem) []Action {
+	var actions []Action
+	for _, item := range items {
+		actions = append(actions, item.Actions...)
+	}
+	return actions
+}
+
+// ItemsWithoutActions returns copies of the items without the actions, used by the services that render the actions
+// natively before joining the items as plain text
+func ItemsWithoutActions(items []MessageItem) []MessageItem {
+	stripped := make([]MessageItem, len(items))
+	for i, item := range items {
+		item.Actions = nil
+		stripped[i] = item
+	}
+	return stripped
+}
+
+// LinkActions returns the actions that open a URL, leaving out the HTTP actions
+func LinkActions(actions []Action) []Action {
+	var links []Action
+	for _, action := range actions {
+		if !action.IsHTTP() {
+			links = append(links, action)
+		}
+	}
+	return links
+}
+
+// ActionsToPlain returns the actions that open a URL as "label: url" lines, used by the services that cannot render
+// the actions natively
+func ActionsToPlain(actions []Action) string {
+	builder := strings.Builder{}
+	for _, action := range LinkActions(actions) {
+		if builder.Len() > 0 {
+			builder.WriteRune('\n')
+		}
+		builder.WriteString(action.Label)
+		builder.WriteString(": ")
+		builder.WriteString(action.URL)
+	}
+	return builder.String()
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrAttachmentsNotSupported is returned when sending attachments using a service that cannot send files
+var ErrAttachmentsNotSupported = errors.New("the service does not support attachments")
+
+// ErrAttachmentTooLarge is returned when an attachment is larger than the service allows
+var ErrAttachmentTooLarge = errors.New("the attachment is too large for the service")
+
+// Attachment is a file sent together with a message item
+type Attachment struct {
+	// Name is the file name shown to the recipients
+	Name string
+	// MimeType is the content type of the file. If empty, it is detected from the Name or the content.
+	MimeType string
+	// Reader is used to read the content if Data is nil. It is only read once, before sending to any service.
+	Reader io.Reader
+	Data   []byte
+}
+
+// AttachmentSender is implemented by the rich senders that can upload the attachments of the message items
+type AttachmentSender interface {
+	RichSender
+	// MaxAttachmentSize returns the max size in bytes of a single attachment, or 0 if it's only limited by the server
+	MaxAttachmentSize() int64
+}
+
+// AttachmentFromFile creates an attachment that reads the content of the file at path
+func AttachmentFromFile(path string) (Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	return Attachment{Name: filepath.Base(path), Data: data}, nil
+}
+
+// ContentType returns the MimeType of the attachment, or the type detected from the name or the content if unset
+func (attachment *Attachment) ContentType() string {
+	if attachment.MimeType != "" {
+		return attachment.MimeType
+	}
+	if byExt := mime.TypeByExtension(filepath.Ext(attachment.Name)); byExt != "" {
+		return byExt
+	}
+	return http.DetectContentType(attachment.Data)
+}
+
+// IsImage returns whether the attachment content is an image
+func (attachment *Attachment) IsImage() bool {
+	return strings.HasPrefix(attachment.ContentType(), "image/")
+}
+
+// ReadAttachments reads the content of every attachment that has a Reader but no Data, so that the items can be sent
+// to several services
+func ReadAttachments(items []MessageItem) error {
+	for i := range items {
+		for a := range items[i].Attachments {
+			attachment := &items[i].Attachments[a]
+			if attachment.Data != nil || attachment.Reader == nil {
+				continue
+			}
+			data, err := io.ReadAll(attachment.Reader)
+			if err != nil {
+				return fmt.Errorf("failed to read attachment %q: %w", attachment.Name, err)
+			}
+			attachment.Data = data
+		}
+	}
+	return nil
+}
+
+// ItemsAttachments returns the attachments of all the items
+func ItemsAttachments(items []MessageItem) []Attachment {
+	var attachments []Attachment
+	for _, item := range items {
+		attachments = append(attachments, item.Attachments...)
+	}
+	return attachments
+}
+
+// CheckAttachments returns an error if the items has attachments that cannot be sent using sender
+func CheckAttachments(sender Sender, items []MessageItem) error {
+	attachments := ItemsAttachments(items)
+	if len(attachments) == 0 {
+		return nil
+	}
+	attachmentSender, ok := sender.(AttachmentSender)
+	if !ok {
+		return ErrAttachmentsNotSupported
+	}
+	maxSize := attachmentSender.MaxAttachmentSize()
+	for _, attachment := range attachments {
+		if maxSize > 0 && int64(len(attachment.Data)) > maxSize {
+			return fmt.Errorf("%w: %q is %d bytes, the max size is %d bytes", ErrAttachmentTooLarge, attachment.Name, len(attachment.Data), maxSize)
+		}
+	}
+	return nil
+}
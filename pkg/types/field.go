@@ -1,6 +1,9 @@
 package types
 
-import "sort"
+import (
+	"sort"
+	"strings"
+)
 
 // Field is a Key/Value pair used for extra data in log messages
 type Field struct {
@@ -21,10 +24,26 @@ func FieldsFromMap(fieldMap map[string]string, sorted bool) []Field {
 		sort.Strings(keys)
 	}
 
-	for i, key := range keys {
-		fields[i].Key = key
-		fields[i].Value = fieldMap[key]
+	for _, key := range keys {
+		fields = append(fields, Field{
+			Key:   key,
+			Value: fieldMap[key],
+		})
 	}
 
 	return fields
 }
+
+// FieldsToPlain returns the fields as "key: value" lines, used by the services that cannot render fields natively
+func FieldsToPlain(fields []Field) string {
+	builder := strings.Builder{}
+	for i, field := range fields {
+		if i > 0 {
+			builder.WriteRune('\n')
+		}
+		builder.WriteString(field.Key)
+		builder.WriteString(": ")
+		builder.WriteString(field.Value)
+	}
+	return builder.String()
+}
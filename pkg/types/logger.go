@@ -0,0 +1,11 @@
+package types
+
+// Logger is a leveled logger, writing messages together with optional alternating keys and values.
+// A StdLogger that also implements Logger, like the adapter returned by logging.Slog, is used as a Logger by the
+// services and the router.
+type Logger interface {
+	Debug(msg string, keyvals ...interface{})
+	Info(msg string, keyvals ...interface{})
+	Warn(msg string, keyvals ...interface{})
+	Error(msg string, keyvals ...interface{})
+}
@@ -39,12 +39,26 @@ func (level MessageLevel) String() string {
 	return messageLevelStrings[level]
 }
 
+// ParseMessageLevel returns the MessageLevel matching the (case-insensitive) name, or false if none matched
+func ParseMessageLevel(name string) (MessageLevel, bool) {
+	for i, levelString := range messageLevelStrings {
+		if strings.EqualFold(levelString, name) {
+			return MessageLevel(i), true
+		}
+	}
+	return Unknown, false
+}
+
 // MessageItem is an entry in a notification being sent by a service
 type MessageItem struct {
 	Text      string
 	Timestamp time.Time
 	Level     MessageLevel
 	Fields    []Field
+	// Attachments are the files sent with the item, by services that implement AttachmentSender
+	Attachments []Attachment
+	// Actions are rendered as buttons by the services that support it, and appended as links by the others
+	Actions []Action
 }
 
 // WithField appends the key/value pair to the message items fields
@@ -56,13 +70,54 @@ func (mi *MessageItem) WithField(key, value string) *MessageItem {
 	return mi
 }
 
-// ItemsToPlain joins together the MessageItems' Text using newlines
+// WithAttachment appends the attachment to the message items attachments
+func (mi *MessageItem) WithAttachment(attachment Attachment) *MessageItem {
+	mi.Attachments = append(mi.Attachments, attachment)
+	return mi
+}
+
+// WithAction appends the action to the message items actions
+func (mi *MessageItem) WithAction(action Action) *MessageItem {
+	mi.Actions = append(mi.Actions, action)
+	return mi
+}
+
+// ItemsToPlain joins together the MessageItems' Text using newlines, with the Fields and Actions of each item
+// following its Text as "key: value" and "label: url" lines.
 // Used implement the rich sender API by redirecting to the plain sender implementation
 func ItemsToPlain(items []MessageItem) string {
 	builder := strings.Builder{}
 	for _, item := range items {
 		builder.WriteString(item.Text)
 		builder.WriteRune('\n')
+		if len(item.Fields) > 0 {
+			builder.WriteString(FieldsToPlain(item.Fields))
+			builder.WriteRune('\n')
+		}
+		if links := ActionsToPlain(item.Actions); links != "" {
+			builder.WriteString(links)
+			builder.WriteRune('\n')
+		}
 	}
 	return builder.String()
 }
+
+// ItemsToText joins together the MessageItems' Text using newlines, without the Fields.
+// Used by the rich senders that renders the fields natively.
+func ItemsToText(items []MessageItem) string {
+	builder := strings.Builder{}
+	for _, item := range items {
+		builder.WriteString(item.Text)
+		builder.WriteRune('\n')
+	}
+	return builder.String()
+}
+
+// ItemsFields returns the fields of all the items
+func ItemsFields(items []MessageItem) []Field {
+	var fields []Field
+	for _, item := range items {
+		fields = append(fields, item.Fields...)
+	}
+	return fields
+}
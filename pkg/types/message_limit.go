@@ -1,5 +1,7 @@
 package types
 
+import "unicode/utf8"
+
 // MessageLimit is used for declaring the payload limits for services upstream APIs
 type MessageLimit struct {
 	ChunkSize      int
@@ -7,4 +9,57 @@ type MessageLimit struct {
 
 	// Maximum number of chunks (including the last chunk for meta data)
 	ChunkCount int
+
+	// Unit used by the service to measure the sizes, runes if not set
+	Unit LengthUnit
+}
+
+// MessageLimiter is implemented by services that have limits on the size of the messages they can send
+type MessageLimiter interface {
+	MessageLimit() MessageLimit
+}
+
+// LengthUnit is the unit used by a service to measure the length of messages
+type LengthUnit int
+
+const (
+	// RuneLength measures the length in unicode code points
+	RuneLength LengthUnit = iota
+	// ByteLength measures the length in UTF-8 encoded bytes
+	ByteLength
+	// UTF16Length measures the length in UTF-16 code units
+	UTF16Length
+)
+
+// Length returns the length of text measured in unit
+func (unit LengthUnit) Length(text string) int {
+	switch unit {
+	case ByteLength:
+		return len(text)
+	case UTF16Length:
+		length := 0
+		for _, r := range text {
+			if r >= 0x10000 {
+				// Runes outside the basic multilingual plane are encoded as surrogate pairs
+				length += 2
+			} else {
+				length++
+			}
+		}
+		return length
+	default:
+		return utf8.RuneCountInString(text)
+	}
+}
+
+// String returns the name of the unit
+func (unit LengthUnit) String() string {
+	switch unit {
+	case ByteLength:
+		return "bytes"
+	case UTF16Length:
+		return "UTF-16 units"
+	default:
+		return "characters"
+	}
 }
@@ -0,0 +1,32 @@
+package types
+
+import "errors"
+
+// ErrUpdateNotSupported is returned when updating or deleting a message using a service (or service config) that
+// cannot change the messages after sending them
+var ErrUpdateNotSupported = errors.New("the service does not support updating messages")
+
+// MessageRef is a service specific reference to a sent message, used to update or delete the message later
+type MessageRef struct {
+	// Channel is the chat, channel or room of the message, if needed by the service to find the message
+	Channel string
+	// ID is the service specific ID of the message
+	ID string
+}
+
+// RefSender is implemented by the services that can return references to the sent messages
+type RefSender interface {
+	Sender
+	// SendRefs sends the message like Send, returning a reference to every message that was sent. A message that is
+	// split into several parts, or sent to several channels, returns a reference for each of them.
+	SendRefs(message string, params *Params) ([]MessageRef, error)
+}
+
+// MessageUpdater is implemented by the services that can edit and delete the messages that have been sent
+type MessageUpdater interface {
+	RefSender
+	// Update replaces the text of the referenced message with message, which must fit in a single message
+	Update(ref MessageRef, message string, params *Params) error
+	// Delete removes the referenced message
+	Delete(ref MessageRef) error
+}
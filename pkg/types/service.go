@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"net/url"
 )
 
@@ -11,3 +12,16 @@ type Service interface {
 	Initialize(serviceURL *url.URL, logger StdLogger) error
 	SetLogger(logger StdLogger)
 }
+
+// ConfigInitializer is implemented by services that can be initialized using a ServiceConfig instead of a URL
+type ConfigInitializer interface {
+	InitializeConfig(config ServiceConfig, logger StdLogger) error
+}
+
+// ContextService is implemented by services that can make their requests using the context of a send
+type ContextService interface {
+	Service
+	// WithContext returns a copy of the service that makes its requests using ctx, canceling them when ctx is done,
+	// and passing it to the Tracer of the HTTP requests
+	WithContext(ctx context.Context) Service
+}
@@ -0,0 +1,43 @@
+package types
+
+import "context"
+
+// Span names used by the shoutrrr tracing hooks
+const (
+	// SpanSend covers sending a notification using a single target, including the interceptors
+	SpanSend = "shoutrrr.send"
+	// SpanAttempt covers sending a notification using the target service, after the interceptors. The router does not
+	// retry sends, so there is a single attempt span for each send span that reaches the service.
+	SpanAttempt = "shoutrrr.attempt"
+	// SpanHTTP covers a single HTTP round-trip made by a service
+	SpanHTTP = "shoutrrr.http"
+)
+
+// Span statuses passed to Span.End. HTTP spans instead use the response status (e.g. "200 OK") if one was received.
+const (
+	SpanStatusOK      = "ok"
+	SpanStatusError   = "error"
+	SpanStatusTimeout = "timeout"
+)
+
+// Tracer is the interface needed to implement to receive tracing callbacks, e.g. for bridging to OpenTelemetry
+type Tracer interface {
+	// StartSpan is called when an operation starts. The returned context is used as the parent of nested spans.
+	StartSpan(ctx context.Context, name string, attributes SpanAttributes) (context.Context, Span)
+}
+
+// Span is an operation started by a Tracer
+type Span interface {
+	// End is called when the operation has completed, with the status and the error, if any
+	End(status string, err error)
+}
+
+// SpanAttributes describes the operation of a Span
+type SpanAttributes struct {
+	// Scheme is the service scheme, or the URL scheme for HTTP spans
+	Scheme string
+	// Target is the router target name, or the URL host for HTTP spans
+	Target string
+	// Method is the request method for HTTP spans
+	Method string
+}
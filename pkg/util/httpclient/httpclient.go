@@ -0,0 +1,118 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dockerutil/shoutrrr/pkg/util/netpolicy"
+	"github.com/dockerutil/shoutrrr/pkg/util/tracing"
+)
+
+// Transport is the transport used by the services once a default destination Policy has been set using
+// netpolicy.SetDefault. It connects using netpolicy.DialContext, enforcing the policy. Requests sent through a proxy
+// (e.g. using the HTTP_PROXY and HTTPS_PROXY environment variables) are only checked against the policy when
+// connecting to the proxy, not to the destination.
+var Transport = newTransport()
+
+func newTransport() *http.Transport {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	netpolicy.Install(transport)
+	return transport
+}
+
+// Client is the http.Client used by the services for their requests, traced using the tracer of the request context
+// (like the tracer of the sending router) or the one set by tracing.SetTracer. Until a default destination Policy is set, it uses the transport of http.DefaultClient (or
+// http.DefaultTransport), so that configuring or mocking those still applies to the services. Once a policy is set,
+// it uses Transport instead.
+var Client = &http.Client{Transport: tracing.Wrap(policyTransport{})}
+
+// policyTransport uses Transport if a default destination Policy has been set, and the default transport otherwise
+type policyTransport struct{}
+
+// RoundTrip executes a single HTTP transaction using the transport matching the default destination Policy
+func (policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if netpolicy.Default() != nil {
+		return Transport.RoundTrip(req)
+	}
+	if http.DefaultClient.Transport != nil {
+		return http.DefaultClient.Transport.RoundTrip(req)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var maxConnsPerHost atomic.Int64
+
+// SetMaxConnsPerHost limits the number of connections per host made using Transport, and the transports created by
+// services afterwards, or 0 for no limit. It should be set before sending.
+func SetMaxConnsPerHost(limit int) {
+	maxConnsPerHost.Store(int64(limit))
+	Transport.MaxConnsPerHost = limit
+}
+
+// MaxConnsPerHost returns the limit set using SetMaxConnsPerHost, used by services creating their own transport
+func MaxConnsPerHost() int {
+	return int(maxConnsPerHost.Load())
+}
+
+// WithContext returns a client that makes the requests of client using ctx, unless they have been created with a
+// context. The transport of client is used at the time of each request, so replacing it (e.g. for mocking) also
+// applies to the returned client.
+func WithContext(ctx context.Context, client *http.Client) *http.Client {
+	return &http.Client{
+		Transport:     &contextTransport{ctx: ctx, client: client},
+		CheckRedirect: client.CheckRedirect,
+		Jar:           client.Jar,
+	}
+}
+
+// contextTransport makes requests without a context using ctx, limited by the Timeout of client
+type contextTransport struct {
+	ctx    context.Context
+	client *http.Client
+}
+
+// RoundTrip executes a single HTTP transaction using the transport of client
+func (transport *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if ctx == context.Background() {
+		ctx = transport.ctx
+	}
+	cancel := context.CancelFunc(func() {})
+	if transport.client.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, transport.client.Timeout)
+	}
+
+	base := transport.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	res, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelBody cancels the context of the request when the response body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
 )
 
 // ContentType is the default mime type for JSON
 const ContentType = "application/json"
 
-// DefaultClient is the singleton instance of jsonclient using http.DefaultClient
+// DefaultClient is the singleton instance of jsonclient using httpclient.Client
 var DefaultClient = NewClient()
 
 // Get fetches url using GET and unmarshals into the passed response using DefaultClient
@@ -31,9 +33,9 @@ type client struct {
 	indent     string
 }
 
-// NewClient returns a new JSON Client using the default http.Client
+// NewClient returns a new JSON Client using the http.Client shared by the services
 func NewClient() Client {
-	return NewWithHTTPClient(http.DefaultClient)
+	return NewWithHTTPClient(httpclient.Client)
 }
 
 // NewWithHTTPClient returns a new JSON Client using the specified http.Client
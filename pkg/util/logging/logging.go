@@ -0,0 +1,105 @@
+// Package logging adapts loggers to the StdLogger and leveled Logger interfaces used by the services and router
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// SlogAdapter writes logs using an slog.Logger. It implements both StdLogger, logging at the Info level, and Logger,
+// so it can be passed anywhere a StdLogger is accepted.
+type SlogAdapter struct {
+	logger *slog.Logger
+}
+
+// Slog returns a SlogAdapter writing logs using logger, or slog.Default() if logger is nil
+func Slog(logger *slog.Logger) *SlogAdapter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogAdapter{logger: logger}
+}
+
+// Print logs the arguments, formatted using fmt.Sprint, at the Info level
+func (adapter *SlogAdapter) Print(v ...interface{}) {
+	adapter.logger.Info(fmt.Sprint(v...))
+}
+
+// Printf logs the arguments, formatted using fmt.Sprintf, at the Info level
+func (adapter *SlogAdapter) Printf(format string, v ...interface{}) {
+	adapter.logger.Info(strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"))
+}
+
+// Println logs the arguments, formatted using fmt.Sprintln, at the Info level
+func (adapter *SlogAdapter) Println(v ...interface{}) {
+	adapter.logger.Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Debug logs msg and the key-value pairs at the Debug level
+func (adapter *SlogAdapter) Debug(msg string, keyvals ...interface{}) {
+	adapter.logger.Debug(msg, keyvals...)
+}
+
+// Info logs msg and the key-value pairs at the Info level
+func (adapter *SlogAdapter) Info(msg string, keyvals ...interface{}) {
+	adapter.logger.Info(msg, keyvals...)
+}
+
+// Warn logs msg and the key-value pairs at the Warn level
+func (adapter *SlogAdapter) Warn(msg string, keyvals ...interface{}) {
+	adapter.logger.Warn(msg, keyvals...)
+}
+
+// Error logs msg and the key-value pairs at the Error level
+func (adapter *SlogAdapter) Error(msg string, keyvals ...interface{}) {
+	adapter.logger.Error(msg, keyvals...)
+}
+
+// Leveled returns logger if it implements Logger. Otherwise, it returns a shim that prints messages of every level
+// using logger, with the key-value pairs appended as key=value.
+func Leveled(logger types.StdLogger) types.Logger {
+	if leveled, ok := logger.(types.Logger); ok {
+		return leveled
+	}
+	return stdShim{logger: logger}
+}
+
+type stdShim struct {
+	logger types.StdLogger
+}
+
+func (shim stdShim) Debug(msg string, keyvals ...interface{}) { shim.print(msg, keyvals) }
+func (shim stdShim) Info(msg string, keyvals ...interface{})  { shim.print(msg, keyvals) }
+func (shim stdShim) Warn(msg string, keyvals ...interface{})  { shim.print(msg, keyvals) }
+func (shim stdShim) Error(msg string, keyvals ...interface{}) { shim.print(msg, keyvals) }
+
+func (shim stdShim) print(msg string, keyvals []interface{}) {
+	shim.logger.Print(Format(msg, keyvals...))
+}
+
+// Format returns msg followed by the key-value pairs as key=value, quoting values that contain spaces or quotes.
+// A key without a value is written as !BADKEY=key, like slog does.
+func Format(msg string, keyvals ...interface{}) string {
+	sb := strings.Builder{}
+	sb.WriteString(msg)
+	for i := 0; i < len(keyvals); i += 2 {
+		key, value := fmt.Sprint(keyvals[i]), ""
+		if i+1 < len(keyvals) {
+			value = fmt.Sprint(keyvals[i+1])
+		} else {
+			key, value = "!BADKEY", key
+		}
+		if strings.ContainsAny(value, " \t\n\"=") || value == "" {
+			value = strconv.Quote(value)
+		}
+		sb.WriteByte(' ')
+		sb.WriteString(key)
+		sb.WriteByte('=')
+		sb.WriteString(value)
+	}
+	return sb.String()
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogging(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr Logging Suite")
+}
+
+var _ = Describe("the logging adapters", func() {
+	Describe("the slog adapter", func() {
+		var buffer *bytes.Buffer
+		var adapter *SlogAdapter
+		BeforeEach(func() {
+			buffer = &bytes.Buffer{}
+			adapter = Slog(slog.New(slog.NewJSONHandler(buffer, &slog.HandlerOptions{Level: slog.LevelDebug})))
+		})
+
+		It("should log the leveled messages with their attributes", func() {
+			adapter.Warn("Retrying failed send", "target", "ops", "attempt", 1)
+			record := decodeRecord(buffer)
+			Expect(record).To(HaveKeyWithValue("level", "WARN"))
+			Expect(record).To(HaveKeyWithValue("msg", "Retrying failed send"))
+			Expect(record).To(HaveKeyWithValue("target", "ops"))
+			Expect(record).To(HaveKeyWithValue("attempt", BeNumerically("==", 1)))
+		})
+		It("should log the Print methods at the info level", func() {
+			adapter.Printf("Sending to %v\n", "ops")
+			record := decodeRecord(buffer)
+			Expect(record).To(HaveKeyWithValue("level", "INFO"))
+			Expect(record).To(HaveKeyWithValue("msg", "Sending to ops"))
+		})
+		It("should be used as a leveled logger", func() {
+			var std types.StdLogger = adapter
+			Expect(Leveled(std)).To(BeIdenticalTo(adapter))
+		})
+	})
+
+	Describe("the StdLogger shim", func() {
+		It("should print the messages of every level with the key-value pairs", func() {
+			output := &strings.Builder{}
+			logger := Leveled(log.New(output, "", 0))
+			logger.Debug("Server response", "body", "ok")
+			logger.Error("Failed to send", "error", errors.New("timed out"))
+			Expect(output.String()).To(Equal("Server response body=ok\nFailed to send error=\"timed out\"\n"))
+		})
+	})
+
+	Describe("formatting key-value pairs", func() {
+		It("should quote values that need it", func() {
+			Expect(Format("msg", "a", 1, "b", "", "c", `say "hi"`)).To(Equal(`msg a=1 b="" c="say \"hi\""`))
+		})
+		It("should mark a key without a value", func() {
+			Expect(Format("msg", "a")).To(Equal("msg !BADKEY=a"))
+		})
+	})
+})
+
+func decodeRecord(buffer *bytes.Buffer) map[string]interface{} {
+	record := map[string]interface{}{}
+	Expect(json.Unmarshal(buffer.Bytes(), &record)).To(Succeed())
+	return record
+}
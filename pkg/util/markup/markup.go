@@ -0,0 +1,92 @@
+// Package markup converts messages written using a subset of CommonMark to the markup used by the services
+package markup
+
+import (
+	"github.com/dockerutil/shoutrrr/pkg/format"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// Format is the markup used by a message
+type Format int
+
+type formatVals struct {
+	Plain    Format
+	Markdown Format
+	Enum     types.EnumFormatter
+}
+
+// Formats is the enum helper for populating the Format config fields
+var Formats = &formatVals{
+	Plain:    0,
+	Markdown: 1,
+	Enum: format.CreateEnumFormatter(
+		[]string{
+			"Plain",
+			"Markdown",
+		}),
+}
+
+func (f Format) String() string {
+	return Formats.Enum.Print(int(f))
+}
+
+// Param is the key of the param setting the markup used by the message
+const Param = "format"
+
+// FromParams returns the format set by the "format" param, or fallback if it's not set or invalid.
+// It is used by the services that does not update their config from the params.
+func FromParams(params *types.Params, fallback Format) Format {
+	if params == nil {
+		return fallback
+	}
+	if value, found := (*params)[Param]; found {
+		if parsed := Formats.Enum.Parse(value); parsed != format.EnumInvalid {
+			return Format(parsed)
+		}
+	}
+	return fallback
+}
+
+// WithoutParam returns a copy of params without the "format" param. It is used by the services rendering markdown
+// natively, which accept the param but send the message unchanged.
+func WithoutParam(params *types.Params) *types.Params {
+	if params == nil {
+		return nil
+	}
+	if _, found := (*params)[Param]; !found {
+		return params
+	}
+	stripped := make(types.Params, len(*params))
+	for key, value := range *params {
+		if key != Param {
+			stripped[key] = value
+		}
+	}
+	return &stripped
+}
+
+// Plain converts markdown to plain text, removing the markup. Links are written as the text followed by the URL.
+func Plain(markdown string) string {
+	return render(markdown, &plainFlavor)
+}
+
+// HTML converts markdown to HTML, escaping the text
+func HTML(markdown string) string {
+	return render(markdown, &htmlFlavor)
+}
+
+// TelegramHTML converts markdown to the subset of HTML supported by the Telegram bot API
+func TelegramHTML(markdown string) string {
+	return render(markdown, &telegramFlavor)
+}
+
+// Discord converts markdown to the Markdown flavour used by Discord, which uses underscores for underline instead of
+// bold, and only supports three heading levels
+func Discord(markdown string) string {
+	return render(markdown, &discordFlavor)
+}
+
+// Slack converts markdown to Slack mrkdwn, which is also used by Google Chat and Rocket.Chat
+func Slack(markdown string) string {
+	return render(markdown, &slackFlavor)
+}
@@ -0,0 +1,310 @@
+package markup
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type blockKind int
+
+const (
+	paragraphBlock blockKind = iota
+	headingBlock
+	codeBlock
+	quoteBlock
+	listItemBlock
+)
+
+// block is a top level element of a message. For code blocks text is the code, for the other blocks it's the inline
+// markdown, with the lines joined by newlines.
+type block struct {
+	kind blockKind
+	// level is the heading level, or the number of ordered list items (0 for bullet list items)
+	level int
+	lang  string
+	text  string
+}
+
+type inlineKind int
+
+const (
+	textInline inlineKind = iota
+	boldInline
+	italicInline
+	strikeInline
+	codeInline
+	linkInline
+	breakInline
+)
+
+type inline struct {
+	kind     inlineKind
+	text     string
+	url      string
+	children []inline
+}
+
+var (
+	headingPattern     = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	bulletItemPattern  = regexp.MustCompile(`^[ \t]{0,3}[-*+][ \t]+(.*)$`)
+	orderedItemPattern = regexp.MustCompile(`^[ \t]{0,3}(\d{1,9})[.)][ \t]+(.*)$`)
+	quotePattern       = regexp.MustCompile(`^[ \t]{0,3}>[ \t]?(.*)$`)
+	fencePattern       = regexp.MustCompile("^[ \t]{0,3}(```+|~~~+)[ \t]*([^` \t]*)")
+)
+
+func parseBlocks(markdown string) []block {
+	var blocks []block
+	var fence string
+	// open is true if the last block can be continued by the next line
+	open := false
+
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if fence != "" {
+			current := &blocks[len(blocks)-1]
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				continue
+			}
+			if current.text != "" || current.level > 0 {
+				current.text += "\n"
+			}
+			current.text += line
+			// level marks that the code block has at least one line, even if it's empty
+			current.level = 1
+			continue
+		}
+
+		if match := fencePattern.FindStringSubmatch(line); match != nil {
+			fence = match[1]
+			blocks = append(blocks, block{kind: codeBlock, lang: match[2]})
+			open = false
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			open = false
+			continue
+		}
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			blocks = append(blocks, block{kind: headingBlock, level: len(match[1]), text: match[2]})
+			open = false
+			continue
+		}
+
+		if match := quotePattern.FindStringSubmatch(line); match != nil {
+			if last := len(blocks) - 1; open && blocks[last].kind == quoteBlock {
+				blocks[last].text += "\n" + match[1]
+			} else {
+				blocks = append(blocks, block{kind: quoteBlock, text: match[1]})
+			}
+			open = true
+			continue
+		}
+
+		if match := bulletItemPattern.FindStringSubmatch(line); match != nil {
+			blocks = append(blocks, block{kind: listItemBlock, text: match[1]})
+			open = true
+			continue
+		}
+
+		if match := orderedItemPattern.FindStringSubmatch(line); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			blocks = append(blocks, block{kind: listItemBlock, level: number, text: match[2]})
+			open = true
+			continue
+		}
+
+		if last := len(blocks) - 1; open && blocks[last].kind != quoteBlock {
+			blocks[last].text += "\n" + strings.TrimSpace(line)
+			continue
+		}
+
+		blocks = append(blocks, block{kind: paragraphBlock, text: line})
+		open = true
+	}
+
+	return blocks
+}
+
+// parseInlines parses the inline markup of text
+func parseInlines(text string) []inline {
+	var nodes []inline
+	var plain strings.Builder
+
+	addText := func() {
+		if plain.Len() > 0 {
+			nodes = append(nodes, inline{kind: textInline, text: plain.String()})
+			plain.Reset()
+		}
+	}
+	addNode := func(node inline) {
+		addText()
+		nodes = append(nodes, node)
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\\' && i+1 < len(text) && isASCIIPunct(text[i+1]):
+			plain.WriteByte(text[i+1])
+			i += 2
+			continue
+
+		case c == '\n':
+			addNode(inline{kind: breakInline})
+			i++
+			continue
+
+		case c == '`':
+			ticks := countRun(text[i:], '`')
+			if end := strings.Index(text[i+ticks:], text[i:i+ticks]); end >= 0 {
+				code := text[i+ticks : i+ticks+end]
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' {
+					code = code[1 : len(code)-1]
+				}
+				addNode(inline{kind: codeInline, text: code})
+				i += ticks*2 + end
+				continue
+			}
+			plain.WriteString(text[i : i+ticks])
+			i += ticks
+			continue
+
+		case strings.HasPrefix(text[i:], "**") || strings.HasPrefix(text[i:], "__") || strings.HasPrefix(text[i:], "~~"):
+			delimiter := text[i : i+2]
+			if end := findClosing(text, i+2, delimiter); end > i+2 {
+				kind := boldInline
+				if delimiter == "~~" {
+					kind = strikeInline
+				}
+				addNode(inline{kind: kind, children: parseInlines(text[i+2 : end])})
+				i = end + 2
+				continue
+			}
+
+		case c == '*' || c == '_':
+			if c == '_' && i > 0 && isWordByte(text, i-1) {
+				break
+			}
+			if end := findClosing(text, i+1, string(c)); end > i+1 {
+				if c != '_' || end+1 >= len(text) || !isWordByte(text, end+1) {
+					addNode(inline{kind: italicInline, children: parseInlines(text[i+1 : end])})
+					i = end + 1
+					continue
+				}
+			}
+
+		case c == '[':
+			if node, length, ok := parseLink(text[i:]); ok {
+				addNode(node)
+				i += length
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(text[i:], '>'); end > 0 {
+				target := text[i+1 : i+end]
+				if (strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") ||
+					strings.HasPrefix(target, "mailto:")) && !strings.ContainsAny(target, " \t\n<") {
+					addNode(inline{kind: linkInline, url: target, children: []inline{{kind: textInline, text: target}}})
+					i += end + 1
+					continue
+				}
+			}
+		}
+
+		plain.WriteByte(c)
+		i++
+	}
+
+	addText()
+	return nodes
+}
+
+// parseLink parses a link in the form [text](url) at the start of text, returning the node and its length
+func parseLink(text string) (inline, int, bool) {
+	depth := 0
+	closing := -1
+	for i := 0; i < len(text) && closing < 0; i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				closing = i
+			}
+		}
+	}
+	if closing < 0 || closing+1 >= len(text) || text[closing+1] != '(' {
+		return inline{}, 0, false
+	}
+
+	end := strings.IndexByte(text[closing+2:], ')')
+	if end < 0 {
+		return inline{}, 0, false
+	}
+	url := strings.TrimSpace(text[closing+2 : closing+2+end])
+	if url == "" || strings.ContainsAny(url, " \t\n") {
+		return inline{}, 0, false
+	}
+
+	return inline{
+		kind:     linkInline,
+		url:      strings.Trim(url, "<>"),
+		children: parseInlines(text[1:closing]),
+	}, closing + 3 + end, true
+}
+
+// findClosing returns the index of the delimiter closing an emphasis starting at start, or -1 if it's not closed
+func findClosing(text string, start int, delimiter string) int {
+	if start >= len(text) || text[start] == ' ' {
+		return -1
+	}
+	for i := start; i < len(text); i++ {
+		switch {
+		case text[i] == '\\':
+			i++
+		case text[i] == '`':
+			// Code spans takes precedence over emphasis
+			ticks := countRun(text[i:], '`')
+			if end := strings.Index(text[i+ticks:], text[i:i+ticks]); end >= 0 {
+				i += ticks*2 + end - 1
+			}
+		case strings.HasPrefix(text[i:], delimiter) && text[i-1] != ' ':
+			if len(delimiter) == 1 && i+1 < len(text) && text[i+1] == delimiter[0] {
+				// Skip double delimiters when looking for a single one
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func countRun(text string, c byte) int {
+	count := 0
+	for count < len(text) && text[count] == c {
+		count++
+	}
+	return count
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < utf8.RuneSelf && unicode.IsPunct(rune(c)) || strings.IndexByte("$+<=>^`|~", c) >= 0
+}
+
+func isWordByte(text string, index int) bool {
+	r, _ := utf8.DecodeRuneInString(text[index:])
+	if r == utf8.RuneError {
+		r, _ = utf8.DecodeLastRuneInString(text[:index+1])
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,269 @@
+package markup
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// flavor contains the functions used to render the parsed markdown using a specific markup
+type flavor struct {
+	escape    func(text string) string
+	bold      func(content string) string
+	italic    func(content string) string
+	strike    func(content string) string
+	code      func(code string) string
+	link      func(content string, url string) string
+	lineBreak string
+	paragraph func(content string) string
+	heading   func(level int, content string) string
+	codeBlock func(lang string, code string) string
+	quote     func(content string) string
+	list      func(ordered bool, items []string) string
+	// blockSeparator is written between the blocks
+	blockSeparator string
+}
+
+func render(markdown string, f *flavor) string {
+	blocks := parseBlocks(markdown)
+	rendered := make([]string, 0, len(blocks))
+
+	for i := 0; i < len(blocks); i++ {
+		b := blocks[i]
+		switch b.kind {
+		case headingBlock:
+			rendered = append(rendered, f.heading(b.level, renderInlines(parseInlines(b.text), f)))
+		case codeBlock:
+			rendered = append(rendered, f.codeBlock(b.lang, b.text))
+		case quoteBlock:
+			rendered = append(rendered, f.quote(renderInlines(parseInlines(b.text), f)))
+		case listItemBlock:
+			ordered := b.level > 0
+			var items []string
+			for ; i < len(blocks) && blocks[i].kind == listItemBlock && (blocks[i].level > 0) == ordered; i++ {
+				items = append(items, renderInlines(parseInlines(blocks[i].text), f))
+			}
+			i--
+			rendered = append(rendered, f.list(ordered, items))
+		default:
+			rendered = append(rendered, f.paragraph(renderInlines(parseInlines(b.text), f)))
+		}
+	}
+
+	return strings.Join(rendered, f.blockSeparator)
+}
+
+func renderInlines(nodes []inline, f *flavor) string {
+	var sb strings.Builder
+	for _, node := range nodes {
+		switch node.kind {
+		case boldInline:
+			sb.WriteString(f.bold(renderInlines(node.children, f)))
+		case italicInline:
+			sb.WriteString(f.italic(renderInlines(node.children, f)))
+		case strikeInline:
+			sb.WriteString(f.strike(renderInlines(node.children, f)))
+		case codeInline:
+			sb.WriteString(f.code(node.text))
+		case linkInline:
+			sb.WriteString(f.link(renderInlines(node.children, f), node.url))
+		case breakInline:
+			sb.WriteString(f.lineBreak)
+		default:
+			sb.WriteString(f.escape(node.text))
+		}
+	}
+	return sb.String()
+}
+
+func identity(text string) string {
+	return text
+}
+
+func wrapWith(before, after string) func(string) string {
+	return func(content string) string {
+		return before + content + after
+	}
+}
+
+// prefixLines prefixes every line of content
+func prefixLines(prefix string, content string) string {
+	return prefix + strings.ReplaceAll(content, "\n", "\n"+prefix)
+}
+
+// textList renders list items as lines, using bullets or the item numbers
+func textList(ordered bool, items []string) string {
+	lines := make([]string, len(items))
+	for i, item := range items {
+		marker := "•"
+		if ordered {
+			marker = fmt.Sprintf("%d.", i+1)
+		}
+		lines[i] = marker + " " + strings.ReplaceAll(item, "\n", "\n  ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+var plainFlavor = flavor{
+	escape: identity,
+	bold:   identity,
+	italic: identity,
+	strike: identity,
+	code:   identity,
+	link: func(content string, url string) string {
+		if content == url || strings.TrimPrefix(url, "mailto:") == content {
+			return content
+		}
+		return content + " (" + url + ")"
+	},
+	lineBreak:      "\n",
+	paragraph:      identity,
+	heading:        func(_ int, content string) string { return content },
+	codeBlock:      func(_ string, code string) string { return code },
+	quote:          func(content string) string { return prefixLines("> ", content) },
+	list:           textList,
+	blockSeparator: "\n\n",
+}
+
+var htmlFlavor = flavor{
+	escape: html.EscapeString,
+	bold:   wrapWith("<strong>", "</strong>"),
+	italic: wrapWith("<em>", "</em>"),
+	strike: wrapWith("<del>", "</del>"),
+	code: func(code string) string {
+		return "<code>" + html.EscapeString(code) + "</code>"
+	},
+	link: func(content string, url string) string {
+		return `<a href="` + html.EscapeString(url) + `">` + content + "</a>"
+	},
+	lineBreak: "<br>\n",
+	paragraph: wrapWith("<p>", "</p>"),
+	heading: func(level int, content string) string {
+		return fmt.Sprintf("<h%d>%s</h%d>", level, content, level)
+	},
+	codeBlock: func(lang string, code string) string {
+		if lang != "" {
+			return `<pre><code class="language-` + html.EscapeString(lang) + `">` + html.EscapeString(code) + "</code></pre>"
+		}
+		return "<pre><code>" + html.EscapeString(code) + "</code></pre>"
+	},
+	quote: wrapWith("<blockquote>", "</blockquote>"),
+	list: func(ordered bool, items []string) string {
+		tag := "ul"
+		if ordered {
+			tag = "ol"
+		}
+		return "<" + tag + "><li>" + strings.Join(items, "</li><li>") + "</li></" + tag + ">"
+	},
+	blockSeparator: "\n",
+}
+
+var telegramFlavor = flavor{
+	escape: html.EscapeString,
+	bold:   wrapWith("<b>", "</b>"),
+	italic: wrapWith("<i>", "</i>"),
+	strike: wrapWith("<s>", "</s>"),
+	code: func(code string) string {
+		return "<code>" + html.EscapeString(code) + "</code>"
+	},
+	link: func(content string, url string) string {
+		return `<a href="` + html.EscapeString(url) + `">` + content + "</a>"
+	},
+	lineBreak: "\n",
+	paragraph: identity,
+	heading: func(_ int, content string) string {
+		return "<b>" + content + "</b>"
+	},
+	codeBlock: func(lang string, code string) string {
+		if lang != "" {
+			return `<pre><code class="language-` + html.EscapeString(lang) + `">` + html.EscapeString(code) + "</code></pre>"
+		}
+		return "<pre>" + html.EscapeString(code) + "</pre>"
+	},
+	quote:          wrapWith("<blockquote>", "</blockquote>"),
+	list:           textList,
+	blockSeparator: "\n\n",
+}
+
+// slackEscaper escapes the control characters of Slack mrkdwn
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackTextEscaper also escapes the formatting characters in the text. As mrkdwn has no escape sequence, a zero-width
+// space is written after them, so that they can not start a formatted span.
+var slackTextEscaper = strings.NewReplacer(
+	"&", "&amp;", "<", "&lt;", ">", "&gt;", "*", "*\u200b", "_", "_\u200b", "~", "~\u200b", "`", "`\u200b",
+)
+
+var slackFlavor = flavor{
+	escape: slackTextEscaper.Replace,
+	bold:   wrapWith("*", "*"),
+	italic: wrapWith("_", "_"),
+	strike: wrapWith("~", "~"),
+	code: func(code string) string {
+		return "`" + slackEscaper.Replace(code) + "`"
+	},
+	link: func(content string, url string) string {
+		if content == slackTextEscaper.Replace(url) {
+			content = ""
+		}
+		url = slackEscaper.Replace(strings.ReplaceAll(url, "|", "%7C"))
+		if content == "" {
+			return "<" + url + ">"
+		}
+		return "<" + url + "|" + content + ">"
+	},
+	lineBreak: "\n",
+	paragraph: identity,
+	heading:   func(_ int, content string) string { return "*" + content + "*" },
+	codeBlock: func(_ string, code string) string {
+		return "```\n" + slackEscaper.Replace(code) + "\n```"
+	},
+	quote:          func(content string) string { return prefixLines("> ", content) },
+	list:           textList,
+	blockSeparator: "\n\n",
+}
+
+// discordEscaper escapes the characters that Discord would otherwise treat as markup
+var discordEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, "[", `\[`, "]", `\]`,
+)
+
+var discordFlavor = flavor{
+	escape: discordEscaper.Replace,
+	bold:   wrapWith("**", "**"),
+	italic: wrapWith("*", "*"),
+	strike: wrapWith("~~", "~~"),
+	code: func(code string) string {
+		if strings.Contains(code, "`") {
+			return "`` " + code + " ``"
+		}
+		return "`" + code + "`"
+	},
+	link: func(content string, url string) string {
+		if content == discordEscaper.Replace(url) {
+			return url
+		}
+		return "[" + content + "](<" + url + ">)"
+	},
+	lineBreak: "\n",
+	paragraph: identity,
+	heading: func(level int, content string) string {
+		return strings.Repeat("#", min(level, 3)) + " " + content
+	},
+	codeBlock: func(lang string, code string) string {
+		return "```" + lang + "\n" + code + "\n```"
+	},
+	quote: func(content string) string { return prefixLines("> ", content) },
+	list: func(ordered bool, items []string) string {
+		lines := make([]string, len(items))
+		for i, item := range items {
+			marker := "-"
+			if ordered {
+				marker = fmt.Sprintf("%d.", i+1)
+			}
+			lines[i] = marker + " " + strings.ReplaceAll(item, "\n", "\n  ")
+		}
+		return strings.Join(lines, "\n")
+	},
+	blockSeparator: "\n\n",
+}
@@ -0,0 +1,138 @@
+package markup
+
+import (
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMarkup(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr Markup Suite")
+}
+
+const message = "# Deploy *done*\n" +
+	"\n" +
+	"The **build** of `api_server` passed, see [the logs](https://ci.example.com/?a=1&b=2).\n" +
+	"Tom & <Jerry>\n" +
+	"\n" +
+	"- one\n" +
+	"- ~~two~~\n" +
+	"\n" +
+	"> quoted\n" +
+	"\n" +
+	"```go\n" +
+	"if a < b {}\n" +
+	"```"
+
+var _ = Describe("the markup conversion", func() {
+	It("should convert markdown to plain text", func() {
+		Expect(Plain(message)).To(Equal("Deploy done\n" +
+			"\n" +
+			"The build of api_server passed, see the logs (https://ci.example.com/?a=1&b=2).\n" +
+			"Tom & <Jerry>\n" +
+			"\n" +
+			"• one\n" +
+			"• two\n" +
+			"\n" +
+			"> quoted\n" +
+			"\n" +
+			"if a < b {}"))
+	})
+	It("should convert markdown to escaped HTML", func() {
+		Expect(HTML(message)).To(Equal("<h1>Deploy <em>done</em></h1>\n" +
+			"<p>The <strong>build</strong> of <code>api_server</code> passed, see " +
+			`<a href="https://ci.example.com/?a=1&amp;b=2">the logs</a>.<br>` + "\n" +
+			"Tom &amp; &lt;Jerry&gt;</p>\n" +
+			"<ul><li>one</li><li><del>two</del></li></ul>\n" +
+			"<blockquote>quoted</blockquote>\n" +
+			`<pre><code class="language-go">if a &lt; b {}</code></pre>`))
+	})
+	It("should convert markdown to Telegram HTML", func() {
+		Expect(TelegramHTML(message)).To(Equal("<b>Deploy <i>done</i></b>\n" +
+			"\n" +
+			"The <b>build</b> of <code>api_server</code> passed, see " +
+			`<a href="https://ci.example.com/?a=1&amp;b=2">the logs</a>.` + "\n" +
+			"Tom &amp; &lt;Jerry&gt;\n" +
+			"\n" +
+			"• one\n" +
+			"• <s>two</s>\n" +
+			"\n" +
+			"<blockquote>quoted</blockquote>\n" +
+			"\n" +
+			`<pre><code class="language-go">if a &lt; b {}</code></pre>`))
+	})
+	It("should convert markdown to Discord markdown", func() {
+		Expect(Discord("#### __Deploy__ _done_\n\n1. see <https://example.com/a_b> or [logs](https://example.com)\n\n" +
+			"snake\\_case")).To(Equal("### **Deploy** *done*\n" +
+			"\n" +
+			"1. see https://example.com/a_b or [logs](<https://example.com>)\n" +
+			"\n" +
+			"snake\\_case"))
+	})
+	It("should convert markdown to Slack mrkdwn", func() {
+		Expect(Slack(message)).To(Equal("*Deploy _done_*\n" +
+			"\n" +
+			"The *build* of `api_server` passed, see <https://ci.example.com/?a=1&amp;b=2|the logs>.\n" +
+			"Tom &amp; &lt;Jerry&gt;\n" +
+			"\n" +
+			"• one\n" +
+			"• ~two~\n" +
+			"\n" +
+			"> quoted\n" +
+			"\n" +
+			"```\nif a &lt; b {}\n```"))
+	})
+
+	When("parsing inline markup", func() {
+		It("should not treat underscores inside words as emphasis", func() {
+			Expect(Plain("snake_case_name and _italic_")).To(Equal("snake_case_name and italic"))
+			Expect(HTML("snake_case_name and _italic_")).To(Equal("<p>snake_case_name and <em>italic</em></p>"))
+		})
+		It("should keep escaped and unclosed delimiters", func() {
+			Expect(Plain(`\*not emphasis\* and 2 * 3 **`)).To(Equal("*not emphasis* and 2 * 3 **"))
+		})
+		It("should not format escaped delimiters in Slack mrkdwn", func() {
+			Expect(Slack(`\*not bold\* and snake\_case`)).
+				To(Equal("*\u200bnot bold*\u200b and snake_\u200bcase"))
+			Expect(Slack("<https://example.com/a_b>")).To(Equal("<https://example.com/a_b>"))
+		})
+		It("should not parse markup inside code spans", func() {
+			Expect(HTML("`**not bold**`")).To(Equal("<p><code>**not bold**</code></p>"))
+		})
+		It("should support autolinks and nested markup in links", func() {
+			Expect(Slack("<https://example.com> [**bold** link](https://example.com/a)")).
+				To(Equal("<https://example.com> <https://example.com/a|*bold* link>"))
+			Expect(Plain("<https://example.com>")).To(Equal("https://example.com"))
+		})
+		It("should not treat brackets without an URL as a link", func() {
+			Expect(Plain("[x] done [y] (not a link)")).To(Equal("[x] done [y] (not a link)"))
+		})
+	})
+
+	When("parsing blocks", func() {
+		It("should number ordered lists and continue items on the next line", func() {
+			Expect(Plain("3. first\ncontinued\n4. second")).To(Equal("1. first\n  continued\n2. second"))
+		})
+		It("should keep the content of unclosed code blocks", func() {
+			Expect(HTML("```\n# not a heading\n\n*x*")).To(Equal("<pre><code># not a heading\n\n*x*</code></pre>"))
+		})
+	})
+
+	When("getting the format from params", func() {
+		It("should use the param if it's valid", func() {
+			Expect(FromParams(&types.Params{"format": "markdown"}, Formats.Plain)).To(Equal(Formats.Markdown))
+			Expect(FromParams(&types.Params{"format": "invalid"}, Formats.Plain)).To(Equal(Formats.Plain))
+			Expect(FromParams(nil, Formats.Markdown)).To(Equal(Formats.Markdown))
+		})
+		It("should remove the param without changing the params", func() {
+			params := types.Params{"format": "markdown", "title": "x"}
+			Expect(WithoutParam(&params)).To(Equal(&types.Params{"title": "x"}))
+			Expect(params).To(HaveKey("format"))
+			Expect(WithoutParam(nil)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,213 @@
+package netpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ErrDenied is returned (wrapped) when a connection to a destination is not allowed by the Policy
+var ErrDenied = errors.New("destination not allowed")
+
+// Policy restricts the destinations that connections can be made to.
+// AllowHosts and DenyHosts entries are either host names, where a leading "*." matches any subdomain, or IP
+// addresses/CIDR ranges that are matched against the resolved address.
+type Policy struct {
+	// AllowPrivate permits connecting to private, loopback and link-local addresses
+	AllowPrivate bool `mapstructure:"allowprivate"`
+	// AllowHosts, if not empty, are the only destinations that can be connected to. Matching destinations are
+	// allowed even if they resolve to a private address.
+	AllowHosts []string `mapstructure:"allowhosts"`
+	// DenyHosts are destinations that can never be connected to
+	DenyHosts []string `mapstructure:"denyhosts"`
+}
+
+// hostList is a parsed list of Policy host entries
+type hostList struct {
+	names []string
+	nets  []*net.IPNet
+}
+
+func parseHostList(entries []string) hostList {
+	list := hostList{}
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry), "."))
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			list.nets = append(list.nets, ipNet)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			list.nets = append(list.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		} else if entry != "" {
+			list.names = append(list.names, entry)
+		}
+	}
+	return list
+}
+
+func (list hostList) empty() bool {
+	return len(list.names) == 0 && len(list.nets) == 0
+}
+
+func (list hostList) matchesName(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, name := range list.names {
+		if suffix, wildcard := strings.CutPrefix(name, "*"); wildcard {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (list hostList) matchesIP(ip net.IP) bool {
+	for _, ipNet := range list.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guard enforces a Policy when dialing connections
+type Guard struct {
+	policy Policy
+	allow  hostList
+	deny   hostList
+	dialer net.Dialer
+}
+
+// NewGuard returns a new Guard enforcing policy
+func NewGuard(policy Policy) *Guard {
+	return &Guard{
+		policy: policy,
+		allow:  parseHostList(policy.AllowHosts),
+		deny:   parseHostList(policy.DenyHosts),
+		dialer: net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		},
+	}
+}
+
+// CheckHost returns an error if host is denied by name, without resolving it
+func (guard *Guard) CheckHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return guard.checkIP(ip, false)
+	}
+	if guard.deny.matchesName(host) {
+		return fmt.Errorf("%w: %v is denied", ErrDenied, host)
+	}
+	if !guard.allow.empty() && len(guard.allow.nets) == 0 && !guard.allow.matchesName(host) {
+		return fmt.Errorf("%w: %v is not in the allowed hosts", ErrDenied, host)
+	}
+	return nil
+}
+
+// checkIP returns an error if a connection to ip is not allowed. If allowedByName is true, the host name matched
+// the allowed hosts, and only the denied hosts are checked.
+func (guard *Guard) checkIP(ip net.IP, allowedByName bool) error {
+	if guard.deny.matchesIP(ip) {
+		return fmt.Errorf("%w: %v is denied", ErrDenied, ip)
+	}
+	if allowedByName || guard.allow.matchesIP(ip) {
+		return nil
+	}
+	if !guard.allow.empty() {
+		return fmt.Errorf("%w: %v is not in the allowed hosts", ErrDenied, ip)
+	}
+	if !guard.policy.AllowPrivate && IsInternal(ip) {
+		return fmt.Errorf("%w: %v is a private address", ErrDenied, ip)
+	}
+	return nil
+}
+
+// DialContext connects to addr, checking the host name before resolving it, and the resolved address before
+// connecting. Checking the address that is actually used prevents DNS rebinding from circumventing the policy.
+func (guard *Guard) DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := guard.CheckHost(host); err != nil {
+		return nil, err
+	}
+	allowedByName := guard.allow.matchesName(host)
+
+	dialer := guard.dialer
+	dialer.Control = func(_ string, address string, _ syscall.RawConn) error {
+		ipString, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		ip := net.ParseIP(ipString)
+		if ip == nil {
+			return fmt.Errorf("%w: invalid address %q", ErrDenied, address)
+		}
+		return guard.checkIP(ip, allowedByName)
+	}
+
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// IsInternal returns whether ip is a loopback, private, link-local or unspecified address
+func IsInternal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+var (
+	defaultGuard  atomic.Pointer[Guard]
+	defaultDialer = &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	installed     []*http.Transport
+	installedLock sync.Mutex
+)
+
+// SetDefault sets the Policy enforced by DialContext, which is used by the transports passed to Install (such as the
+// one shared by the services) and by the services that does not use HTTP. Passing nil removes the policy.
+func SetDefault(policy *Policy) {
+	if policy == nil {
+		defaultGuard.Store(nil)
+		return
+	}
+	defaultGuard.Store(NewGuard(*policy))
+
+	// Connections made before the policy was set must not be reused
+	installedLock.Lock()
+	defer installedLock.Unlock()
+	for _, transport := range installed {
+		transport.CloseIdleConnections()
+	}
+}
+
+// Default returns the Guard enforcing the default Policy, or nil if no policy has been set
+func Default() *Guard {
+	return defaultGuard.Load()
+}
+
+// DialContext connects to addr using the default Policy, if one has been set
+func DialContext(ctx context.Context, network string, addr string) (net.Conn, error) {
+	if guard := Default(); guard != nil {
+		return guard.DialContext(ctx, network, addr)
+	}
+	return defaultDialer.DialContext(ctx, network, addr)
+}
+
+// Install makes transport use DialContext, enforcing the default Policy. The idle connections of the transport are
+// closed when the policy is changed.
+func Install(transport *http.Transport) {
+	transport.DialContext = DialContext
+
+	installedLock.Lock()
+	defer installedLock.Unlock()
+	installed = append(installed, transport)
+}
@@ -0,0 +1,131 @@
+package netpolicy_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
+	"github.com/dockerutil/shoutrrr/pkg/util/netpolicy"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestNetPolicy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Network Policy Suite")
+}
+
+var _ = Describe("the network policy", func() {
+	var server *httptest.Server
+	var serverPort string
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, _ *http.Request) {
+			res.WriteHeader(http.StatusNoContent)
+		}))
+		serverURL, _ := url.Parse(server.URL)
+		serverPort = serverURL.Port()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		netpolicy.SetDefault(nil)
+	})
+
+	dial := func(policy netpolicy.Policy, host string) error {
+		conn, err := netpolicy.NewGuard(policy).DialContext(context.Background(), "tcp", net.JoinHostPort(host, serverPort))
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	DescribeTable("detecting internal addresses",
+		func(address string, internal bool) {
+			Expect(netpolicy.IsInternal(net.ParseIP(address))).To(Equal(internal))
+		},
+		Entry("loopback", "127.0.0.1", true),
+		Entry("IPv6 loopback", "::1", true),
+		Entry("private", "10.1.2.3", true),
+		Entry("private 172.16/12", "172.20.0.1", true),
+		Entry("link-local metadata", "169.254.169.254", true),
+		Entry("IPv6 link-local", "fe80::1", true),
+		Entry("IPv6 unique local", "fd00::1", true),
+		Entry("IPv4-mapped loopback", "::ffff:127.0.0.1", true),
+		Entry("unspecified", "0.0.0.0", true),
+		Entry("public", "1.1.1.1", false),
+		Entry("public IPv6", "2606:4700::1111", false),
+	)
+
+	When("dialing", func() {
+		It("should deny loopback addresses by default", func() {
+			Expect(dial(netpolicy.Policy{}, "127.0.0.1")).To(MatchError(netpolicy.ErrDenied))
+		})
+		It("should check the resolved address of host names", func() {
+			Expect(dial(netpolicy.Policy{}, "localhost")).To(MatchError(netpolicy.ErrDenied))
+		})
+		It("should allow private addresses if enabled", func() {
+			Expect(dial(netpolicy.Policy{AllowPrivate: true}, "127.0.0.1")).To(Succeed())
+		})
+		It("should allow private addresses in the allowed hosts", func() {
+			Expect(dial(netpolicy.Policy{AllowHosts: []string{"127.0.0.0/8"}}, "127.0.0.1")).To(Succeed())
+			Expect(dial(netpolicy.Policy{AllowHosts: []string{"localhost"}}, "localhost")).To(Succeed())
+		})
+		It("should deny hosts that are not in the allowed hosts", func() {
+			policy := netpolicy.Policy{AllowPrivate: true, AllowHosts: []string{"example.com"}}
+			Expect(dial(policy, "localhost")).To(MatchError(netpolicy.ErrDenied))
+			Expect(dial(policy, "127.0.0.1")).To(MatchError(netpolicy.ErrDenied))
+		})
+		It("should deny the denied hosts even if private addresses are allowed", func() {
+			Expect(dial(netpolicy.Policy{AllowPrivate: true, DenyHosts: []string{"127.0.0.1"}}, "127.0.0.1")).
+				To(MatchError(netpolicy.ErrDenied))
+			Expect(dial(netpolicy.Policy{AllowPrivate: true, DenyHosts: []string{"LOCALHOST."}}, "localhost")).
+				To(MatchError(netpolicy.ErrDenied))
+		})
+	})
+
+	When("checking host names", func() {
+		guard := netpolicy.NewGuard(netpolicy.Policy{
+			AllowHosts: []string{"*.example.com", "example.org"},
+			DenyHosts:  []string{"secret.example.com"},
+		})
+		It("should match wildcard entries against subdomains", func() {
+			Expect(guard.CheckHost("api.example.com")).To(Succeed())
+			Expect(guard.CheckHost("example.com")).To(MatchError(netpolicy.ErrDenied))
+		})
+		It("should match exact entries", func() {
+			Expect(guard.CheckHost("Example.org")).To(Succeed())
+			Expect(guard.CheckHost("www.example.org")).To(MatchError(netpolicy.ErrDenied))
+		})
+		It("should prefer the denied hosts", func() {
+			Expect(guard.CheckHost("secret.example.com")).To(MatchError(netpolicy.ErrDenied))
+		})
+	})
+
+	When("a default policy has been set", func() {
+		It("should be enforced by the transport shared by the services", func() {
+			Expect(httpclient.Client.Get(server.URL)).Error().NotTo(HaveOccurred())
+
+			netpolicy.SetDefault(&netpolicy.Policy{})
+			_, err := httpclient.Client.Get(server.URL)
+			Expect(err).To(MatchError(netpolicy.ErrDenied))
+
+			netpolicy.SetDefault(nil)
+			Expect(httpclient.Client.Get(server.URL)).Error().NotTo(HaveOccurred())
+		})
+		It("should not be enforced by the default HTTP transport", func() {
+			netpolicy.SetDefault(&netpolicy.Policy{})
+			Expect(http.Get(server.URL)).Error().NotTo(HaveOccurred())
+		})
+		It("should be enforced by DialContext", func() {
+			netpolicy.SetDefault(&netpolicy.Policy{})
+			_, err := netpolicy.DialContext(context.Background(), "tcp", net.JoinHostPort("127.0.0.1", serverPort))
+			Expect(err).To(MatchError(netpolicy.ErrDenied))
+		})
+	})
+})
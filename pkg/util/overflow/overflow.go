@@ -0,0 +1,140 @@
+// Package overflow handles messages that exceed the limits of the services
+package overflow
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/dockerutil/shoutrrr/pkg/format"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+)
+
+// Mode is the strategy used for messages that exceed the limits of a service
+type Mode int
+
+type modeVals struct {
+	Split    Mode
+	Truncate Mode
+	Attach   Mode
+	Error    Mode
+	Enum     types.EnumFormatter
+}
+
+// Modes is the enum helper for populating the Overflow config fields
+var Modes = &modeVals{
+	Split:    0,
+	Truncate: 1,
+	Attach:   2,
+	Error:    3,
+	Enum: format.CreateEnumFormatter(
+		[]string{
+			"Split",
+			"Truncate",
+			"Attach",
+			"Error",
+		}),
+}
+
+func (m Mode) String() string {
+	return Modes.Enum.Print(int(m))
+}
+
+// FromParams returns the mode set by the "overflow" param, or fallback if it's not set or invalid.
+// It is used by the services that does not update their config from the params.
+func FromParams(params *types.Params, fallback Mode) Mode {
+	if params == nil {
+		return fallback
+	}
+	if value, found := (*params)["overflow"]; found {
+		if parsed := Modes.Enum.Parse(value); parsed != format.EnumInvalid {
+			return Mode(parsed)
+		}
+	}
+	return fallback
+}
+
+// ErrMessageTooLong is returned when a message exceeds the limits of the service using the Error mode
+var ErrMessageTooLong = errors.New("the message is too long for the service")
+
+const (
+	// AttachmentName is the file name of the full message sent using the Attach mode
+	AttachmentName = "message.txt"
+	// searchDistance is how far back to search for a whitespace when splitting or truncating messages
+	searchDistance = 100
+)
+
+// MaxLength returns the max length of a single message sent using a service with the limit, or 0 if unlimited
+func MaxLength(limit types.MessageLimit) int {
+	if limit.TotalChunkSize > 0 {
+		return limit.TotalChunkSize
+	}
+	return limit.ChunkSize
+}
+
+// Exceeds returns whether message is longer than a single message sent using a service with the limit
+func Exceeds(message string, limit types.MessageLimit) bool {
+	maxLength := MaxLength(limit)
+	return maxLength > 0 && limit.Unit.Length(message) > maxLength
+}
+
+// Apply returns the messages to send for message using mode. Messages that does not exceed the limit are returned as
+// is. When using Attach, the full message is returned as an attachment that should be sent with the truncated message.
+func Apply(message string, limit types.MessageLimit, mode Mode) (messages []string, attachment *types.Attachment, err error) {
+	if !Exceeds(message, limit) {
+		return []string{message}, nil, nil
+	}
+
+	switch mode {
+	case Modes.Truncate:
+		return []string{Truncate(message, limit)}, nil, nil
+	case Modes.Attach:
+		return []string{Truncate(message, limit)}, Attachment(message), nil
+	case Modes.Error:
+		return nil, nil, fmt.Errorf("%w: %d %v, the max is %d", ErrMessageTooLong,
+			limit.Unit.Length(message), limit.Unit, MaxLength(limit))
+	default:
+		return Split(message, limit), nil, nil
+	}
+}
+
+// Split splits message into parts that each fit in a single message, with "(1/3)" style markers
+func Split(message string, limit types.MessageLimit) []string {
+	partitioner := util.Partitioner{
+		Limits:   types.MessageLimit{ChunkSize: MaxLength(limit), Unit: limit.Unit},
+		Distance: searchDistance,
+		Markers:  true,
+	}
+	return partitioner.Split(message)
+}
+
+// Truncate returns message cut to fit in a single message, ending with an ellipsis and the number of omitted characters
+func Truncate(message string, limit types.MessageLimit) string {
+	if !Exceeds(message, limit) {
+		return message
+	}
+
+	// The omitted count is never more than the length of the message, so reserve room for that many digits
+	size := util.Max(MaxLength(limit)-len(omittedSuffix(utf8.RuneCountInString(message))), 1)
+	partitioner := util.Partitioner{
+		Limits:   types.MessageLimit{ChunkSize: size, ChunkCount: 1, Unit: limit.Unit},
+		Distance: searchDistance,
+	}
+	chunks, rest := partitioner.Partition(message)
+
+	return chunks[0] + omittedSuffix(utf8.RuneCountInString(rest))
+}
+
+// Attachment returns the full message as a text file attachment
+func Attachment(message string) *types.Attachment {
+	return &types.Attachment{
+		Name:     AttachmentName,
+		MimeType: "text/plain; charset=utf-8",
+		Data:     []byte(message),
+	}
+}
+
+func omittedSuffix(omitted int) string {
+	return fmt.Sprintf(" [... %d more characters]", omitted)
+}
@@ -0,0 +1,71 @@
+package overflow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOverflow(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr Overflow Suite")
+}
+
+var limit = types.MessageLimit{ChunkSize: 40, TotalChunkSize: 40, ChunkCount: 1}
+
+const long = "The nightly build failed in the integration tests of the api server, see the logs"
+
+var _ = Describe("the overflow modes", func() {
+	It("should return messages within the limit as they are", func() {
+		for _, mode := range []Mode{Modes.Split, Modes.Truncate, Modes.Attach, Modes.Error} {
+			messages, attachment, err := Apply("short", limit, mode)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(Equal([]string{"short"}))
+			Expect(attachment).To(BeNil())
+		}
+	})
+	When("splitting", func() {
+		It("should return parts with markers that fit the limit", func() {
+			messages, _, err := Apply(long, limit, Modes.Split)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(HaveLen(3))
+			Expect(messages[0]).To(Equal("The nightly build failed in the (1/3)"))
+			for _, message := range messages {
+				Expect(len(message)).To(BeNumerically("<=", 40))
+			}
+		})
+	})
+	When("truncating", func() {
+		It("should end the message with an ellipsis and the omitted count", func() {
+			messages, _, err := Apply(long, limit, Modes.Truncate)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(Equal([]string{"The nightly [... 69 more characters]"}))
+		})
+		It("should measure the length in the unit of the limit", func() {
+			utf16Limit := types.MessageLimit{ChunkSize: 40, Unit: types.UTF16Length}
+			truncated := Truncate(strings.Repeat("😀", 30), utf16Limit)
+			Expect(truncated).To(Equal(strings.Repeat("😀", 7) + " [... 23 more characters]"))
+		})
+	})
+	When("attaching", func() {
+		It("should return the truncated message and the full message as an attachment", func() {
+			messages, attachment, err := Apply(long, limit, Modes.Attach)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(messages).To(Equal([]string{Truncate(long, limit)}))
+			Expect(attachment.Name).To(Equal(AttachmentName))
+			Expect(string(attachment.Data)).To(Equal(long))
+		})
+	})
+	When("using the error mode", func() {
+		It("should return an error", func() {
+			_, _, err := Apply(long, limit, Modes.Error)
+			Expect(errors.Is(err, ErrMessageTooLong)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("81 characters, the max is 40")))
+		})
+	})
+})
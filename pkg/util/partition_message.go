@@ -1,109 +1,75 @@
 package util
 
 import (
-	t "github.com/dockerutil/shoutrrr/pkg/types"
+	"unicode/utf8"
 
-	"strings"
+	t "github.com/dockerutil/shoutrrr/pkg/types"
 )
 
 const ellipsis = " [...]"
 
-// PartitionMessage splits a string into chunks that is at most chunkSize runes, it will search the last distance runes
-// for a whitespace to make the split appear nicer. It will keep adding chunks until it reaches maxCount chunks, or if
-// the total amount of runes in the chunks reach maxTotal.
+// PartitionMessage splits a string into chunks that is at most chunkSize long (measured in limits.Unit), it will
+// search the last distance runes for a whitespace to make the split appear nicer. It will keep adding chunks until it
+// reaches maxCount chunks, or if the total length of the chunks reach maxTotal.
 // The chunks are returned together with the number of omitted runes (that did not fit into the chunks)
 func PartitionMessage(input string, limits t.MessageLimit, distance int) (items []t.MessageItem, omitted int) {
-	runes := []rune(input)
-	chunkOffset := 0
-	maxTotal := Min(len(runes), limits.TotalChunkSize)
-	maxCount := limits.ChunkCount - 1
-
 	if len(input) == 0 {
 		// If the message is empty, return an empty array
-		omitted = 0
 		return
 	}
 
-	for i := 0; i < maxCount; i++ {
-		// If no suitable split point is found, use the chunkSize
-		chunkEnd := chunkOffset + limits.ChunkSize
-		// ... and start next chunk directly after this one
-		nextChunkStart := chunkEnd
-		if chunkEnd >= maxTotal {
-			// The chunk is smaller than the limit, no need to search
-			chunkEnd = maxTotal
-			nextChunkStart = maxTotal
-		} else {
-			for r := 0; r < distance; r++ {
-				rp := chunkEnd - r
-				if runes[rp] == '\n' || runes[rp] == ' ' {
-					// Suitable split point found
-					chunkEnd = rp
-					// Since the split is on a whitespace, skip it in the next chunk
-					nextChunkStart = chunkEnd + 1
-					break
-				}
-			}
-		}
+	// The last chunk is reserved for meta data
+	limits.ChunkCount--
+	if limits.ChunkCount < 1 {
+		return nil, utf8.RuneCountInString(input)
+	}
 
+	partitioner := Partitioner{Limits: limits, Distance: distance}
+	chunks, rest := partitioner.Partition(input)
+	for _, chunk := range chunks {
 		items = append(items, t.MessageItem{
-			Text: string(runes[chunkOffset:chunkEnd]),
+			Text: chunk,
 		})
-
-		chunkOffset = nextChunkStart
-		if chunkOffset >= maxTotal {
-			break
-		}
 	}
 
-	return items, len(runes) - chunkOffset
+	return items, utf8.RuneCountInString(rest)
 }
 
 // Ellipsis returns a string that is at most maxLength characters with a ellipsis appended if the input was longer
 func Ellipsis(text string, maxLength int) string {
-	if len(text) > maxLength {
-		text = text[:maxLength-len(ellipsis)] + ellipsis
-	}
-	return text
+	return truncate(text, maxLength, t.RuneLength)
 }
 
-// MessageItemsFromLines creates a set of MessageItems that is compatible with the supplied limits
-func MessageItemsFromLines(plain string, limits t.MessageLimit) (batches [][]t.MessageItem) {
-	maxCount := limits.ChunkCount
-
-	lines := strings.Split(plain, "\n")
-	batches = make([][]t.MessageItem, 0)
-	items := make([]t.MessageItem, 0, Min(maxCount, len(lines)))
-
-	totalLength := 0
-	for _, line := range lines {
-
-		maxLen := limits.ChunkSize
+// truncate returns a string that is at most maxLength long, measured in unit, with a ellipsis appended if the input
+// was longer. The text is never cut inside a grapheme cluster.
+func truncate(text string, maxLength int, unit t.LengthUnit) string {
+	if unit.Length(text) <= maxLength {
+		return text
+	}
 
-		if len(items) == maxCount || totalLength+maxLen > limits.TotalChunkSize {
-			batches = append(batches, items)
-			items = items[:0]
+	maxText := Max(maxLength-len(ellipsis), 0)
+	bounds := graphemeBounds(text)
+	cut, length := 0, 0
+	for i := 1; i < len(bounds); i++ {
+		length += unit.Length(text[bounds[i-1]:bounds[i]])
+		if length > maxText {
+			break
 		}
+		cut = bounds[i]
+	}
 
-		runes := []rune(line)
-		if len(runes) > maxLen {
-			// Trim and add ellipsis
-			runes = runes[:maxLen-len(ellipsis)]
-			line = string(runes) + ellipsis
-		}
+	return text[:cut] + ellipsis
+}
 
-		if len(runes) < 1 {
-			continue
+// MessageItemsFromLines creates a set of MessageItems that is compatible with the supplied limits
+func MessageItemsFromLines(plain string, limits t.MessageLimit) (batches [][]t.MessageItem) {
+	partitioner := Partitioner{Limits: limits}
+	batches = make([][]t.MessageItem, 0)
+	for _, lines := range partitioner.Lines(plain) {
+		items := make([]t.MessageItem, 0, len(lines))
+		for _, line := range lines {
+			items = append(items, t.MessageItem{Text: line})
 		}
-
-		items = append(items, t.MessageItem{
-			Text: line,
-		})
-
-		totalLength += len(runes)
-	}
-
-	if len(items) > 0 {
 		batches = append(batches, items)
 	}
 
@@ -127,6 +127,20 @@ var _ = Describe("Partition Message", func() {
 					Expect(len(batches)).To(Equal(2))
 				})
 			})
+			It("should keep the items of every batch", func() {
+				lines := make([]string, 25)
+				for i := range lines {
+					lines[i] = fmt.Sprintf("line %d", i+1)
+				}
+				batches := MessageItemsFromLines(strings.Join(lines, "\n"), limits)
+
+				Expect(batches).To(HaveLen(3))
+				Expect(batches[0]).To(HaveLen(10))
+				Expect(batches[0][0].Text).To(Equal("line 1"))
+				Expect(batches[1][0].Text).To(Equal("line 11"))
+				Expect(batches[2]).To(HaveLen(5))
+				Expect(batches[2][4].Text).To(Equal("line 25"))
+			})
 			It("should trim characters above chunk size", func() {
 				hundreds := 42
 				repeat := 21
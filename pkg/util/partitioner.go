@@ -0,0 +1,425 @@
+package util
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	t "github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// inlineMarkupPattern matches the inline markup that should be kept in a single chunk: code spans, Markdown links
+// and images, HTML links and tags, and URLs
+var inlineMarkupPattern = regexp.MustCompile("`[^`\n]+`" +
+	`|!?\[[^\]\n]*\]\([^)\s]*\)|<a\s[^>]*>[^<]*</a>|</?[a-zA-Z][^<>\n]*>|https?://[^\s<>]+`)
+
+// Partitioner splits messages into chunks that fit the limits of a service. The chunks are split at a whitespace if
+// one is found within Distance of the chunk size, and never inside grapheme clusters or inline markup like links and
+// tags. Code blocks that span several chunks are closed at the end of each chunk and reopened in the next one.
+type Partitioner struct {
+	// Limits of the service, with the sizes measured in Limits.Unit
+	Limits t.MessageLimit
+	// Distance is how far back from the chunk size to search for a whitespace to split at
+	Distance int
+	// Markers appends "(1/3)" style continuation markers to the chunks when there is more than one
+	Markers bool
+}
+
+// Partition splits input into at most Limits.ChunkCount chunks with a total size of at most Limits.TotalChunkSize,
+// returning the chunks together with the rest of the input that did not fit
+func (p *Partitioner) Partition(input string) (chunks []string, rest string) {
+	text := newMarkupText(input, p.Limits.Unit)
+	batches, next := p.partition(text, false)
+	return batches[0], input[text.bounds[next]:]
+}
+
+// Split splits the whole input into chunks of at most Limits.ChunkSize, ignoring the other limits
+func (p *Partitioner) Split(input string) []string {
+	unlimited := *p
+	unlimited.Limits.ChunkCount = 0
+	unlimited.Limits.TotalChunkSize = 0
+	chunks, _ := unlimited.Partition(input)
+	return chunks
+}
+
+// Batches splits the whole input into batches of chunks, where each batch is within the Limits.ChunkCount and
+// Limits.TotalChunkSize limits. The markers are numbered across all the batches.
+// At least one batch is always returned, which is empty if the input is empty.
+func (p *Partitioner) Batches(input string) [][]string {
+	batches, _ := p.partition(newMarkupText(input, p.Limits.Unit), true)
+	return batches
+}
+
+// Lines splits input into one chunk per non-empty line, truncating the lines longer than Limits.ChunkSize, and
+// batches the chunks within the Limits.ChunkCount and Limits.TotalChunkSize limits. The markers are numbered across
+// all the batches.
+func (p *Partitioner) Lines(input string) [][]string {
+	lines := strings.Split(input, "\n")
+	digits := 0
+	for {
+		batches, count := p.collectLines(lines, markerLength(digits))
+		if !p.Markers || count < 2 {
+			return batches
+		}
+		if countDigits := len(strconv.Itoa(count)); countDigits > digits {
+			// The reserved room is too small for the number of chunks, try again with room for more digits
+			digits = countDigits
+			continue
+		}
+		return addMarkers(batches, count)
+	}
+}
+
+// collectLines batches the lines, reserving markerLen for the marker of each chunk
+func (p *Partitioner) collectLines(lines []string, markerLen int) (batches [][]string, count int) {
+	limits := p.Limits
+	var batch []string
+	total := 0
+	for _, line := range lines {
+		if limits.ChunkSize > 0 {
+			line = truncate(line, limits.ChunkSize-markerLen, limits.Unit)
+		}
+		if len(line) < 1 {
+			continue
+		}
+
+		length := limits.Unit.Length(line) + markerLen
+		full := limits.ChunkCount > 0 && len(batch) >= limits.ChunkCount
+		if len(batch) > 0 && (full || limits.TotalChunkSize > 0 && total+length > limits.TotalChunkSize) {
+			batches = append(batches, batch)
+			batch, total = nil, 0
+		}
+
+		batch = append(batch, line)
+		total += length
+		count++
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches, count
+}
+
+// partition splits the text into batches, reserving room for the markers and adding them if enabled.
+// Only the first batch is created unless all is set.
+func (p *Partitioner) partition(text *markupText, all bool) (batches [][]string, next int) {
+	digits := 0
+	for {
+		batches, next = p.collect(text, all, markerLength(digits))
+		count := 0
+		for _, batch := range batches {
+			count += len(batch)
+		}
+		if !p.Markers || count < 2 {
+			return batches, next
+		}
+		if countDigits := len(strconv.Itoa(count)); countDigits > digits {
+			// The reserved room is too small for the number of chunks, try again with room for more digits
+			digits = countDigits
+			continue
+		}
+		return addMarkers(batches, count), next
+	}
+}
+
+// markerLength returns the max length of a marker for a chunk count with digits digits, including the separator.
+// The markers are always ASCII, and so have the same length in every unit.
+func markerLength(digits int) int {
+	if digits < 1 {
+		return 0
+	}
+	return len(" (/)") + digits*2
+}
+
+func addMarkers(batches [][]string, count int) [][]string {
+	number := 0
+	for _, batch := range batches {
+		for c, chunk := range batch {
+			number++
+			separator := " "
+			lastLine := chunk[strings.LastIndexByte(chunk, '\n')+1:]
+			if fenceMarker(strings.TrimLeft(lastLine, " ")) != "" {
+				// Keep the closing code fence on a line of its own
+				separator = "\n"
+			}
+			batch[c] = fmt.Sprintf("%s%s(%d/%d)", chunk, separator, number, count)
+		}
+	}
+	return batches
+}
+
+// collect splits the text into batches of chunks, reserving markerLen for the marker of each chunk
+func (p *Partitioner) collect(text *markupText, all bool, markerLen int) (batches [][]string, from int) {
+	last := len(text.bounds) - 1
+	for {
+		var chunks []string
+		chunks, from = p.collectBatch(text, from, markerLen)
+		batches = append(batches, chunks)
+		if !all || from >= last || len(chunks) == 0 {
+			return batches, from
+		}
+	}
+}
+
+// collectBatch creates a single batch of chunks, starting at the boundary with index from
+func (p *Partitioner) collectBatch(text *markupText, from int, markerLen int) (chunks []string, next int) {
+	limits := p.Limits
+	last := len(text.bounds) - 1
+	total := 0
+	prefix := ""
+
+	for from < last && (limits.ChunkCount < 1 || len(chunks) < limits.ChunkCount) {
+		overhead := markerLen + text.unit.Length(prefix)
+		size := math.MaxInt
+		if limits.ChunkSize > 0 {
+			size = limits.ChunkSize - overhead
+		}
+		truncated := false
+		if limits.TotalChunkSize > 0 {
+			if left := limits.TotalChunkSize - total - overhead; left < size {
+				size, truncated = left, true
+			}
+		}
+		if truncated && size < 1 && len(chunks) > 0 {
+			break
+		}
+
+		cut, skip := p.findCut(text, from, size, truncated)
+		closing := ""
+		if fence := text.fenceAround(text.bounds[cut]); fence != nil {
+			// Close the code block at the end of the chunk, and reopen it in the next one
+			closing = "\n" + fence.marker
+			if text.length(from, cut)+len(closing) > size && size > len(closing) {
+				cut, skip = p.findCut(text, from, size-len(closing), truncated)
+				if fence = text.fenceAround(text.bounds[cut]); fence == nil {
+					closing = ""
+				}
+			}
+		}
+
+		chunks = append(chunks, prefix+text.slice(from, cut)+closing)
+		total += overhead + text.length(from, skip) + len(closing)
+
+		prefix = ""
+		if closing != "" {
+			prefix = text.fenceAround(text.bounds[cut]).open + "\n"
+		}
+		from = skip
+	}
+
+	return chunks, from
+}
+
+// findCut returns the boundary index to end the chunk starting at from, which is at most size long, and the index
+// where the next chunk should start. Unless the chunk is truncated, a whitespace within Distance is used if found.
+func (p *Partitioner) findCut(text *markupText, from int, size int, truncated bool) (cut int, next int) {
+	last := len(text.bounds) - 1
+	end := text.lastBound(from, size)
+	if end >= last {
+		return last, last
+	}
+
+	if !truncated {
+		for i := end; i > from && text.length(i, end) < p.Distance; i-- {
+			if text.isSpace(i) && !text.blocked(text.bounds[i]) {
+				// Since the split is on a whitespace, skip it in the next chunk
+				return i, i + 1
+			}
+		}
+	}
+
+	cut = end
+	if start, found := text.blockStart(text.bounds[cut]); found {
+		// Move the whole markup to the next chunk, unless it is longer than a chunk
+		if startBound := text.boundAt(start); startBound > from {
+			cut = startBound
+		}
+	}
+	if cut <= from {
+		// Always include at least one grapheme cluster to make progress
+		cut = from + 1
+	}
+	return cut, cut
+}
+
+// markupText is a text prepared for partitioning
+type markupText struct {
+	text string
+	unit t.LengthUnit
+	// bounds are the byte offsets of the grapheme cluster boundaries
+	bounds []int
+	// lengths are the lengths of the text before each of the bounds
+	lengths []int
+	// spans are the ranges of the text that should not be split
+	spans  []textSpan
+	fences []codeFence
+}
+
+// textSpan is a range of the text where splitting at any offset after start and before end should be avoided
+type textSpan struct {
+	start, end int
+}
+
+type codeFence struct {
+	// start, body, close and end are the byte offsets of the opening line, the content, the closing line and the end
+	// of the closing line
+	start, body, close, end int
+	// open is the opening line, used to reopen the block
+	open string
+	// marker is the fence characters, used to close the block
+	marker string
+}
+
+func newMarkupText(text string, unit t.LengthUnit) *markupText {
+	mt := &markupText{
+		text:   text,
+		unit:   unit,
+		bounds: graphemeBounds(text),
+	}
+
+	mt.lengths = make([]int, len(mt.bounds))
+	for i := 1; i < len(mt.bounds); i++ {
+		mt.lengths[i] = mt.lengths[i-1] + unit.Length(text[mt.bounds[i-1]:mt.bounds[i]])
+	}
+
+	mt.fences, mt.spans = findFences(text)
+	if !strings.ContainsAny(text, "`[<:") {
+		// None of the inline markup can be present
+		return mt
+	}
+	for _, match := range inlineMarkupPattern.FindAllStringIndex(text, -1) {
+		if !mt.inFence(match[0]) {
+			mt.spans = append(mt.spans, textSpan{match[0], match[1]})
+		}
+	}
+
+	return mt
+}
+
+func (mt *markupText) slice(from int, to int) string {
+	return mt.text[mt.bounds[from]:mt.bounds[to]]
+}
+
+// length returns the length of the text between the boundaries with index from and to
+func (mt *markupText) length(from int, to int) int {
+	return mt.lengths[to] - mt.lengths[from]
+}
+
+// lastBound returns the index of the last boundary that is at most size after the boundary with index from
+func (mt *markupText) lastBound(from int, size int) int {
+	last := len(mt.bounds) - 1
+	if size >= mt.length(from, last) {
+		return last
+	}
+	if size < 0 {
+		size = 0
+	}
+	return sort.SearchInts(mt.lengths, mt.lengths[from]+size+1) - 1
+}
+
+// boundAt returns the index of the last boundary at or before the byte offset
+func (mt *markupText) boundAt(offset int) int {
+	return sort.SearchInts(mt.bounds, offset+1) - 1
+}
+
+// isSpace returns whether the grapheme cluster starting at the boundary with index i is a whitespace
+func (mt *markupText) isSpace(i int) bool {
+	switch mt.slice(i, i+1) {
+	case " ", "\n", "\r\n", "\t":
+		return true
+	}
+	return false
+}
+
+// blocked returns whether splitting the text at the byte offset would break any markup
+func (mt *markupText) blocked(offset int) bool {
+	_, found := mt.blockStart(offset)
+	return found
+}
+
+// blockStart returns the start of the markup that would be broken by splitting the text at the byte offset
+func (mt *markupText) blockStart(offset int) (start int, found bool) {
+	for _, span := range mt.spans {
+		if span.start < offset && offset < span.end {
+			return span.start, true
+		}
+	}
+	return 0, false
+}
+
+// inFence returns whether the byte offset is within a code block, including the fences
+func (mt *markupText) inFence(offset int) bool {
+	for _, fence := range mt.fences {
+		if fence.start <= offset && offset < fence.end {
+			return true
+		}
+	}
+	return false
+}
+
+// fenceAround returns the code block that would have to be closed if the text was split at the byte offset
+func (mt *markupText) fenceAround(offset int) *codeFence {
+	for f := range mt.fences {
+		if fence := &mt.fences[f]; fence.body < offset && offset < fence.close {
+			return fence
+		}
+	}
+	return nil
+}
+
+// findFences returns the fenced code blocks of text, together with the spans of their fence lines
+func findFences(text string) (fences []codeFence, spans []textSpan) {
+	var current *codeFence
+	for offset := 0; offset < len(text); {
+		lineEnd := strings.IndexByte(text[offset:], '\n')
+		next := len(text)
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += offset
+			next = lineEnd + 1
+		}
+
+		line := strings.TrimRight(text[offset:lineEnd], "\r")
+		trimmed := strings.TrimLeft(line, " ")
+		marker := fenceMarker(trimmed)
+		if current == nil && marker != "" {
+			current = &codeFence{start: offset, body: next, open: line, marker: marker}
+		} else if current != nil && strings.HasPrefix(marker, current.marker) &&
+			strings.TrimSpace(trimmed[len(marker):]) == "" {
+			current.close, current.end = offset, lineEnd
+			fences = append(fences, *current)
+			spans = append(spans, textSpan{current.start, current.body + 1}, textSpan{offset - 2, lineEnd})
+			current = nil
+		}
+
+		offset = next
+	}
+
+	if current != nil {
+		// Unclosed code blocks continue to the end of the text
+		current.close, current.end = len(text), len(text)
+		fences = append(fences, *current)
+		spans = append(spans, textSpan{current.start, current.body + 1})
+	}
+
+	return fences, spans
+}
+
+// fenceMarker returns the backticks or tildes starting a code fence line, or an empty string if it is not a fence
+func fenceMarker(line string) string {
+	for _, char := range []byte{'`', '~'} {
+		count := 0
+		for count < len(line) && line[count] == char {
+			count++
+		}
+		if count >= 3 {
+			return line[:count]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,112 @@
+package util
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("the Partitioner", func() {
+	split := func(input string, size int, unit types.LengthUnit) []string {
+		partitioner := Partitioner{Limits: types.MessageLimit{ChunkSize: size, Unit: unit}, Distance: size}
+		chunks := partitioner.Split(input)
+		for _, chunk := range chunks {
+			Expect(utf8.ValidString(chunk)).To(BeTrue(), chunk)
+			Expect(unit.Length(chunk)).To(BeNumerically("<=", size), chunk)
+		}
+		return chunks
+	}
+
+	When("measuring the size in bytes", func() {
+		It("should not split multi-byte runes", func() {
+			chunks := split("aåäöåäö", 4, types.ByteLength)
+			Expect(strings.Join(chunks, "")).To(Equal("aåäöåäö"))
+			Expect(chunks).To(Equal([]string{"aå", "äö", "åä", "ö"}))
+		})
+	})
+	When("measuring the size in UTF-16 units", func() {
+		It("should count runes outside the BMP as two units", func() {
+			Expect(split("a😀b😀", 3, types.UTF16Length)).To(Equal([]string{"a😀", "b😀"}))
+		})
+	})
+	When("the text contains grapheme clusters", func() {
+		It("should not separate combining marks from their base", func() {
+			combined := "e\u0301"
+			chunks := split(strings.Repeat(combined, 4), 3, types.RuneLength)
+			Expect(chunks).To(Equal([]string{combined, combined, combined, combined}))
+		})
+		It("should keep flags and joined emoji together", func() {
+			Expect(split("🇸🇪🇫🇮", 3, types.RuneLength)).To(Equal([]string{"🇸🇪", "🇫🇮"}))
+			family := "👩\u200d👩\u200d👧"
+			Expect(split("a"+family, 5, types.RuneLength)).To(Equal([]string{"a", family}))
+		})
+	})
+	When("the text contains markup", func() {
+		It("should not split inside links", func() {
+			chunks := split("see [the docs](https://example.com) now", 31, types.RuneLength)
+			Expect(chunks).To(Equal([]string{"see", "[the docs](https://example.com)", "now"}))
+		})
+		It("should not split inside HTML tags", func() {
+			chunks := split(`click <a href="https://example.com">here</a>`, 40, types.RuneLength)
+			Expect(chunks).To(Equal([]string{"click", `<a href="https://example.com">here</a>`}))
+		})
+		It("should close and reopen split code blocks", func() {
+			input := "Output:\n```go\nfirst()\nsecond()\nthird()\n```\nDone"
+			chunks := split(input, 28, types.RuneLength)
+			Expect(chunks).To(Equal([]string{
+				"Output:\n```go\nfirst()\n```",
+				"```go\nsecond()\nthird()\n```",
+				"Done",
+			}))
+		})
+	})
+	When("adding markers", func() {
+		It("should number the chunks", func() {
+			partitioner := Partitioner{Limits: types.MessageLimit{ChunkSize: 12}, Distance: 12, Markers: true}
+			chunks := partitioner.Split("one two three four")
+			Expect(chunks).To(Equal([]string{"one (1/4)", "two (2/4)", "three (3/4)", "four (4/4)"}))
+		})
+		It("should not add a marker to a single chunk", func() {
+			partitioner := Partitioner{Limits: types.MessageLimit{ChunkSize: 12}, Markers: true}
+			Expect(partitioner.Split("one")).To(Equal([]string{"one"}))
+		})
+		It("should number the chunks across batches", func() {
+			limits := types.MessageLimit{ChunkSize: 10, TotalChunkSize: 20, ChunkCount: 2}
+			partitioner := Partitioner{Limits: limits, Distance: 10, Markers: true}
+			batches := partitioner.Batches("aaa bbb ccc")
+			Expect(batches).To(Equal([][]string{{"aaa (1/3)", "bbb (2/3)"}, {"ccc (3/3)"}}))
+		})
+	})
+	When("splitting by lines", func() {
+		It("should start a new batch and total when a limit is reached", func() {
+			limits := types.MessageLimit{ChunkSize: 10, TotalChunkSize: 12, ChunkCount: 2}
+			partitioner := Partitioner{Limits: limits}
+			batches := partitioner.Lines("aaaa\nbbbb\ncccc\n\ndddd\neeeeeeee\nfffff")
+			Expect(batches).To(Equal([][]string{{"aaaa", "bbbb"}, {"cccc", "dddd"}, {"eeeeeeee"}, {"fffff"}}))
+		})
+		It("should number the lines across batches", func() {
+			limits := types.MessageLimit{ChunkSize: 14, TotalChunkSize: 30, ChunkCount: 2}
+			partitioner := Partitioner{Limits: limits, Markers: true}
+			batches := partitioner.Lines("aaa\nbbb\nccc very long line")
+			Expect(batches).To(Equal([][]string{{"aaa (1/3)", "bbb (2/3)"}, {"cc [...] (3/3)"}}))
+		})
+	})
+	When("the total size is limited", func() {
+		It("should return the rest of the input", func() {
+			limits := types.MessageLimit{ChunkSize: 4, TotalChunkSize: 10, ChunkCount: 5}
+			partitioner := Partitioner{Limits: limits}
+			chunks, rest := partitioner.Partition("ååååååååååååå")
+			Expect(chunks).To(Equal([]string{"åååå", "åååå", "åå"}))
+			Expect(rest).To(Equal("ååå"))
+		})
+	})
+	When("truncating with an ellipsis", func() {
+		It("should not cut multi-byte runes", func() {
+			Expect(Ellipsis("åäöåäöåäöåäö", 8)).To(Equal("åä [...]"))
+			Expect(Ellipsis("åäö", 8)).To(Equal("åäö"))
+		})
+	})
+})
@@ -0,0 +1,152 @@
+// Package priority maps the service independent notification priorities to the native priorities of the services
+package priority
+
+import (
+	"net/url"
+
+	"github.com/dockerutil/shoutrrr/pkg/format"
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+// Priority is the service independent urgency of a notification
+type Priority int
+
+type priorityVals struct {
+	Unset  Priority
+	Min    Priority
+	Low    Priority
+	Normal Priority
+	High   Priority
+	Urgent Priority
+	Enum   types.EnumFormatter
+}
+
+// Priorities is the enum helper for the service independent priorities
+var Priorities = &priorityVals{
+	Unset:  0,
+	Min:    1,
+	Low:    2,
+	Normal: 3,
+	High:   4,
+	Urgent: 5,
+	Enum: format.CreateEnumFormatter(
+		[]string{
+			"",
+			"Min",
+			"Low",
+			"Normal",
+			"High",
+			"Urgent",
+		}),
+}
+
+func (p Priority) String() string {
+	return Priorities.Enum.Print(int(p))
+}
+
+// Param is the key of the param used to set the priority of a notification
+const Param = "priority"
+
+// Parse returns the priority matching the (case-insensitive) name, or false if it is not a service independent priority
+func Parse(name string) (Priority, bool) {
+	parsed := Priorities.Enum.Parse(name)
+	if parsed == format.EnumInvalid || Priority(parsed) == Priorities.Unset {
+		return Priorities.Unset, false
+	}
+	return Priority(parsed), true
+}
+
+// FromLevel returns the priority used for messages with the level, or Unset for the Unknown level
+func FromLevel(level types.MessageLevel) Priority {
+	switch level {
+	case types.Debug:
+		return Priorities.Low
+	case types.Info:
+		return Priorities.Normal
+	case types.Warning:
+		return Priorities.High
+	case types.Error:
+		return Priorities.Urgent
+	default:
+		return Priorities.Unset
+	}
+}
+
+// FromItems returns the highest priority of the levels of the items, or Unset if none of them has a known level
+func FromItems(items []types.MessageItem) Priority {
+	highest := Priorities.Unset
+	for _, item := range items {
+		if itemPriority := FromLevel(item.Level); itemPriority > highest {
+			highest = itemPriority
+		}
+	}
+	return highest
+}
+
+// WithItemsLevel returns params with the priority param set from the levels of the items, unless it is already set.
+// The params are copied before being changed.
+func WithItemsLevel(params *types.Params, items []types.MessageItem) *types.Params {
+	itemsPriority := FromItems(items)
+	if itemsPriority == Priorities.Unset {
+		return params
+	}
+	if params != nil {
+		if _, found := (*params)[Param]; found {
+			return params
+		}
+	}
+
+	updated := types.Params{}
+	if params != nil {
+		for key, value := range *params {
+			updated[key] = value
+		}
+	}
+	updated[Param] = itemsPriority.String()
+	return &updated
+}
+
+// Mapping is the native priority values of a service for each of the service independent priorities
+type Mapping map[Priority]string
+
+// Mapper is implemented by the services that translate the service independent priorities into their native priority
+type Mapper interface {
+	PriorityMapping() Mapping
+}
+
+// Translate returns the native value if value is the name of a service independent priority, or value as is otherwise
+func (mapping Mapping) Translate(value string) string {
+	if parsed, ok := Parse(value); ok {
+		if native, found := mapping[parsed]; found {
+			return native
+		}
+	}
+	return value
+}
+
+// Params returns params with the priority param translated to the native value.
+// The params are copied before being changed.
+func (mapping Mapping) Params(params *types.Params) *types.Params {
+	if params == nil {
+		return nil
+	}
+	value, found := (*params)[Param]
+	if !found || mapping.Translate(value) == value {
+		return params
+	}
+
+	updated := make(types.Params, len(*params))
+	for key, value := range *params {
+		updated[key] = value
+	}
+	updated[Param] = mapping.Translate(value)
+	return &updated
+}
+
+// Query translates the priority value of the query to the native value, and returns the query
+func (mapping Mapping) Query(query url.Values) url.Values {
+	if value := query.Get(Param); value != "" {
+		query.Set(Param, mapping.Translate(value))
+	}
+	return query
+}
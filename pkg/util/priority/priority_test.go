@@ -0,0 +1,77 @@
+package priority
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestPriority(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Shoutrrr Priority Suite")
+}
+
+var mapping = Mapping{
+	Priorities.Min:    "0",
+	Priorities.Low:    "2",
+	Priorities.Normal: "5",
+	Priorities.High:   "8",
+	Priorities.Urgent: "10",
+}
+
+var _ = Describe("the priorities", func() {
+	When("parsing a name", func() {
+		It("should ignore the case", func() {
+			parsed, ok := Parse("URGENT")
+			Expect(ok).To(BeTrue())
+			Expect(parsed).To(Equal(Priorities.Urgent))
+		})
+		It("should not accept unknown or empty names", func() {
+			for _, name := range []string{"", "critical", "5"} {
+				_, ok := Parse(name)
+				Expect(ok).To(BeFalse(), name)
+			}
+		})
+	})
+	When("mapping the message levels", func() {
+		It("should use the highest level of the items", func() {
+			items := []types.MessageItem{{Level: types.Info}, {Level: types.Warning}, {Level: types.Debug}}
+			Expect(FromItems(items)).To(Equal(Priorities.High))
+		})
+		It("should be unset if no item has a known level", func() {
+			Expect(FromItems([]types.MessageItem{{Text: "message"}})).To(Equal(Priorities.Unset))
+		})
+		It("should set the param without changing the original params", func() {
+			params := types.Params{"title": "Deploy"}
+			updated := WithItemsLevel(&params, []types.MessageItem{{Level: types.Error}})
+			Expect(*updated).To(Equal(types.Params{"title": "Deploy", "priority": "Urgent"}))
+			Expect(params).NotTo(HaveKey(Param))
+		})
+		It("should not replace a priority param", func() {
+			params := types.Params{"priority": "low"}
+			Expect(WithItemsLevel(&params, []types.MessageItem{{Level: types.Error}})).To(Equal(&params))
+		})
+	})
+	When("translating to the native values", func() {
+		It("should translate the service independent names", func() {
+			Expect(mapping.Translate("high")).To(Equal("8"))
+		})
+		It("should keep the native values as they are", func() {
+			Expect(mapping.Translate("7")).To(Equal("7"))
+		})
+		It("should translate the params without changing the original params", func() {
+			params := types.Params{"priority": "min"}
+			Expect(*mapping.Params(&params)).To(Equal(types.Params{"priority": "0"}))
+			Expect(params["priority"]).To(Equal("min"))
+			Expect(mapping.Params(nil)).To(BeNil())
+		})
+		It("should translate the query", func() {
+			query := url.Values{"priority": {"Normal"}, "title": {"low"}}
+			Expect(mapping.Query(query)).To(Equal(url.Values{"priority": {"5"}, "title": {"low"}}))
+		})
+	})
+})
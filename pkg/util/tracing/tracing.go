@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+type tracerHolder struct {
+	tracer types.Tracer
+}
+
+var defaultTracer atomic.Pointer[tracerHolder]
+
+type tracerKey struct{}
+
+// SetTracer sets the process-wide Tracer used for the HTTP requests made using a Transport, such as the requests made
+// by the services using httpclient.Client, unless the request context has a Tracer set using WithTracer. Passing nil
+// disables tracing for the requests without one.
+func SetTracer(tracer types.Tracer) {
+	if tracer == nil {
+		defaultTracer.Store(nil)
+		return
+	}
+	defaultTracer.Store(&tracerHolder{tracer: tracer})
+}
+
+// WithTracer returns a copy of ctx using tracer for the HTTP requests made using a Transport with the context, instead
+// of the Tracer set by SetTracer
+func WithTracer(ctx context.Context, tracer types.Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, &tracerHolder{tracer: tracer})
+}
+
+// Tracer returns the process-wide Tracer used for HTTP requests, or nil if none has been set
+func Tracer() types.Tracer {
+	if holder := defaultTracer.Load(); holder != nil {
+		return holder.tracer
+	}
+	return nil
+}
+
+// Transport is a http.RoundTripper that creates a span around each round-trip, using the Tracer of the request context
+// set by WithTracer, or else the one set by SetTracer.
+// The span is started using the context of the request, making it a child of any span in that context.
+type Transport struct {
+	// Base is the RoundTripper used to make the requests. If nil, http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+// Wrap returns a Transport that traces the round-trips made using base
+func Wrap(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip executes a single HTTP transaction using the Base RoundTripper
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := transport.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	tracer := Tracer()
+	if holder, found := req.Context().Value(tracerKey{}).(*tracerHolder); found {
+		tracer = holder.tracer
+	}
+	if tracer == nil {
+		return base.RoundTrip(req)
+	}
+
+	ctx, span := tracer.StartSpan(req.Context(), types.SpanHTTP, types.SpanAttributes{
+		Scheme: req.URL.Scheme,
+		Target: req.URL.Host,
+		Method: req.Method,
+	})
+
+	res, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.End(types.SpanStatusError, err)
+		return nil, err
+	}
+
+	span.End(res.Status, nil)
+	return res, nil
+}
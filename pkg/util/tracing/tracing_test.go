@@ -0,0 +1,113 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+	"github.com/dockerutil/shoutrrr/pkg/util/tracing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTracing(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing Suite")
+}
+
+var _ = Describe("the tracing transport", func() {
+	var server *httptest.Server
+	var tracer *mockTracer
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.WriteHeader(http.StatusAccepted)
+		}))
+		tracer = &mockTracer{}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		tracing.SetTracer(nil)
+	})
+
+	It("should not trace requests unless a tracer has been set", func() {
+		client := http.Client{Transport: tracing.Wrap(nil)}
+		_, err := client.Post(server.URL, "text/plain", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tracer.attributes).To(BeEmpty())
+	})
+
+	It("should trace requests made using the transport", func() {
+		client := http.Client{Transport: tracing.Wrap(nil)}
+		tracing.SetTracer(tracer)
+		req, err := http.NewRequestWithContext(context.WithValue(context.Background(), parentKey{}, "send"), http.MethodPost, server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tracer.attributes).To(HaveLen(1))
+		Expect(tracer.attributes[0].Scheme).To(Equal("http"))
+		Expect(tracer.attributes[0].Target).To(Equal(server.Listener.Addr().String()))
+		Expect(tracer.attributes[0].Method).To(Equal(http.MethodPost))
+		Expect(tracer.parents).To(Equal([]interface{}{"send"}))
+		Expect(tracer.statuses).To(Equal([]string{"202 Accepted"}))
+	})
+
+	It("should trace requests using the tracer of the request context", func() {
+		client := http.Client{Transport: tracing.Wrap(nil)}
+		tracing.SetTracer(&mockTracer{})
+		req, err := http.NewRequestWithContext(tracing.WithTracer(context.Background(), tracer), http.MethodGet, server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tracer.statuses).To(Equal([]string{"202 Accepted"}))
+	})
+
+	It("should not trace requests whose context disables tracing", func() {
+		client := http.Client{Transport: tracing.Wrap(nil)}
+		tracing.SetTracer(tracer)
+		req, err := http.NewRequestWithContext(tracing.WithTracer(context.Background(), nil), http.MethodGet, server.URL, nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = client.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tracer.attributes).To(BeEmpty())
+	})
+
+	It("should not trace requests made using the default client", func() {
+		tracing.SetTracer(tracer)
+		_, err := http.Post(server.URL, "text/plain", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tracer.attributes).To(BeEmpty())
+	})
+
+	It("should end the span with the error if the request fails", func() {
+		client := http.Client{Transport: tracing.Wrap(nil)}
+		tracing.SetTracer(tracer)
+		server.Close()
+		_, err := client.Get(server.URL)
+		Expect(err).To(HaveOccurred())
+		Expect(tracer.statuses).To(Equal([]string{types.SpanStatusError}))
+	})
+})
+
+type parentKey struct{}
+
+type mockTracer struct {
+	attributes []types.SpanAttributes
+	parents    []interface{}
+	statuses   []string
+}
+
+func (tracer *mockTracer) StartSpan(ctx context.Context, name string, attributes types.SpanAttributes) (context.Context, types.Span) {
+	Expect(name).To(Equal(types.SpanHTTP))
+	tracer.attributes = append(tracer.attributes, attributes)
+	tracer.parents = append(tracer.parents, ctx.Value(parentKey{}))
+	return ctx, tracer
+}
+
+func (tracer *mockTracer) End(status string, _ error) {
+	tracer.statuses = append(tracer.statuses, status)
+}
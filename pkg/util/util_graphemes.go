@@ -0,0 +1,65 @@
+package util
+
+import "unicode"
+
+const zeroWidthJoiner = '\u200d'
+
+// graphemeBounds returns the byte offsets of the grapheme cluster boundaries of text, including 0 and len(text).
+// The clusters are approximated by keeping combining marks, joiners, variation selectors, emoji modifiers and tags
+// together with the preceding rune, as well as CR LF and pairs of regional indicators (flags).
+func graphemeBounds(text string) []int {
+	bounds := make([]int, 0, len(text)+1)
+	prev := rune(-1)
+	regional := 0
+	for i, r := range text {
+		if prev < 0 || !extendsCluster(prev, r, regional) {
+			bounds = append(bounds, i)
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		} else {
+			regional = 0
+		}
+		prev = r
+	}
+	return append(bounds, len(text))
+}
+
+// extendsCluster returns whether r belongs to the same grapheme cluster as prev, preceded by regional indicators
+func extendsCluster(prev rune, r rune, regional int) bool {
+	if prev == '\r' {
+		return r == '\n'
+	}
+	if prev == '\n' || r == '\n' || r == '\r' {
+		return false
+	}
+	if prev == zeroWidthJoiner || isExtending(r) {
+		return true
+	}
+	return isRegionalIndicator(r) && regional%2 == 1
+}
+
+// isExtending returns whether r modifies the preceding rune instead of starting a new grapheme cluster
+func isExtending(r rune) bool {
+	switch {
+	case r < 0x300:
+		// Below the first combining marks
+		return false
+	case r == zeroWidthJoiner:
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0xe0100 && r <= 0xe01ef:
+		// Variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff:
+		// Emoji skin tone modifiers
+		return true
+	case r >= 0xe0020 && r <= 0xe007f:
+		// Tags, used by subdivision flags
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc)
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
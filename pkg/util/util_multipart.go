@@ -0,0 +1,27 @@
+package util
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+
+	"github.com/dockerutil/shoutrrr/pkg/types"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// WriteFormFile writes the attachment as a file part of a multipart form, using its name and content type
+func WriteFormFile(writer *multipart.Writer, fieldName string, attachment *types.Attachment) error {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(fieldName), quoteEscaper.Replace(attachment.Name)))
+	header.Set("Content-Type", attachment.ContentType())
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(attachment.Data)
+	return err
+}
@@ -13,9 +13,10 @@ func SetLogger(logger types.StdLogger) {
 	defaultRouter.SetLogger(logger)
 }
 
-// Send notifications using a supplied url and message
+// Send notifications using a supplied url and message.
+// The initialized service is cached, and reused for subsequent sends using the same url.
 func Send(rawURL string, message string) error {
-	service, err := defaultRouter.Locate(rawURL)
+	service, err := defaultRouter.LocateCached(rawURL)
 	if err != nil {
 		return err
 	}
@@ -23,6 +24,16 @@ func Send(rawURL string, message string) error {
 	return service.Send(message, &types.Params{})
 }
 
+// Invalidate removes the cached service for the url used by Send, returning whether one was removed
+func Invalidate(rawURL string) bool {
+	return defaultRouter.Invalidate(rawURL)
+}
+
+// InvalidateAll removes all cached services used by Send
+func InvalidateAll() {
+	defaultRouter.InvalidateAll()
+}
+
 // CreateSender returns a notification sender configured according to the supplied URL
 func CreateSender(rawURLs ...string) (*router.ServiceRouter, error) {
 	return router.New(nil, rawURLs...)
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/util/priority"
 	"github.com/spf13/cobra"
 
 	f "github.com/dockerutil/shoutrrr/pkg/format"
@@ -69,7 +70,33 @@ func printDocs(format string, services []string) cli.Result {
 		config := f.GetServiceConfig(service)
 		configNode := f.GetConfigFormat(config)
 		fmt.Println(renderer.RenderTree(configNode, scheme))
+		if mapper, isMapper := service.(priority.Mapper); isMapper {
+			fmt.Println(renderPriorities(format, mapper.PriorityMapping()))
+		}
 	}
 
 	return cli.Success
 }
+
+// renderPriorities renders the native values that the service independent priorities are translated into
+func renderPriorities(format string, mapping priority.Mapping) string {
+	sb := strings.Builder{}
+	if format == "markdown" {
+		sb.WriteString("### Priorities\n\n")
+		sb.WriteString("The `priority` param also accepts the service independent priorities, translated into these values:\n\n")
+		sb.WriteString("| Priority | Value |\n|----------|-------|\n")
+	} else {
+		sb.WriteString("Priorities:\n")
+	}
+
+	for _, name := range priority.Priorities.Enum.Names() {
+		parsed, _ := priority.Parse(name)
+		if format == "markdown" {
+			sb.WriteString(fmt.Sprintf("| %-8s | `%s` |\n", name, mapping[parsed]))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %-8s %s\n", name, mapping[parsed]))
+		}
+	}
+
+	return sb.String()
+}
@@ -36,6 +36,8 @@ func init() {
 	_ = Cmd.MarkFlagRequired("message")
 
 	Cmd.Flags().StringP("title", "t", "", "The title used for services that support it")
+
+	Cmd.Flags().StringArrayP("attach", "a", []string{}, "A file to attach, for services that support attachments")
 }
 
 func logf(format string, a ...interface{}) {
@@ -50,6 +52,7 @@ func run(cmd *cobra.Command) error {
 	urls = dedupe.RemoveDuplicates(urls)
 	message, _ := flags.GetString("message")
 	title, _ := flags.GetString("title")
+	attachPaths, _ := flags.GetStringArray("attach")
 
 	if message == "-" {
 		logf("Reading from STDIN...")
@@ -62,6 +65,15 @@ func run(cmd *cobra.Command) error {
 		message = sb.String()
 	}
 
+	item := types.MessageItem{Text: message}
+	for _, path := range attachPaths {
+		attachment, err := types.AttachmentFromFile(path)
+		if err != nil {
+			return cli.ConfigurationError(fmt.Sprintf("failed to read attachment: %s", err))
+		}
+		item.WithAttachment(attachment)
+	}
+
 	var logger *log.Logger
 
 	if verbose {
@@ -77,6 +89,9 @@ func run(cmd *cobra.Command) error {
 		if title != "" {
 			logf("Title: %v", title)
 		}
+		for _, attachment := range item.Attachments {
+			logf("Attachment: %s (%s, %d byte(s))", attachment.Name, attachment.ContentType(), len(attachment.Data))
+		}
 		logger = log.New(os.Stderr, "SHOUTRRR ", log.LstdFlags)
 	} else {
 		logger = util.DiscardLogger
@@ -90,6 +105,17 @@ func run(cmd *cobra.Command) error {
 		if title != "" {
 			params["title"] = title
 		}
+		if len(item.Attachments) > 0 {
+			// Attachments can only be sent as message items
+			for _, err := range router.Errors(sr.SendItemsTo(sr.Targets(), []types.MessageItem{item}, &params)) {
+				if err != nil {
+					return cli.TaskUnavailable(err.Error())
+				}
+				logf("Notification sent")
+			}
+			return nil
+		}
+
 		errs := sr.SendAsync(message, &params)
 		for err := range errs {
 			if err != nil {
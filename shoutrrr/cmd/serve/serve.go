@@ -0,0 +1,176 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/dockerutil/shoutrrr/internal/dedupe"
+	"github.com/dockerutil/shoutrrr/pkg/router"
+	"github.com/dockerutil/shoutrrr/pkg/server"
+	"github.com/dockerutil/shoutrrr/pkg/util"
+	"github.com/dockerutil/shoutrrr/pkg/util/httpclient"
+	"github.com/dockerutil/shoutrrr/pkg/util/netpolicy"
+	cli "github.com/dockerutil/shoutrrr/shoutrrr/cmd"
+)
+
+// Cmd runs an HTTP API daemon for sending notifications
+var Cmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run an HTTP API for sending notifications",
+	Args:  cobra.NoArgs,
+	RunE:  Run,
+}
+
+func init() {
+	Cmd.Flags().BoolP("verbose", "v", false, "")
+	Cmd.Flags().StringP("listen", "l", "localhost:8080", "The address to listen on")
+	Cmd.Flags().StringP("config", "c", "", "The profile config file containing the named targets")
+	Cmd.Flags().StringArrayP("url", "u", []string{}, "Notification url to use as an unnamed target")
+	Cmd.Flags().BoolP("watch", "w", false, "Reload the targets when the profile config file changes")
+	Cmd.Flags().Duration("shutdown-timeout", 30*time.Second, "The max time to wait for in-flight requests when shutting down")
+	Cmd.Flags().Duration("drain-delay", 0, "The time to keep accepting requests after reporting not ready when shutting down")
+	Cmd.Flags().Bool("allow-private", false, "Allow sending to private, loopback and link-local addresses")
+}
+
+func run(cmd *cobra.Command) error {
+	flags := cmd.Flags()
+	verbose, _ := flags.GetBool("verbose")
+	listen, _ := flags.GetString("listen")
+	configFile, _ := flags.GetString("config")
+	urls, _ := flags.GetStringArray("url")
+	shutdownTimeout, _ := flags.GetDuration("shutdown-timeout")
+	drainDelay, _ := flags.GetDuration("drain-delay")
+	watch, _ := flags.GetBool("watch")
+	allowPrivate, _ := flags.GetBool("allow-private")
+
+	if watch && (configFile == "" || len(urls) > 0) {
+		return cli.InvalidUsage("--watch requires --config, and cannot be used with --url")
+	}
+
+	logger := log.New(os.Stderr, "SHOUTRRR ", log.LstdFlags)
+	serviceLogger := util.DiscardLogger
+	if verbose {
+		serviceLogger = logger
+	}
+
+	var config *server.Config
+	if configFile != "" {
+		var err error
+		if config, err = server.LoadConfig(configFile); err != nil {
+			return cli.ConfigurationError(fmt.Sprintf("error invoking serve: %s", err))
+		}
+	}
+
+	// Private destinations are denied unless explicitly allowed, since the URLs might come from clients.
+	// The policy is set before initializing the services, since some of them connect when initialized.
+	policy := netpolicy.Policy{}
+	if config != nil {
+		if config.Destinations != nil {
+			policy = *config.Destinations
+		}
+		httpclient.SetMaxConnsPerHost(config.MaxConnsPerHost)
+	}
+	policy.AllowPrivate = policy.AllowPrivate || allowPrivate
+	netpolicy.SetDefault(&policy)
+
+	sr, err := router.New(serviceLogger, dedupe.RemoveDuplicates(urls)...)
+	if err != nil {
+		return cli.ConfigurationError(fmt.Sprintf("error invoking serve: %s", err))
+	}
+
+	if configFile != "" {
+		profile, err := router.LoadProfile(configFile)
+		if err == nil {
+			err = sr.AddProfile(profile)
+		}
+		if err != nil {
+			return cli.ConfigurationError(fmt.Sprintf("error invoking serve: %s", err))
+		}
+	}
+
+	if len(sr.Targets()) < 1 {
+		return cli.InvalidUsage("no targets configured, use --config or --url")
+	}
+
+	api, err := server.New(sr, config, logger)
+	if err != nil {
+		return cli.ConfigurationError(fmt.Sprintf("error invoking serve: %s", err))
+	}
+
+	httpServer := &http.Server{
+		Addr:              listen,
+		Handler:           api,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if watch {
+		err := sr.WatchProfile(ctx, configFile, func(err error) {
+			if err != nil {
+				logger.Printf("Failed to reload targets from %v: %v", configFile, err)
+			} else {
+				logger.Printf("Reloaded %d target(s) from %v", len(sr.Targets()), configFile)
+			}
+		})
+		if err != nil {
+			return cli.TaskUnavailable(fmt.Sprintf("error invoking serve: %s", err))
+		}
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Printf("Listening on %v with %d target(s)", listen, len(sr.Targets()))
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return cli.TaskUnavailable(fmt.Sprintf("error running server: %s", err))
+	case <-ctx.Done():
+	}
+
+	// Restore the default signal handling, so that another signal stops the server without waiting
+	stop()
+	api.SetReady(false)
+	if drainDelay > 0 {
+		// Keep serving while load balancers notice that the server is no longer ready
+		logger.Printf("Shutting down in %v, draining requests...", drainDelay)
+		time.Sleep(drainDelay)
+	}
+
+	logger.Println("Shutting down, waiting for in-flight notifications...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return cli.TaskUnavailable(fmt.Sprintf("error shutting down server: %s", err))
+	}
+
+	logger.Println("Server stopped")
+	return nil
+}
+
+// Run the serve command
+func Run(cmd *cobra.Command, _ []string) error {
+	err := run(cmd)
+	if err != nil {
+		if result, ok := err.(cli.Result); ok && result.ExitCode != cli.ExUsage {
+			// If the error is not related to the CLI usage, report error and exit to not invoke cobra error output
+			_, _ = fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(result.ExitCode)
+		}
+	}
+	return err
+}
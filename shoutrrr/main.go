@@ -8,6 +8,7 @@ import (
 	"github.com/dockerutil/shoutrrr/shoutrrr/cmd/docs"
 	"github.com/dockerutil/shoutrrr/shoutrrr/cmd/generate"
 	"github.com/dockerutil/shoutrrr/shoutrrr/cmd/send"
+	"github.com/dockerutil/shoutrrr/shoutrrr/cmd/serve"
 	"github.com/dockerutil/shoutrrr/shoutrrr/cmd/verify"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -25,6 +26,7 @@ func init() {
 	cmd.AddCommand(generate.Cmd)
 	cmd.AddCommand(send.Cmd)
 	cmd.AddCommand(docs.Cmd)
+	cmd.AddCommand(serve.Cmd)
 }
 
 func main() {